@@ -0,0 +1,210 @@
+package restapi
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// mediaRange represents a single media range in an Accept header, as described
+// in RFC 9110, section 12.5.1:
+//
+//	Accept = #( media-range [ weight ] )
+//
+//	media-range = ( "*/*" / ( type "/" "*" ) / ( type "/" subtype ) ) parameters
+//
+// A mediaRange records the position (index) of the range in the header so that
+// ranges with equal weight and specificity may be ordered according to client
+// preference.
+type mediaRange struct {
+	typ     string
+	subtype string
+	params  map[string]string
+	q       float64
+	index   int
+}
+
+// specificity returns a value indicating how specific the media range is.
+// More specific ranges override less specific ranges when determining the
+// weight to be applied to a content type:
+//
+//	*/*                 0
+//	type/*              1
+//	type/subtype        2
+//	type/subtype;p=v    2 + the number of parameters
+func (mr mediaRange) specificity() int {
+	switch {
+	case mr.typ == "*":
+		return 0
+	case mr.subtype == "*":
+		return 1
+	default:
+		return 2 + len(mr.params)
+	}
+}
+
+// matches returns true if the media range matches the specified content type.
+//
+// A range with parameters matches only if every parameter in the range is also
+// present (with the same value) on the content type.  As exceptions, a charset
+// parameter of "utf-8" is matched by a content type that does not specify a
+// charset, since all marshalled content is utf-8 encoded, and a profile
+// parameter of the JSON:API media type is ignored (a server may ignore any
+// profile requested by a client).
+func (mr mediaRange) matches(ct mediaRange) bool {
+	if mr.typ != "*" && mr.typ != ct.typ {
+		return false
+	}
+	if mr.subtype != "*" && mr.subtype != ct.subtype {
+		return false
+	}
+	for k, v := range mr.params {
+		ctv, ok := ct.params[k]
+		switch {
+		case ok && strings.EqualFold(v, ctv):
+			continue
+		case !ok && k == "charset" && strings.EqualFold(v, "utf-8"):
+			continue
+		case !ok && k == "profile" && ct.typ+"/"+ct.subtype == jsonAPIContentType:
+			continue
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// parseMediaRange parses a single element of an Accept header.  Any parameters
+// following the weight (q) parameter are accept-extensions and are ignored.
+//
+// The returned bool is false if the element is not a valid media range.
+func parseMediaRange(s string, index int) (mediaRange, bool) {
+	parts := strings.Split(s, ";")
+
+	typ, subtype, ok := strings.Cut(strings.ToLower(strings.TrimSpace(parts[0])), "/")
+	typ = strings.TrimSpace(typ)
+	subtype = strings.TrimSpace(subtype)
+	if !ok || typ == "" || subtype == "" || (typ == "*" && subtype != "*") {
+		return mediaRange{}, false
+	}
+
+	mr := mediaRange{typ: typ, subtype: subtype, q: 1, index: index}
+	for _, p := range parts[1:] {
+		k, v, ok := strings.Cut(p, "=")
+		if !ok {
+			return mediaRange{}, false
+		}
+		k = strings.ToLower(strings.TrimSpace(k))
+		v = strings.Trim(strings.TrimSpace(v), "\"")
+
+		if k == "q" {
+			q, err := strconv.ParseFloat(v, 64)
+			if err != nil || q < 0 || q > 1 {
+				return mediaRange{}, false
+			}
+			mr.q = q
+			break
+		}
+
+		if mr.params == nil {
+			mr.params = map[string]string{}
+		}
+		mr.params[k] = v
+	}
+
+	return mr, true
+}
+
+// parseAccept parses an Accept header into a slice of media ranges.  Invalid
+// elements in the header are ignored.
+func parseAccept(header string) []mediaRange {
+	result := []mediaRange{}
+	for i, s := range strings.Split(header, ",") {
+		if strings.TrimSpace(s) == "" {
+			continue
+		}
+		if mr, ok := parseMediaRange(s, i); ok {
+			result = append(result, mr)
+		}
+	}
+	return result
+}
+
+// negotiateContentType selects the most acceptable of a list of supported content
+// types according to an Accept header.
+//
+// The supported content types should be provided in order of server preference;
+// if the Accept header is empty the first supported content type is selected.
+//
+// For each supported content type the weight (q-value) of the most specific
+// matching media range in the Accept header is determined; content types with
+// a weight of zero (or with no matching range) are not acceptable.
+//
+// Of the acceptable content types, the type with the highest weight is selected.
+// If more than one type has the same weight, preference is given (in order) to:
+//
+//   - the type matched by the most specific media range;
+//   - the type matched by the media range that appears first in the header;
+//   - the type that appears first in the list of supported content types.
+//
+// The returned bool is false if none of the supported content types is acceptable.
+func negotiateContentType(header string, supported []string) (string, bool) {
+	if strings.TrimSpace(header) == "" {
+		if len(supported) == 0 {
+			return "", false
+		}
+		return supported[0], true
+	}
+
+	type candidate struct {
+		contentType string
+		match       mediaRange
+		order       int
+	}
+
+	ranges := parseAccept(header)
+	candidates := []candidate{}
+	for i, s := range supported {
+		ct, ok := parseMediaRange(s, i)
+		if !ok {
+			continue
+		}
+
+		var (
+			match   mediaRange
+			matched bool
+		)
+		for _, mr := range ranges {
+			if !mr.matches(ct) {
+				continue
+			}
+			if !matched || mr.specificity() > match.specificity() {
+				match = mr
+				matched = true
+			}
+		}
+		if matched && match.q > 0 {
+			candidates = append(candidates, candidate{contentType: s, match: match, order: i})
+		}
+	}
+
+	if len(candidates) == 0 {
+		return "", false
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		switch {
+		case a.match.q != b.match.q:
+			return a.match.q > b.match.q
+		case a.match.specificity() != b.match.specificity():
+			return a.match.specificity() > b.match.specificity()
+		case a.match.index != b.match.index:
+			return a.match.index < b.match.index
+		default:
+			return a.order < b.order
+		}
+	})
+
+	return candidates[0].contentType, true
+}
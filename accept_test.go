@@ -0,0 +1,170 @@
+package restapi
+
+import (
+	"testing"
+
+	"github.com/blugnu/test"
+)
+
+func TestAccept(t *testing.T) {
+	// ARRANGE
+	supported := []string{"application/json", "application/xml", "text/json", "text/xml"}
+
+	testcases := []struct {
+		scenario string
+		exec     func(t *testing.T)
+	}{
+		// parseMediaRange tests
+		{scenario: "parseMediaRange/type and subtype",
+			exec: func(t *testing.T) {
+				// ACT
+				result, ok := parseMediaRange(" Application/JSON ", 1)
+
+				// ASSERT
+				test.IsTrue(t, ok)
+				test.That(t, result).Equals(mediaRange{typ: "application", subtype: "json", q: 1, index: 1})
+			},
+		},
+		{scenario: "parseMediaRange/parameters and weight",
+			exec: func(t *testing.T) {
+				// ACT
+				result, ok := parseMediaRange(`text/json; Charset="utf-8"; q=0.5; ext=ignored`, 0)
+
+				// ASSERT
+				test.IsTrue(t, ok)
+				test.That(t, result).Equals(mediaRange{
+					typ:     "text",
+					subtype: "json",
+					params:  map[string]string{"charset": "utf-8"},
+					q:       0.5,
+				})
+			},
+		},
+		{scenario: "parseMediaRange/invalid",
+			exec: func(t *testing.T) {
+				for _, s := range []string{
+					"",
+					"json",
+					"application/",
+					"*/json",
+					"application/json;q=high",
+					"application/json;q=1.5",
+					"application/json;q=-1",
+					"application/json;charset",
+				} {
+					// ACT
+					_, ok := parseMediaRange(s, 0)
+
+					// ASSERT
+					test.IsFalse(t, ok, s)
+				}
+			},
+		},
+
+		// specificity tests
+		{scenario: "specificity",
+			exec: func(t *testing.T) {
+				// ARRANGE
+				all, _ := parseMediaRange("*/*", 0)
+				typ, _ := parseMediaRange("text/*", 0)
+				subtype, _ := parseMediaRange("text/json", 0)
+				params, _ := parseMediaRange("text/json;charset=utf-8", 0)
+
+				// ACT & ASSERT
+				test.That(t, all.specificity()).Equals(0)
+				test.That(t, typ.specificity()).Equals(1)
+				test.That(t, subtype.specificity()).Equals(2)
+				test.That(t, params.specificity()).Equals(3)
+			},
+		},
+
+		// negotiateContentType tests
+		{scenario: "negotiateContentType",
+			exec: func(t *testing.T) {
+				testcases := []struct {
+					accept string
+					result string
+					ok     bool
+				}{
+					{accept: "", result: "application/json", ok: true},
+					{accept: "*/*", result: "application/json", ok: true},
+					{accept: "application/xml", result: "application/xml", ok: true},
+					{accept: "APPLICATION/XML", result: "application/xml", ok: true},
+					{accept: "text/*", result: "text/json", ok: true},
+					{accept: "text/*, text/json;q=0", result: "text/xml", ok: true},
+					{accept: "text/plain", ok: false},
+					{accept: "application/json;q=0", ok: false},
+					{accept: "*/*;q=0", ok: false},
+					{accept: "*/*, application/json;q=0", result: "application/xml", ok: true},
+					{accept: "application/json;q=0.5, application/xml", result: "application/xml", ok: true},
+					{accept: "text/xml, text/json", result: "text/xml", ok: true},
+					{accept: "application/*, text/json", result: "text/json", ok: true},
+					{accept: "application/json;charset=utf-8", result: "application/json", ok: true},
+					{accept: "application/json;charset=iso-8859-1", ok: false},
+					{accept: "application/json;version=2", ok: false},
+					{accept: "not a media range, application/xml", result: "application/xml", ok: true},
+					{accept: "text/html,application/xhtml+xml,application/json;q=0.9,*/*;q=0.8", result: "application/json", ok: true},
+					{accept: "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8", result: "application/xml", ok: true},
+				}
+				for _, tc := range testcases {
+					// ACT
+					result, ok := negotiateContentType(tc.accept, supported)
+
+					// ASSERT
+					test.That(t, ok, tc.accept).Equals(tc.ok)
+					test.That(t, result, tc.accept).Equals(tc.result)
+				}
+			},
+		},
+		{scenario: "negotiateContentType/parameterised content type",
+			exec: func(t *testing.T) {
+				// ARRANGE
+				supported := []string{"application/json", "application/vnd.acme+json;version=2"}
+
+				// ACT
+				result, ok := negotiateContentType("application/vnd.acme+json;version=2", supported)
+
+				// ASSERT
+				test.IsTrue(t, ok)
+				test.That(t, result).Equals("application/vnd.acme+json;version=2")
+			},
+		},
+		{scenario: "negotiateContentType/json:api profile",
+			exec: func(t *testing.T) {
+				// ARRANGE
+				supported := []string{"application/json", jsonAPIContentType, "application/hal+json"}
+				testcases := []struct {
+					accept string
+					result string
+					ok     bool
+				}{
+					{accept: jsonAPIContentType + `;profile="https://example.com/p"`, result: jsonAPIContentType, ok: true},
+					{accept: jsonAPIContentType + `;ext="https://example.com/e"`, ok: false},
+					{accept: `application/hal+json;profile="https://example.com/p"`, ok: false},
+				}
+				for _, tc := range testcases {
+					// ACT
+					result, ok := negotiateContentType(tc.accept, supported)
+
+					// ASSERT
+					test.That(t, ok, tc.accept).Equals(tc.ok)
+					test.That(t, result, tc.accept).Equals(tc.result)
+				}
+			},
+		},
+		{scenario: "negotiateContentType/no supported content types",
+			exec: func(t *testing.T) {
+				// ACT
+				_, ok := negotiateContentType("", nil)
+
+				// ASSERT
+				test.IsFalse(t, ok)
+			},
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.scenario, func(t *testing.T) {
+			tc.exec(t)
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package restapi
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/blugnu/restapi/internal/bind"
+)
+
+// ParameterError describes a request parameter that could not be bound,
+// identifying where the parameter was provided (path, query or header), the
+// name of the parameter and the reason it is invalid.
+type ParameterError struct {
+	In     string `json:"in" xml:"in"`
+	Name   string `json:"name" xml:"name"`
+	Reason string `json:"reason" xml:"reason"`
+}
+
+// Bind returns a new T with fields bound from the path, query and header
+// parameters of a request.  Fields are identified by struct tags:
+//
+//	path:"name"     // a path value (see http.Request.PathValue)
+//	query:"name"    // a query parameter
+//	header:"Name"   // a request header
+//
+// A field may have tags for more than one source; the field is bound from the
+// first source that is tagged, in the order above.  Fields with no source tag
+// are not bound.
+//
+// If a parameter is not present in the request, any `default` tag on the field
+// provides the value.  For slice fields, repeated parameters provide multiple
+// values and a default may specify multiple comma-separated values.
+//
+// In addition to strings, bools, ints, uints, floats and pointers to any of
+// these, the following types are supported:
+//
+//	time.Time                   // RFC3339, or the layout specified by a `format` tag
+//	time.Duration               // in the format accepted by time.ParseDuration
+//	encoding.TextUnmarshaler    // e.g. uuid.UUID, net.IP
+//
+// If any parameter cannot be bound, an Error with a status of 400 Bad Request
+// is returned, wrapping ErrInvalidParameter, with a "parameters" property
+// listing each invalid parameter (as ParameterErrors).
+//
+// Bind does not validate the bound values; the result may be passed to
+// Validate if required.
+//
+// # panics
+//
+// Bind will panic with ErrInvalidArgument if T is not a struct type.
+//
+// # example
+//
+//	type params struct {
+//	    ID     uuid.UUID `path:"id"`
+//	    Limit  int       `query:"limit" default:"20"`
+//	    Tenant string    `header:"X-Tenant"`
+//	}
+//
+//	func GetThing(ctx context.Context, rq *http.Request) any {
+//	    p, err := restapi.Bind[params](rq)
+//	    if err != nil {
+//	        return err
+//	    }
+//	    ...
+//	}
+func Bind[T any](rq *http.Request) (*T, error) {
+	result := new(T)
+
+	query := rq.URL.Query()
+	err := bind.Struct(result,
+		bind.Source{Tag: "path", Values: func(name string) []string {
+			if v := rq.PathValue(name); v != "" {
+				return []string{v}
+			}
+			return nil
+		}},
+		bind.Source{Tag: "query", Values: func(name string) []string { return query[name] }},
+		bind.Source{Tag: "header", Values: rq.Header.Values},
+	)
+
+	var errs bind.Errors
+	switch {
+	case err == nil:
+		return result, nil
+
+	case errors.As(err, &errs):
+		params := make([]ParameterError, len(errs))
+		for i, fe := range errs {
+			params[i] = ParameterError{In: fe.Source, Name: fe.Name, Reason: fe.Err.Error()}
+		}
+		return nil, BadRequest(ErrInvalidParameter, rq).
+			WithProperty("parameters", params)
+
+	default:
+		panic(fmt.Errorf("%w: Bind: %w", ErrInvalidArgument, err))
+	}
+}
@@ -0,0 +1,102 @@
+package restapi
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/blugnu/test"
+)
+
+func TestBind(t *testing.T) {
+	// ARRANGE
+	type params struct {
+		ID      int           `path:"id"`
+		Limit   int           `query:"limit" default:"20"`
+		Tags    []string      `query:"tag"`
+		Since   time.Time     `query:"since"`
+		Day     *time.Time    `query:"day" format:"2006-01-02"`
+		Timeout time.Duration `query:"timeout" default:"5s"`
+		IP      net.IP        `header:"X-Forwarded-For"`
+		Tenant  string        `header:"X-Tenant"`
+		Unbound string
+	}
+
+	testcases := []struct {
+		scenario string
+		exec     func(t *testing.T)
+	}{
+		{scenario: "all sources",
+			exec: func(t *testing.T) {
+				// ARRANGE
+				rq := httptest.NewRequest(http.MethodGet, "/things/42?tag=a&tag=b&since=2010-09-08T07:06:05Z&day=2010-09-08&Unbound=x", nil)
+				rq.SetPathValue("id", "42")
+				rq.Header.Set("X-Forwarded-For", "10.0.0.1")
+				rq.Header.Set("X-Tenant", "acme")
+				day := time.Date(2010, 9, 8, 0, 0, 0, 0, time.UTC)
+
+				// ACT
+				result, err := Bind[params](rq)
+
+				// ASSERT
+				test.Error(t, err).IsNil()
+				test.That(t, result).Equals(&params{
+					ID:      42,
+					Limit:   20,
+					Tags:    []string{"a", "b"},
+					Since:   time.Date(2010, 9, 8, 7, 6, 5, 0, time.UTC),
+					Day:     &day,
+					Timeout: 5 * time.Second,
+					IP:      net.ParseIP("10.0.0.1"),
+					Tenant:  "acme",
+				})
+			},
+		},
+		{scenario: "invalid parameters",
+			exec: func(t *testing.T) {
+				// ARRANGE
+				rq := httptest.NewRequest(http.MethodGet, "/things/x?limit=ten", nil)
+				rq.SetPathValue("id", "x")
+				rq.Header.Set("X-Forwarded-For", "not-an-ip")
+
+				// ACT
+				result, err := Bind[params](rq)
+
+				// ASSERT
+				test.That(t, result).IsNil()
+				test.Error(t, err).Is(ErrInvalidParameter)
+
+				var apierr *Error
+				test.IsTrue(t, errors.As(err, &apierr))
+				test.That(t, apierr.statusCode).Equals(http.StatusBadRequest)
+
+				params := apierr.properties["parameters"].([]ParameterError)
+				test.That(t, len(params)).Equals(3)
+				test.That(t, params[0].In).Equals("path")
+				test.That(t, params[0].Name).Equals("id")
+				test.That(t, params[1].In).Equals("query")
+				test.That(t, params[1].Name).Equals("limit")
+				test.That(t, params[2].In).Equals("header")
+				test.That(t, params[2].Name).Equals("X-Forwarded-For")
+			},
+		},
+		{scenario: "not a struct",
+			exec: func(t *testing.T) {
+				// ARRANGE
+				defer test.ExpectPanic(ErrInvalidArgument).Assert(t)
+				rq := httptest.NewRequest(http.MethodGet, "/", nil)
+
+				// ACT
+				_, _ = Bind[int](rq)
+			},
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.scenario, func(t *testing.T) {
+			tc.exec(t)
+		})
+	}
+}
@@ -0,0 +1,11 @@
+package body
+
+import "errors"
+
+var (
+	ErrFieldTooLarge      = errors.New("form field too large")
+	ErrFileTooLarge       = errors.New("file too large")
+	ErrMalformedMultipart = errors.New("malformed multipart body")
+	ErrTooManyParts       = errors.New("too many parts")
+	ErrUnmarshal          = errors.New("error unmarshalling request body")
+)
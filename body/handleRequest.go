@@ -0,0 +1,178 @@
+// Package body provides functions for handling request bodies of any content
+// type for which an unmarshalling function is registered, dispatching on the
+// request Content-Type header.
+//
+// Unmarshalling functions are provided for the following content types:
+//
+//	application/json                   // and any +json content type
+//	application/xml                    // and any +xml content type
+//	text/xml
+//	application/x-www-form-urlencoded  // bound to struct fields with `form` tags
+//
+// Additional content types may be supported by registering an unmarshalling
+// function using RegisterUnmarshaller.
+package body
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+
+	"github.com/blugnu/restapi"
+)
+
+// func variables to facilitate testing
+var (
+	ioReadAll = io.ReadAll
+)
+
+// defaultContentType is assumed for a request with a body but no Content-Type
+const defaultContentType = "application/json"
+
+// handle reads the request body and unmarshals it into a value of type T
+// according to the request Content-Type, which is then passed to the supplied
+// function to handle the request.  After being read, the request Body is
+// replaced with a new ReadCloser so that it may be re-read by the handler
+// function if required.
+//
+// If the strict argument is true then the request is required to have a non-empty
+// body which does not contain any fields not expected by the type T.
+//
+// If the strict argument is false then an empty body is allowed (the specified
+// function will be called with a `nil` argument) and any fields in the request
+// body that are not expected by the type T are silently ignored and discarded.
+//
+// In either case, an unmarshalled value is validated using restapi.Validate
+// before being passed to the supplied function.
+func handle[T any](rq *http.Request, strict bool, h func(c *T) any) any {
+	body, err := ioReadAll(rq.Body)
+	if err != nil {
+		return fmt.Errorf("%w: %w", restapi.ErrErrorReadingRequestBody, err) //NOSONAR
+	}
+	defer rq.Body.Close()
+	rq.Body = io.NopCloser(bytes.NewReader(body))
+
+	if len(body) == 0 {
+		// in strict mode, an empty or missing body constitutes a bad request
+		if strict {
+			return restapi.BadRequest(restapi.ErrBodyRequired)
+		}
+		// otherwise an empty body may be expected by the handler so we let the
+		// handler decide what to do with a 'nil body'
+		return h(nil)
+	}
+
+	ct := rq.Header.Get("Content-Type")
+	if ct == "" {
+		ct = defaultContentType
+	}
+	mt, _, err := mime.ParseMediaType(ct)
+	if err != nil {
+		return unsupportedMediaType(fmt.Errorf("%w: %w", restapi.ErrUnsupportedMediaType, err))
+	}
+
+	fn, ok := unmarshal.get(mt)
+	if !ok {
+		return unsupportedMediaType(fmt.Errorf("%w: %s", restapi.ErrUnsupportedMediaType, mt))
+	}
+
+	c := new(T)
+	if err := fn(body, c, strict); err != nil {
+		if errors.Is(err, restapi.ErrUnexpectedField) {
+			return restapi.BadRequest(err)
+		}
+		return restapi.BadRequest(fmt.Errorf("%w: %w", ErrUnmarshal, err))
+	}
+
+	if err := restapi.Validate(c); err != nil {
+		return err
+	}
+
+	return h(c)
+}
+
+// unsupportedMediaType returns a 415 Unsupported Media Type error, listing the
+// supported content types in a "supported" property.
+func unsupportedMediaType(err error) *restapi.Error {
+	return restapi.NewError(http.StatusUnsupportedMediaType, err).
+		WithProperty("supported", SupportedContentTypes())
+}
+
+// HandleRequest reads the request body and unmarshals it into a value of type T
+// according to the request Content-Type, which is then passed to the supplied
+// function to handle the request.  After being read, the request Body is
+// replaced with a new ReadCloser so that it may be re-read by the handler
+// function if required.
+//
+//   - if the request body is empty, the handler function is called with a nil value.
+//
+//   - if the request has a body but no Content-Type, the body is assumed to be
+//     application/json.
+//
+//   - if no unmarshalling function is registered for the request Content-Type, a
+//     415 Unsupported Media Type error is returned.
+//
+//   - if the request body cannot be unmarshalled into a value of type T,
+//     restapi.BadRequest(ErrUnmarshal) is returned.
+//
+//   - if the request body contains fields that are not expected by the handler
+//     function, they are ignored and discarded.
+//
+//   - if the unmarshalled value is not valid (see: restapi.Validate), the error
+//     returned by restapi.Validate is returned (422 Unprocessable Entity).
+//
+// To automatically treat unexpected fields or an empty body as an error, use
+// the StrictRequest function.
+//
+// # example
+//
+//	func PostResource(ctx context.Context, rq *http.Request) any {
+//	  type resource struct {
+//	    Name string `json:"name" xml:"name" form:"name"`
+//	  }
+//	  return body.HandleRequest(rq, func(r *resource) any {
+//	    if r == nil {
+//	      return restapi.BadRequest(restapi.ErrBodyRequired)
+//	    }
+//
+//	    // ... create a new resource with the required name  ...
+//
+//	    return restapi.Created().WithValue(r)
+//	  })
+//	}
+func HandleRequest[T any](rq *http.Request, h func(c *T) any) any {
+	return handle[T](rq, false, h)
+}
+
+// StrictRequest reads the request body and unmarshals it into a value of type T
+// according to the request Content-Type, which is then passed to the supplied
+// function to handle the request.  After being read, the request Body is
+// replaced with a new ReadCloser so that it may be re-read by the handler
+// function if required.
+//
+// The supplied function is not called if:
+//
+//   - the request body is empty; restapi.BadRequest(restapi.ErrBodyRequired)
+//     is returned;
+//
+//   - no unmarshalling function is registered for the request Content-Type; a
+//     415 Unsupported Media Type error is returned;
+//
+//   - the request body cannot be unmarshalled into a value of type T;
+//     restapi.BadRequest(ErrUnmarshal) is returned;
+//
+//   - the request body contains fields that are not expected by the unmarshalled
+//     value type; restapi.BadRequest(restapi.ErrUnexpectedField) is returned.
+//     (unexpected fields are not detected in XML request bodies);
+//
+//   - the unmarshalled value is not valid (see: restapi.Validate); the error
+//     returned by restapi.Validate is returned (422 Unprocessable Entity).
+//
+// To accept requests with no body or which may contain additional fields not
+// supported by the type parameter T, use HandleRequest.
+func StrictRequest[T any](rq *http.Request, h func(c *T) any) any {
+	return handle[T](rq, true, h)
+}
@@ -0,0 +1,246 @@
+package body
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/blugnu/restapi"
+	"github.com/blugnu/test"
+)
+
+func TestHandleRequest(t *testing.T) {
+	// ARRANGE
+	type resource struct {
+		ID   int    `json:"id" xml:"id" form:"id"`
+		Name string `json:"name" xml:"name" form:"name"`
+	}
+
+	request := func(contentType string, body string) *http.Request {
+		rq := &http.Request{
+			Header: http.Header{},
+			Body:   io.NopCloser(bytes.NewReader([]byte(body))),
+		}
+		if contentType != "" {
+			rq.Header.Set("Content-Type", contentType)
+		}
+		return rq
+	}
+
+	testcases := []struct {
+		scenario string
+		exec     func(t *testing.T)
+	}{
+		{scenario: "content types",
+			exec: func(t *testing.T) {
+				testcases := []struct {
+					contentType string
+					body        string
+				}{
+					{contentType: "", body: `{"id":1,"name":"test"}`},
+					{contentType: "application/json", body: `{"id":1,"name":"test"}`},
+					{contentType: "application/json; charset=utf-8", body: `{"id":1,"name":"test"}`},
+					{contentType: "application/vnd.acme+json", body: `{"id":1,"name":"test"}`},
+					{contentType: "application/xml", body: `<resource><id>1</id><name>test</name></resource>`},
+					{contentType: "text/xml", body: `<resource><id>1</id><name>test</name></resource>`},
+					{contentType: "application/atom+xml", body: `<resource><id>1</id><name>test</name></resource>`},
+					{contentType: "application/x-www-form-urlencoded", body: `id=1&name=test`},
+				}
+				for _, tc := range testcases {
+					// ARRANGE
+					rq := request(tc.contentType, tc.body)
+
+					// ACT
+					result := HandleRequest(rq, func(r *resource) any {
+						test.That(t, r, tc.contentType).Equals(&resource{ID: 1, Name: "test"})
+						return http.StatusOK
+					})
+
+					// ASSERT
+					test.That(t, result, tc.contentType).Equals(http.StatusOK)
+
+					// the body may be re-read
+					body, _ := io.ReadAll(rq.Body)
+					test.That(t, string(body), tc.contentType).Equals(tc.body)
+				}
+			},
+		},
+		{scenario: "request body cannot be read",
+			exec: func(t *testing.T) {
+				// ARRANGE
+				ioerr := errors.New("io error")
+				defer test.Using(&ioReadAll, func(io.Reader) ([]byte, error) {
+					return nil, ioerr
+				})()
+
+				// ACT
+				result := HandleRequest(&http.Request{}, func(r *resource) any {
+					return http.StatusOK
+				})
+
+				// ASSERT
+				test.Error(t, result.(error)).Is(restapi.ErrErrorReadingRequestBody)
+			},
+		},
+		{scenario: "request body is empty",
+			exec: func(t *testing.T) {
+				// ARRANGE
+				rq := request("application/unsupported", "")
+
+				// ACT
+				result := HandleRequest(rq, func(r *resource) any {
+					test.That(t, r).IsNil()
+					return http.StatusOK
+				})
+
+				// ASSERT
+				test.That(t, result).Equals(http.StatusOK)
+			},
+		},
+		{scenario: "unsupported media type",
+			exec: func(t *testing.T) {
+				// ARRANGE
+				rq := request("application/cbor", "body")
+
+				// ACT
+				result := HandleRequest(rq, func(r *resource) any {
+					t.Error("handler was called")
+					return http.StatusOK
+				})
+
+				// ASSERT
+				err, isErr := result.(error)
+				test.IsTrue(t, isErr)
+				if isErr {
+					test.Error(t, err).Is(restapi.NewError(http.StatusUnsupportedMediaType, restapi.ErrUnsupportedMediaType))
+				}
+			},
+		},
+		{scenario: "invalid Content-Type",
+			exec: func(t *testing.T) {
+				// ARRANGE
+				rq := request("not a content type", "body")
+
+				// ACT
+				result := HandleRequest(rq, func(r *resource) any {
+					t.Error("handler was called")
+					return http.StatusOK
+				})
+
+				// ASSERT
+				err, isErr := result.(error)
+				test.IsTrue(t, isErr)
+				if isErr {
+					test.Error(t, err).Is(restapi.NewError(http.StatusUnsupportedMediaType, restapi.ErrUnsupportedMediaType))
+				}
+			},
+		},
+		{scenario: "malformed body",
+			exec: func(t *testing.T) {
+				// ARRANGE
+				rq := request("application/json", "anything")
+
+				// ACT
+				result := HandleRequest(rq, func(r *resource) any {
+					t.Error("handler was called")
+					return http.StatusOK
+				})
+
+				// ASSERT
+				err, isErr := result.(error)
+				test.IsTrue(t, isErr)
+				if isErr {
+					test.Error(t, err).Is(restapi.BadRequest(ErrUnmarshal))
+				}
+			},
+		},
+		{scenario: "unknown fields are ignored",
+			exec: func(t *testing.T) {
+				// ARRANGE
+				rq := request("application/x-www-form-urlencoded", "id=1&unknown=2")
+
+				// ACT
+				result := HandleRequest(rq, func(r *resource) any {
+					test.That(t, r).Equals(&resource{ID: 1})
+					return http.StatusOK
+				})
+
+				// ASSERT
+				test.That(t, result).Equals(http.StatusOK)
+			},
+		},
+		{scenario: "strict request/no body",
+			exec: func(t *testing.T) {
+				// ARRANGE
+				rq := request("application/json", "")
+
+				// ACT
+				result := StrictRequest(rq, func(r *resource) any {
+					return http.StatusOK
+				})
+
+				// ASSERT
+				err, isErr := result.(error)
+				test.IsTrue(t, isErr)
+				if isErr {
+					test.Error(t, err).Is(restapi.BadRequest(restapi.ErrBodyRequired))
+				}
+			},
+		},
+		{scenario: "strict request/unknown field in body",
+			exec: func(t *testing.T) {
+				for _, tc := range []struct {
+					contentType string
+					body        string
+				}{
+					{contentType: "application/json", body: `{"id":1,"unknown":2}`},
+					{contentType: "application/x-www-form-urlencoded", body: `id=1&unknown=2`},
+				} {
+					// ARRANGE
+					rq := request(tc.contentType, tc.body)
+
+					// ACT
+					result := StrictRequest(rq, func(r *resource) any {
+						return http.StatusOK
+					})
+
+					// ASSERT
+					err, isErr := result.(error)
+					test.IsTrue(t, isErr, tc.contentType)
+					if isErr {
+						test.Error(t, err, tc.contentType).Is(restapi.BadRequest(restapi.ErrUnexpectedField))
+					}
+				}
+			},
+		},
+		{scenario: "invalid body",
+			exec: func(t *testing.T) {
+				// ARRANGE
+				rq := request("application/x-www-form-urlencoded", "id=1")
+
+				// ACT
+				result := HandleRequest(rq, func(_ *struct {
+					ID   int    `form:"id"`
+					Name string `form:"name" validate:"required"`
+				}) any {
+					t.Error("handler was called")
+					return http.StatusOK
+				})
+
+				// ASSERT
+				err, isErr := result.(error)
+				test.IsTrue(t, isErr)
+				if isErr {
+					test.Error(t, err).Is(restapi.UnprocessableEntity(restapi.ErrValidationFailed))
+				}
+			},
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.scenario, func(t *testing.T) {
+			tc.exec(t)
+		})
+	}
+}
@@ -0,0 +1,266 @@
+package body
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"net/url"
+
+	"github.com/blugnu/restapi"
+	"github.com/blugnu/restapi/internal/bind"
+)
+
+// MultipartLimits specifies the limits applied when reading a multipart/form-data
+// request.  A limit of zero (or less) is not applied.
+type MultipartLimits struct {
+	// MaxFieldSize is the maximum size (in bytes) of the value of a form field
+	MaxFieldSize int64
+
+	// MaxFileSize is the maximum size (in bytes) of an uploaded file
+	MaxFileSize int64
+
+	// MaxParts is the maximum number of parts (fields and files) in the request
+	MaxParts int
+}
+
+// DefaultMultipartLimits are the limits applied by HandleMultipart if no limits
+// are specified in the call.
+var DefaultMultipartLimits = MultipartLimits{
+	MaxFieldSize: 1 << 20,  // 1 MiB
+	MaxFileSize:  32 << 20, // 32 MiB
+	MaxParts:     1000,
+}
+
+// File is a file uploaded in a multipart/form-data request.  The content of
+// the file is streamed from the request body when read; it is not buffered.
+//
+// If the file exceeds the MaxFileSize limit, Read returns a 413 Request Entity
+// Too Large restapi.Error, wrapping ErrFileTooLarge.  If the content of the file
+// is malformed, Read returns a restapi.BadRequest() wrapping ErrMalformedMultipart.
+type File struct {
+	FieldName   string
+	FileName    string
+	ContentType string
+	Header      textproto.MIMEHeader
+	io.Reader
+}
+
+// fileReader reads the content of a file part, enforcing a size limit.
+type fileReader struct {
+	r    io.Reader
+	n    int64
+	max  int64
+	name string
+}
+
+// tooLarge returns the error returned when a file exceeds the size limit.
+func (f *fileReader) tooLarge() error {
+	return restapi.NewError(http.StatusRequestEntityTooLarge,
+		fmt.Errorf("%w: %s: exceeds %d bytes", ErrFileTooLarge, f.name, f.max))
+}
+
+// Read implements io.Reader for a fileReader.  Once the size limit has been
+// exceeded, any further Read returns the error without reading.
+func (f *fileReader) Read(p []byte) (int, error) {
+	if f.max > 0 && f.n > f.max {
+		return 0, f.tooLarge()
+	}
+
+	n, err := f.r.Read(p)
+	f.n += int64(n)
+	if f.max > 0 && f.n > f.max {
+		return n - int(f.n-f.max), f.tooLarge()
+	}
+	if err != nil && !errors.Is(err, io.EOF) {
+		return n, restapi.BadRequest(fmt.Errorf("%w: %s: %w", ErrMalformedMultipart, f.name, err))
+	}
+	return n, err
+}
+
+// Files provides access to the files uploaded in a multipart/form-data request,
+// in the order in which they appear in the request body.
+type Files struct {
+	mr     *multipart.Reader
+	limits MultipartLimits
+	parts  int
+	values url.Values
+	fields any
+	next   *multipart.Part
+}
+
+// nextPart reads the next part from the request body, enforcing the MaxParts
+// limit.  At the end of the body, io.EOF is returned.
+func (f *Files) nextPart() (*multipart.Part, error) {
+	p, err := f.mr.NextPart()
+	switch {
+	case errors.Is(err, io.EOF):
+		return nil, io.EOF
+	case err != nil:
+		return nil, restapi.BadRequest(fmt.Errorf("%w: %w", ErrMalformedMultipart, err))
+	}
+
+	f.parts++
+	if f.limits.MaxParts > 0 && f.parts > f.limits.MaxParts {
+		return nil, restapi.NewError(http.StatusRequestEntityTooLarge,
+			fmt.Errorf("%w: exceeds %d parts", ErrTooManyParts, f.limits.MaxParts))
+	}
+	return p, nil
+}
+
+// readFields reads parts from the request body until a file part is found or
+// the end of the body is reached, binding the values of any form fields to
+// the fields value.  A file part is retained, to be returned by Next().
+func (f *Files) readFields() error {
+	for {
+		p, err := f.nextPart()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if p.FileName() != "" {
+			f.next = p
+			return nil
+		}
+
+		name := p.FormName()
+		r := io.Reader(p)
+		if f.limits.MaxFieldSize > 0 {
+			r = io.LimitReader(p, f.limits.MaxFieldSize+1)
+		}
+		value, err := io.ReadAll(r)
+		if err != nil {
+			return restapi.BadRequest(fmt.Errorf("%w: %s: %w", ErrMalformedMultipart, name, err))
+		}
+		if f.limits.MaxFieldSize > 0 && int64(len(value)) > f.limits.MaxFieldSize {
+			return restapi.NewError(http.StatusRequestEntityTooLarge,
+				fmt.Errorf("%w: %s: exceeds %d bytes", ErrFieldTooLarge, name, f.limits.MaxFieldSize))
+		}
+		f.values.Add(name, string(value))
+
+		if err := bind.Form(f.values, f.fields, false); err != nil {
+			return restapi.BadRequest(fmt.Errorf("%w: %w", ErrUnmarshal, err))
+		}
+	}
+}
+
+// Next returns the next file in the request body.  When there are no further
+// files, io.EOF is returned.
+//
+// Any form fields that follow a file in the request body are bound to the
+// fields value passed to the handler function as they are encountered by Next.
+//
+// Errors returned by Next (other than io.EOF) are restapi.Error values which
+// may be returned by a handler function to produce an appropriate response:
+//
+//   - 400 Bad Request, if the request body is malformed;
+//   - 413 Request Entity Too Large, if the request exceeds any limit.
+func (f *Files) Next() (*File, error) {
+	if f.next == nil {
+		if err := f.readFields(); err != nil {
+			return nil, err
+		}
+	}
+
+	p := f.next
+	if p == nil {
+		return nil, io.EOF
+	}
+	f.next = nil
+
+	return &File{
+		FieldName:   p.FormName(),
+		FileName:    p.FileName(),
+		ContentType: p.Header.Get("Content-Type"),
+		Header:      p.Header,
+		Reader:      &fileReader{r: p, max: f.limits.MaxFileSize, name: p.FileName()},
+	}, nil
+}
+
+// HandleMultipart reads a multipart/form-data request, binding the values of
+// any form fields to a value of type T, which is passed to the supplied function
+// to handle the request together with a Files value providing access to any
+// uploaded files.
+//
+// Form fields are bound to the fields of T identified by `form` tags (or field
+// names) and are bound up to the first file in the request body; clients should
+// send form fields before files.  Files are not buffered; the content of each
+// file is streamed from the request body as it is read by the handler function.
+//
+// The limits applied to the request are specified by an optional MultipartLimits
+// argument; if not specified, DefaultMultipartLimits are applied.
+//
+// The supplied function is not called if:
+//
+//   - the request is not a multipart/form-data request; a 415 Unsupported
+//     Media Type error is returned;
+//
+//   - the request body is malformed or the form fields cannot be bound to T;
+//     restapi.BadRequest() is returned;
+//
+//   - a form field or the number of parts exceeds the specified limits; a
+//     413 Request Entity Too Large error is returned;
+//
+//   - the form fields bound to T are not valid (see: restapi.Validate); the
+//     error returned by restapi.Validate is returned (422 Unprocessable Entity).
+//
+// # example
+//
+//	func PostAvatar(ctx context.Context, rq *http.Request) any {
+//	    type fields struct {
+//	        UserID string `form:"user"`
+//	    }
+//	    return body.HandleMultipart(rq, func(f *fields, files *body.Files) any {
+//	        file, err := files.Next()
+//	        if errors.Is(err, io.EOF) {
+//	            return restapi.BadRequest("an avatar image is required")
+//	        }
+//	        if err != nil {
+//	            return err // e.g. 413 Request Entity Too Large
+//	        }
+//	        if err := store.SaveAvatar(ctx, f.UserID, file); err != nil {
+//	            return err
+//	        }
+//	        return restapi.Created()
+//	    })
+//	}
+func HandleMultipart[T any](rq *http.Request, h func(fields *T, files *Files) any, limits ...MultipartLimits) any {
+	mt, _, err := mime.ParseMediaType(rq.Header.Get("Content-Type"))
+	if err != nil || mt != "multipart/form-data" {
+		return restapi.NewError(http.StatusUnsupportedMediaType,
+			fmt.Errorf("%w: %s", restapi.ErrUnsupportedMediaType, mt)).
+			WithProperty("supported", []string{"multipart/form-data"})
+	}
+
+	mr, err := rq.MultipartReader()
+	if err != nil {
+		return restapi.BadRequest(fmt.Errorf("%w: %w", ErrMalformedMultipart, err))
+	}
+
+	c := new(T)
+	files := &Files{
+		mr:     mr,
+		limits: DefaultMultipartLimits,
+		values: url.Values{},
+		fields: c,
+	}
+	if len(limits) > 0 {
+		files.limits = limits[0]
+	}
+
+	if err := files.readFields(); err != nil {
+		return err
+	}
+
+	if err := restapi.Validate(c); err != nil {
+		return err
+	}
+
+	return h(c, files)
+}
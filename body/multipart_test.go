@@ -0,0 +1,294 @@
+package body
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/blugnu/restapi"
+	"github.com/blugnu/test"
+)
+
+func TestHandleMultipart(t *testing.T) {
+	// ARRANGE
+	type fields struct {
+		Name string   `form:"name"`
+		Tags []string `form:"tag"`
+		Size int      `form:"size"`
+	}
+
+	type part struct {
+		name, filename, content string
+	}
+
+	request := func(parts ...part) *http.Request {
+		buf := &bytes.Buffer{}
+		w := multipart.NewWriter(buf)
+		for _, p := range parts {
+			if p.filename == "" {
+				_ = w.WriteField(p.name, p.content)
+				continue
+			}
+			fw, _ := w.CreateFormFile(p.name, p.filename)
+			_, _ = fw.Write([]byte(p.content))
+		}
+		_ = w.Close()
+
+		return &http.Request{
+			Method: http.MethodPost,
+			Header: http.Header{"Content-Type": []string{w.FormDataContentType()}},
+			Body:   io.NopCloser(buf),
+		}
+	}
+
+	isError := func(t *testing.T, result any, target error) {
+		t.Helper()
+		err, isErr := result.(error)
+		test.IsTrue(t, isErr, "is error")
+		if isErr {
+			test.Error(t, err).Is(target)
+		}
+	}
+
+	testcases := []struct {
+		scenario string
+		exec     func(t *testing.T)
+	}{
+		{scenario: "fields and files",
+			exec: func(t *testing.T) {
+				// ARRANGE
+				rq := request(
+					part{name: "name", content: "upload"},
+					part{name: "tag", content: "a"},
+					part{name: "tag", content: "b"},
+					part{name: "file", filename: "a.txt", content: "content of a"},
+					part{name: "size", content: "2"},
+					part{name: "file", filename: "b.txt", content: "content of b"},
+				)
+
+				// ACT
+				result := HandleMultipart(rq, func(f *fields, files *Files) any {
+					test.That(t, *f).Equals(fields{Name: "upload", Tags: []string{"a", "b"}})
+
+					uploaded := map[string]string{}
+					for {
+						file, err := files.Next()
+						if errors.Is(err, io.EOF) {
+							break
+						}
+						if err != nil {
+							return err
+						}
+						content, _ := io.ReadAll(file)
+						uploaded[file.FileName] = string(content)
+						test.That(t, file.FieldName).Equals("file")
+						test.That(t, file.ContentType).Equals("application/octet-stream")
+					}
+					test.Map(t, uploaded).Equals(map[string]string{"a.txt": "content of a", "b.txt": "content of b"})
+					test.That(t, f.Size, "field following a file").Equals(2)
+					return http.StatusCreated
+				})
+
+				// ASSERT
+				test.That(t, result).Equals(http.StatusCreated)
+			},
+		},
+		{scenario: "not multipart",
+			exec: func(t *testing.T) {
+				// ARRANGE
+				rq := &http.Request{
+					Header: http.Header{"Content-Type": []string{"application/json"}},
+					Body:   io.NopCloser(strings.NewReader("{}")),
+				}
+
+				// ACT
+				result := HandleMultipart(rq, func(*fields, *Files) any {
+					t.Error("handler was called")
+					return nil
+				})
+
+				// ASSERT
+				isError(t, result, restapi.NewError(http.StatusUnsupportedMediaType, restapi.ErrUnsupportedMediaType))
+			},
+		},
+		{scenario: "missing boundary",
+			exec: func(t *testing.T) {
+				// ARRANGE
+				rq := &http.Request{
+					Method: http.MethodPost,
+					Header: http.Header{"Content-Type": []string{"multipart/form-data"}},
+					Body:   io.NopCloser(strings.NewReader("")),
+				}
+
+				// ACT
+				result := HandleMultipart(rq, func(*fields, *Files) any {
+					t.Error("handler was called")
+					return nil
+				})
+
+				// ASSERT
+				isError(t, result, restapi.BadRequest(ErrMalformedMultipart))
+			},
+		},
+		{scenario: "malformed body",
+			exec: func(t *testing.T) {
+				// ARRANGE
+				rq := &http.Request{
+					Method: http.MethodPost,
+					Header: http.Header{"Content-Type": []string{"multipart/form-data; boundary=xyz"}},
+					Body:   io.NopCloser(strings.NewReader("--xyz\r\nnot a header\r\n")),
+				}
+
+				// ACT
+				result := HandleMultipart(rq, func(*fields, *Files) any {
+					t.Error("handler was called")
+					return nil
+				})
+
+				// ASSERT
+				isError(t, result, restapi.BadRequest(ErrMalformedMultipart))
+			},
+		},
+		{scenario: "invalid field value",
+			exec: func(t *testing.T) {
+				// ARRANGE
+				rq := request(part{name: "size", content: "large"})
+
+				// ACT
+				result := HandleMultipart(rq, func(*fields, *Files) any {
+					t.Error("handler was called")
+					return nil
+				})
+
+				// ASSERT
+				isError(t, result, restapi.BadRequest(ErrUnmarshal))
+			},
+		},
+		{scenario: "field too large",
+			exec: func(t *testing.T) {
+				// ARRANGE
+				rq := request(part{name: "name", content: "too long"})
+
+				// ACT
+				result := HandleMultipart(rq, func(*fields, *Files) any {
+					t.Error("handler was called")
+					return nil
+				}, MultipartLimits{MaxFieldSize: 4})
+
+				// ASSERT
+				isError(t, result, restapi.NewError(http.StatusRequestEntityTooLarge, ErrFieldTooLarge))
+			},
+		},
+		{scenario: "too many parts",
+			exec: func(t *testing.T) {
+				// ARRANGE
+				rq := request(
+					part{name: "tag", content: "a"},
+					part{name: "tag", content: "b"},
+					part{name: "tag", content: "c"},
+				)
+
+				// ACT
+				result := HandleMultipart(rq, func(*fields, *Files) any {
+					t.Error("handler was called")
+					return nil
+				}, MultipartLimits{MaxParts: 2})
+
+				// ASSERT
+				isError(t, result, restapi.NewError(http.StatusRequestEntityTooLarge, ErrTooManyParts))
+			},
+		},
+		{scenario: "file too large",
+			exec: func(t *testing.T) {
+				// ARRANGE
+				rq := request(part{name: "file", filename: "a.txt", content: "0123456789"})
+
+				// ACT
+				result := HandleMultipart(rq, func(_ *fields, files *Files) any {
+					file, err := files.Next()
+					test.Error(t, err).IsNil()
+
+					content, err := io.ReadAll(file)
+					test.That(t, string(content)).Equals("01234")
+					return err
+				}, MultipartLimits{MaxFileSize: 5})
+
+				// ASSERT
+				isError(t, result, restapi.NewError(http.StatusRequestEntityTooLarge, ErrFileTooLarge))
+			},
+		},
+		{scenario: "file too large/read after limit exceeded",
+			exec: func(t *testing.T) {
+				// ARRANGE
+				rq := request(part{name: "file", filename: "a.txt", content: "0123456789"})
+
+				// ACT
+				result := HandleMultipart(rq, func(_ *fields, files *Files) any {
+					file, _ := files.Next()
+					_, _ = io.ReadAll(file)
+
+					n, err := file.Read(make([]byte, 4))
+					test.That(t, n).Equals(0)
+					return err
+				}, MultipartLimits{MaxFileSize: 5})
+
+				// ASSERT
+				isError(t, result, restapi.NewError(http.StatusRequestEntityTooLarge, ErrFileTooLarge))
+			},
+		},
+		{scenario: "file truncated",
+			exec: func(t *testing.T) {
+				// ARRANGE
+				rq := &http.Request{
+					Method: http.MethodPost,
+					Header: http.Header{"Content-Type": []string{"multipart/form-data; boundary=xyz"}},
+					Body: io.NopCloser(strings.NewReader("--xyz\r\n" +
+						"Content-Disposition: form-data; name=\"file\"; filename=\"a.txt\"\r\n" +
+						"\r\n" +
+						"truncated content")),
+				}
+
+				// ACT
+				result := HandleMultipart(rq, func(_ *fields, files *Files) any {
+					file, err := files.Next()
+					test.Error(t, err).IsNil()
+
+					_, err = io.ReadAll(file)
+					return err
+				})
+
+				// ASSERT
+				isError(t, result, restapi.BadRequest(ErrMalformedMultipart))
+			},
+		},
+		{scenario: "no files",
+			exec: func(t *testing.T) {
+				// ARRANGE
+				rq := request(part{name: "name", content: "no files"})
+
+				// ACT
+				result := HandleMultipart(rq, func(f *fields, files *Files) any {
+					_, err := files.Next()
+					test.Error(t, err).Is(io.EOF)
+
+					// subsequent calls continue to return io.EOF
+					_, err = files.Next()
+					test.Error(t, err).Is(io.EOF)
+					return f.Name
+				})
+
+				// ASSERT
+				test.That(t, result).Equals("no files")
+			},
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.scenario, func(t *testing.T) {
+			tc.exec(t)
+		})
+	}
+}
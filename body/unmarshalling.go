@@ -0,0 +1,166 @@
+package body
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"mime"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/blugnu/restapi"
+	"github.com/blugnu/restapi/internal/bind"
+)
+
+// UnmarshalFunc is the signature of a function that unmarshals a request body
+// of a particular content type into a value.
+//
+// If strict is true the function should return an error wrapping
+// restapi.ErrUnexpectedField if the body contains any fields that are not
+// supported by the value, where the content type is capable of identifying
+// such fields.
+type UnmarshalFunc = func(data []byte, v any, strict bool) error
+
+// unmarshallers is a registry associating each supported request Content-Type
+// with an appropriate unmarshalling function.
+type unmarshallers struct {
+	mu           sync.RWMutex
+	contentTypes []string
+	funcs        map[string]UnmarshalFunc
+}
+
+// unmarshal is the registry of content types and unmarshalling functions.
+var unmarshal = &unmarshallers{
+	contentTypes: []string{
+		"application/json",
+		"application/xml",
+		"text/xml",
+		"application/x-www-form-urlencoded",
+	},
+	funcs: map[string]UnmarshalFunc{
+		"application/json":                  unmarshalJSON,
+		"application/xml":                   unmarshalXML,
+		"text/xml":                          unmarshalXML,
+		"application/x-www-form-urlencoded": unmarshalForm,
+	},
+}
+
+// get returns the unmarshalling function for a media type.  If no function is
+// registered for the media type but the media type has a structured syntax
+// suffix of +json or +xml (RFC 6839), the function registered for
+// "application/json" or "application/xml" respectively is returned.
+func (u *unmarshallers) get(mediaType string) (UnmarshalFunc, bool) {
+	u.mu.RLock()
+	defer u.mu.RUnlock()
+
+	if fn, ok := u.funcs[mediaType]; ok {
+		return fn, true
+	}
+
+	switch {
+	case strings.HasSuffix(mediaType, "+json"):
+		fn, ok := u.funcs["application/json"]
+		return fn, ok
+	case strings.HasSuffix(mediaType, "+xml"):
+		fn, ok := u.funcs["application/xml"]
+		return fn, ok
+	}
+	return nil, false
+}
+
+// supported returns the registered content types, in the order in which they
+// were registered.
+func (u *unmarshallers) supported() []string {
+	u.mu.RLock()
+	defer u.mu.RUnlock()
+	return slices.Clone(u.contentTypes)
+}
+
+// RegisterUnmarshaller registers a request content type with an unmarshalling
+// function.  If the content type is already registered the existing function
+// is replaced.
+//
+// Any parameters of the content type are ignored; request bodies are matched to
+// an unmarshalling function by media type only.
+//
+// # panics
+//
+// RegisterUnmarshaller will panic with restapi.ErrInvalidArgument if the content
+// type is not a valid media type or the unmarshalling function is nil.
+func RegisterUnmarshaller(contentType string, fn UnmarshalFunc) {
+	mt, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		panic(fmt.Errorf("%w: content type: %w", restapi.ErrInvalidArgument, err))
+	}
+	if fn == nil {
+		panic(fmt.Errorf("%w: unmarshalling function for %s is nil", restapi.ErrInvalidArgument, mt))
+	}
+
+	unmarshal.mu.Lock()
+	defer unmarshal.mu.Unlock()
+	if _, exists := unmarshal.funcs[mt]; !exists {
+		unmarshal.contentTypes = append(unmarshal.contentTypes, mt)
+	}
+	unmarshal.funcs[mt] = fn
+}
+
+// UnregisterUnmarshaller removes a request content type from the registry.
+func UnregisterUnmarshaller(contentType string) {
+	mt, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return
+	}
+
+	unmarshal.mu.Lock()
+	defer unmarshal.mu.Unlock()
+	delete(unmarshal.funcs, mt)
+	unmarshal.contentTypes = slices.DeleteFunc(unmarshal.contentTypes, func(s string) bool { return s == mt })
+}
+
+// SupportedContentTypes returns the registered request content types.
+func SupportedContentTypes() []string {
+	return unmarshal.supported()
+}
+
+// unmarshalJSON unmarshals a JSON request body.
+func unmarshalJSON(data []byte, v any, strict bool) error {
+	dc := json.NewDecoder(bytes.NewReader(data))
+	if strict {
+		dc.DisallowUnknownFields()
+	}
+	if err := dc.Decode(v); err != nil {
+		// the json decoder does not provide a specific error type for unknown
+		// fields so the error message is the only means of identifying them
+		if strings.HasPrefix(err.Error(), "json: unknown field") {
+			return fmt.Errorf("%w: %w", restapi.ErrUnexpectedField, err)
+		}
+		return err
+	}
+	return nil
+}
+
+// unmarshalXML unmarshals an XML request body.  The xml decoder does not
+// support the detection of unknown elements or attributes, so the strict
+// argument is ignored.
+func unmarshalXML(data []byte, v any, _ bool) error {
+	return xml.Unmarshal(data, v)
+}
+
+// unmarshalForm unmarshals an application/x-www-form-urlencoded request body
+// into the fields of a struct, identified by `form` tags (or field names).
+func unmarshalForm(data []byte, v any, strict bool) error {
+	values, err := url.ParseQuery(string(data))
+	if err != nil {
+		return err
+	}
+
+	err = bind.Form(values, v, strict)
+	if errors.Is(err, bind.ErrUnknownField) {
+		return fmt.Errorf("%w: %w", restapi.ErrUnexpectedField, err)
+	}
+	return err
+}
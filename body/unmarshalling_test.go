@@ -0,0 +1,105 @@
+package body
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/blugnu/restapi"
+	"github.com/blugnu/test"
+)
+
+func TestUnmarshalling(t *testing.T) {
+	// ARRANGE
+	testcases := []struct {
+		scenario string
+		exec     func(t *testing.T)
+	}{
+		{scenario: "SupportedContentTypes",
+			exec: func(t *testing.T) {
+				// ACT
+				result := SupportedContentTypes()
+
+				// ASSERT
+				test.That(t, result).Equals([]string{
+					"application/json",
+					"application/xml",
+					"text/xml",
+					"application/x-www-form-urlencoded",
+				})
+			},
+		},
+		{scenario: "RegisterUnmarshaller/UnregisterUnmarshaller",
+			exec: func(t *testing.T) {
+				// ARRANGE
+				cborErr := errors.New("cbor")
+				fn := func([]byte, any, bool) error { return cborErr }
+
+				// ACT
+				RegisterUnmarshaller("Application/CBOR; version=1", fn)
+				defer UnregisterUnmarshaller("application/cbor")
+
+				// ASSERT
+				result, ok := unmarshal.get("application/cbor")
+				test.IsTrue(t, ok)
+				test.Error(t, result(nil, nil, false)).Is(cborErr)
+				test.That(t, SupportedContentTypes()[4]).Equals("application/cbor")
+
+				// ACT
+				UnregisterUnmarshaller("application/cbor")
+
+				// ASSERT
+				_, ok = unmarshal.get("application/cbor")
+				test.IsFalse(t, ok)
+				test.That(t, len(SupportedContentTypes())).Equals(4)
+			},
+		},
+		{scenario: "RegisterUnmarshaller/invalid content type",
+			exec: func(t *testing.T) {
+				// ARRANGE
+				defer test.ExpectPanic(restapi.ErrInvalidArgument).Assert(t)
+
+				// ACT
+				RegisterUnmarshaller("not a content type", unmarshalJSON)
+			},
+		},
+		{scenario: "RegisterUnmarshaller/nil function",
+			exec: func(t *testing.T) {
+				// ARRANGE
+				defer test.ExpectPanic(restapi.ErrInvalidArgument).Assert(t)
+
+				// ACT
+				RegisterUnmarshaller("application/cbor", nil)
+			},
+		},
+		{scenario: "get/structured syntax suffix",
+			exec: func(t *testing.T) {
+				// ACT
+				_, json := unmarshal.get("application/hal+json")
+				_, xml := unmarshal.get("application/rss+xml")
+				_, other := unmarshal.get("application/vnd.acme+yaml")
+
+				// ASSERT
+				test.IsTrue(t, json)
+				test.IsTrue(t, xml)
+				test.IsFalse(t, other)
+			},
+		},
+		{scenario: "unmarshalForm/invalid body",
+			exec: func(t *testing.T) {
+				// ARRANGE
+				v := &struct{ A int }{}
+
+				// ACT
+				err := unmarshalForm([]byte("a=%zz"), v, false)
+
+				// ASSERT
+				test.IsTrue(t, err != nil)
+			},
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.scenario, func(t *testing.T) {
+			tc.exec(t)
+		})
+	}
+}
@@ -0,0 +1,292 @@
+package restapi
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Encoding is a content coding (e.g. "gzip") that may be applied to the
+// content of a response, with a function returning a writer that encodes
+// content written to it.
+//
+// If the writer returned by NewWriter has a Flush() error method, it is
+// called when a streamed response is flushed.
+//
+// Brotli and zstd encodings are provided by the compression package (a separate
+// module, github.com/blugnu/restapi/compression, so that this module does not
+// depend on the packages implementing them).  Other encodings may be provided
+// using third-party packages.
+type Encoding struct {
+	Name      string
+	NewWriter func(io.Writer) (io.WriteCloser, error)
+}
+
+// Compression specifies the compression applied to response content.  Content
+// is compressed only by a Server configured with a Compression (see:
+// DefaultCompression).
+//
+// The encoding of a response is negotiated using the request Accept-Encoding
+// header, respecting q-values; when more than one encoding is equally
+// acceptable the first in Encodings is used.  Compressed responses have a
+// Content-Encoding header.  A Vary: Accept-Encoding header is added to any
+// response with content that may be compressed.
+//
+// A strong entity tag of a compressed response is given a suffix identifying
+// the encoding (e.g. "v1" becomes "v1-gzip"), since the compressed content is
+// a different representation.  The suffix is ignored when comparing the entity
+// tags in an If-Match or If-None-Match request header.
+//
+// Content is not compressed if:
+//
+//   - the response has no content type or the content type matches any of the
+//     Exclude content types (a content type of the form "type/*" matches any
+//     subtype);
+//   - the content is smaller than MinSize (for content of known size);
+//   - the response already has a Content-Encoding header;
+//   - the response is served from an io.ReadSeeker (supporting Range requests).
+type Compression struct {
+	// MinSize is the minimum size (in bytes) of content to be compressed.
+	MinSize int
+
+	// Exclude is a list of content types which are not compressed.
+	Exclude []string
+
+	// Encodings is a list of the encodings supported, in order of preference.
+	Encodings []Encoding
+}
+
+// Encodings supported by DefaultCompression, which may be used to configure
+// a Compression with different encodings or preferences.  The deflate content
+// coding is the zlib format (RFC 9110, 8.4.1.2), not raw deflate.
+var (
+	Deflate = Encoding{Name: "deflate", NewWriter: func(w io.Writer) (io.WriteCloser, error) { return zlib.NewWriterLevel(w, zlib.DefaultCompression) }}
+	Gzip    = Encoding{Name: "gzip", NewWriter: func(w io.Writer) (io.WriteCloser, error) { return gzip.NewWriter(w), nil }}
+)
+
+// DefaultCompression is a Compression suitable for most APIs, supporting the
+// following encodings (in order of preference):
+//
+//	gzip
+//	deflate
+//
+// Content of less than 1 KiB is not compressed, nor is content of types that
+// are typically already compressed (images, audio, video, archives and
+// application/octet-stream) or a text/event-stream.
+//
+// Responses are not compressed unless a Server is configured with a
+// Compression:
+//
+//	api := &restapi.Server{Compression: restapi.DefaultCompression}
+var DefaultCompression = &Compression{
+	MinSize: 1024,
+	Exclude: []string{
+		"application/gzip",
+		"application/octet-stream",
+		"application/zip",
+		"application/zstd",
+		"audio/*",
+		"font/woff",
+		"font/woff2",
+		"image/*",
+		"text/event-stream",
+		"video/*",
+	},
+	Encodings: []Encoding{Gzip, Deflate},
+}
+
+// contentCodings are the names of the registered content codings, recognised
+// (in addition to the names of any configured Encodings) as the suffix of the
+// entity tag of a compressed response.
+var contentCodings = []string{"br", "compress", "deflate", "gzip", "zstd"}
+
+// encodedETag returns the entity tag of a response compressed using a named
+// encoding; a strong entity tag is given a suffix identifying the encoding.  A
+// weak entity tag is returned unchanged.
+func encodedETag(etag string, encoding string) string {
+	if etag == "" || strings.HasPrefix(etag, "W/") {
+		return etag
+	}
+	return etag[:len(etag)-1] + "-" + encoding + `"`
+}
+
+// identityETag returns an entity tag with any suffix identifying the encoding
+// of a compressed response removed (see: encodedETag), and the name of that
+// encoding (empty if the entity tag has no suffix).  A suffix is recognised if
+// it names a registered content coding or any Encoding of a Compression (which
+// may be nil).
+func identityETag(etag string, c *Compression) (string, string) {
+	names := contentCodings
+	if c != nil {
+		names = make([]string, 0, len(contentCodings)+len(c.Encodings))
+		names = append(names, contentCodings...)
+		for _, enc := range c.Encodings {
+			names = append(names, enc.Name)
+		}
+	}
+	for _, name := range names {
+		if suffix := "-" + name + `"`; name != "" && strings.HasSuffix(etag, suffix) {
+			return strings.TrimSuffix(etag, suffix) + `"`, name
+		}
+	}
+	return etag, ""
+}
+
+// excludes returns true if content of a specified type is not to be compressed.
+func (c *Compression) excludes(contentType string) bool {
+	mt, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return true
+	}
+	for _, ex := range c.Exclude {
+		if ex == mt || (strings.HasSuffix(ex, "/*") && strings.HasPrefix(mt, ex[:len(ex)-1])) {
+			return true
+		}
+	}
+	return false
+}
+
+// negotiate returns the most acceptable encoding according to an
+// Accept-Encoding header.  The returned bool is false if no encoding is
+// acceptable (in which case the content is not to be encoded).
+func (c *Compression) negotiate(acceptEncoding string) (Encoding, bool) {
+	q := map[string]float64{}
+	for _, s := range strings.Split(acceptEncoding, ",") {
+		name, params, _ := strings.Cut(s, ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		q[name] = 1
+		if k, v, ok := strings.Cut(strings.TrimSpace(params), "="); ok && strings.TrimSpace(k) == "q" {
+			if f, err := strconv.ParseFloat(strings.TrimSpace(v), 64); err == nil && f >= 0 && f <= 1 {
+				q[name] = f
+			}
+		}
+	}
+
+	var (
+		best   Encoding
+		bestQ  float64
+		wildQ  float64
+		isWild bool
+	)
+	wildQ, isWild = q["*"]
+	for _, enc := range c.Encodings {
+		eq, ok := q[enc.Name]
+		if !ok && isWild {
+			eq, ok = wildQ, true
+		}
+		if ok && eq > bestQ {
+			best, bestQ = enc, eq
+		}
+	}
+	return best, bestQ > 0
+}
+
+// encoding returns the encoding to be applied to a response for a request,
+// and a bool indicating whether the response content may be compressed (and
+// so varies according to the request Accept-Encoding header).
+func (c *Compression) encoding(rq *http.Request, r *Response, h http.Header) (*Encoding, bool) {
+	if c == nil || len(c.Encodings) == 0 || r.identity ||
+		r.ContentType == "" || c.excludes(r.ContentType) ||
+		h.Get("Content-Encoding") != "" {
+		return nil, false
+	}
+
+	if r.serve == nil && len(r.Content) < c.MinSize {
+		return nil, true
+	}
+
+	enc, ok := c.negotiate(rq.Header.Get("Accept-Encoding"))
+	if !ok {
+		return nil, true
+	}
+	return &enc, true
+}
+
+// setContentEncoding sets the Content-Encoding header of a compressed response,
+// adding a suffix identifying the encoding to any strong entity tag.
+func setContentEncoding(h http.Header, encoding string) {
+	h.Set("Content-Encoding", encoding)
+	if etag := h.Get("ETag"); etag != "" {
+		h.Set("ETag", encodedETag(etag, encoding))
+	}
+}
+
+// encode returns content encoded using an encoding.
+func (enc *Encoding) encode(content []byte) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	w, err := enc.NewWriter(buf)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(content); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// encodingWriter is an http.ResponseWriter that encodes content written to
+// the response.  The encoder is initialised when content is first written.
+type encodingWriter struct {
+	http.ResponseWriter
+	enc *Encoding
+	w   io.WriteCloser
+}
+
+// WriteHeader removes any Content-Length header (which would describe the
+// content before encoding) before writing the response status.
+func (ew *encodingWriter) WriteHeader(statusCode int) {
+	ew.Header().Del("Content-Length")
+	ew.ResponseWriter.WriteHeader(statusCode)
+}
+
+// Write encodes content written to the response.
+func (ew *encodingWriter) Write(b []byte) (int, error) {
+	if ew.w == nil {
+		w, err := ew.enc.NewWriter(ew.ResponseWriter)
+		if err != nil {
+			return 0, err
+		}
+		ew.w = w
+	}
+	return ew.w.Write(b)
+}
+
+// FlushError flushes any content buffered by the encoder before flushing the
+// response.
+func (ew *encodingWriter) FlushError() error {
+	if f, ok := ew.w.(interface{ Flush() error }); ok {
+		if err := f.Flush(); err != nil {
+			return err
+		}
+	}
+	if err := http.NewResponseController(ew.ResponseWriter).Flush(); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		return err
+	}
+	return nil
+}
+
+// Close closes the encoder, writing any remaining encoded content to the
+// response.
+func (ew *encodingWriter) Close() error {
+	if ew.w == nil {
+		return nil
+	}
+	return ew.w.Close()
+}
+
+// Unwrap returns the underlying http.ResponseWriter.
+func (ew *encodingWriter) Unwrap() http.ResponseWriter {
+	return ew.ResponseWriter
+}
@@ -0,0 +1,38 @@
+// Package compression provides brotli and zstd encodings for the compression
+// of REST API responses (see: restapi.Compression), implemented by pure-Go
+// packages.  The encodings are provided in a separate module so that the
+// restapi module does not depend on those packages.
+//
+// To compress responses using brotli, zstd, gzip or deflate encoding, configure
+// a restapi.Server with the Default Compression:
+//
+//	api := &restapi.Server{Compression: compression.Default}
+package compression
+
+import (
+	"io"
+
+	"github.com/andybalholm/brotli"
+	"github.com/blugnu/restapi"
+	"github.com/klauspost/compress/zstd"
+)
+
+// Encodings which may be used to configure a restapi.Compression.
+var (
+	Brotli = restapi.Encoding{Name: "br", NewWriter: func(w io.Writer) (io.WriteCloser, error) { return brotli.NewWriter(w), nil }}
+	Zstd   = restapi.Encoding{Name: "zstd", NewWriter: func(w io.Writer) (io.WriteCloser, error) { return zstd.NewWriter(w, zstd.WithEncoderConcurrency(1)) }}
+)
+
+// Default is a restapi.Compression with the minimum size and excluded content
+// types of restapi.DefaultCompression, supporting the following encodings (in
+// order of preference):
+//
+//	zstd
+//	br        // brotli
+//	gzip
+//	deflate
+var Default = &restapi.Compression{
+	MinSize:   restapi.DefaultCompression.MinSize,
+	Exclude:   restapi.DefaultCompression.Exclude,
+	Encodings: []restapi.Encoding{Zstd, Brotli, restapi.Gzip, restapi.Deflate},
+}
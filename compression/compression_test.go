@@ -0,0 +1,74 @@
+package compression
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+	"github.com/blugnu/restapi"
+	"github.com/blugnu/test"
+	"github.com/klauspost/compress/zstd"
+)
+
+func TestDefault(t *testing.T) {
+	// ARRANGE
+	large := strings.Repeat("compressible content ", 100)
+	decoders := map[string]func(io.Reader) (io.Reader, error){
+		"br":      func(r io.Reader) (io.Reader, error) { return brotli.NewReader(r), nil },
+		"zstd":    func(r io.Reader) (io.Reader, error) { return zstd.NewReader(r) },
+		"gzip":    func(r io.Reader) (io.Reader, error) { return gzip.NewReader(r) },
+		"deflate": func(r io.Reader) (io.Reader, error) { return zlib.NewReader(r) },
+	}
+	api := &restapi.Server{Compression: Default}
+	serve := func(acceptEncoding string, result any) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		rq := httptest.NewRequest(http.MethodGet, "/", nil)
+		rq.Header.Set("Accept-Encoding", acceptEncoding)
+		api.HandlerFunc(func(context.Context, *http.Request) any { return result })(rec, rq)
+		return rec
+	}
+
+	testcases := []struct {
+		scenario       string
+		acceptEncoding string
+		result         any
+		encoding       string
+		body           string
+	}{
+		{scenario: "br", acceptEncoding: "br", result: large, encoding: "br", body: `"` + large + `"`},
+		{scenario: "zstd", acceptEncoding: "zstd", result: large, encoding: "zstd", body: `"` + large + `"`},
+		{scenario: "gzip", acceptEncoding: "gzip", result: large, encoding: "gzip", body: `"` + large + `"`},
+		{scenario: "deflate", acceptEncoding: "deflate", result: large, encoding: "deflate", body: `"` + large + `"`},
+		{scenario: "preferred", acceptEncoding: "gzip, deflate, br, zstd", result: large, encoding: "zstd", body: `"` + large + `"`},
+		{scenario: "stream/br", acceptEncoding: "br",
+			result:   restapi.Stream(func(yield func(int) bool) { _ = yield(1) && yield(2) }),
+			encoding: "br",
+			body:     "[1,2]",
+		},
+		{scenario: "stream/zstd", acceptEncoding: "zstd",
+			result:   restapi.Stream(func(yield func(int) bool) { _ = yield(1) && yield(2) }),
+			encoding: "zstd",
+			body:     "[1,2]",
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.scenario, func(t *testing.T) {
+			// ACT
+			rec := serve(tc.acceptEncoding, tc.result)
+
+			// ASSERT
+			test.That(t, rec.Header().Get("Content-Encoding")).Equals(tc.encoding)
+			r, err := decoders[tc.encoding](rec.Body)
+			test.Error(t, err).IsNil()
+			b, err := io.ReadAll(r)
+			test.Error(t, err).IsNil()
+			test.That(t, string(b)).Equals(tc.body)
+		})
+	}
+}
@@ -0,0 +1,12 @@
+module github.com/blugnu/restapi/compression
+
+go 1.22
+
+require (
+	github.com/andybalholm/brotli v1.2.0
+	github.com/blugnu/restapi v0.0.0
+	github.com/blugnu/test v0.5.0
+	github.com/klauspost/compress v1.18.0
+)
+
+replace github.com/blugnu/restapi => ../
@@ -0,0 +1,398 @@
+package restapi
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/blugnu/test"
+)
+
+func TestCompressionNegotiate(t *testing.T) {
+	// ARRANGE
+	testcases := []struct {
+		acceptEncoding string
+		result         string
+	}{
+		{acceptEncoding: "", result: ""},
+		{acceptEncoding: "identity", result: ""},
+		{acceptEncoding: "gzip", result: "gzip"},
+		{acceptEncoding: "GZIP", result: "gzip"},
+		{acceptEncoding: "deflate, gzip, br, zstd", result: "gzip"},
+		{acceptEncoding: "gzip;q=0.8, deflate;q=1.0", result: "deflate"},
+		{acceptEncoding: "gzip;q=0, deflate", result: "deflate"},
+		{acceptEncoding: "*", result: "gzip"},
+		{acceptEncoding: "*;q=0.5, gzip;q=0", result: "deflate"},
+		{acceptEncoding: "br", result: ""},
+		{acceptEncoding: "gzip;q=invalid", result: "gzip"},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.acceptEncoding, func(t *testing.T) {
+			// ACT
+			enc, ok := DefaultCompression.negotiate(tc.acceptEncoding)
+
+			// ASSERT
+			test.That(t, enc.Name).Equals(tc.result)
+			test.That(t, ok).Equals(tc.result != "")
+		})
+	}
+}
+
+func TestCompressionExcludes(t *testing.T) {
+	// ARRANGE
+	testcases := []struct {
+		contentType string
+		result      bool
+	}{
+		{contentType: "application/json", result: false},
+		{contentType: "text/plain; charset=utf-8", result: false},
+		{contentType: "image/png", result: true},
+		{contentType: "application/octet-stream", result: true},
+		{contentType: "imagery/x-custom", result: false},
+		{contentType: "", result: true},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.contentType, func(t *testing.T) {
+			// ACT
+			result := DefaultCompression.excludes(tc.contentType)
+
+			// ASSERT
+			test.That(t, result).Equals(tc.result)
+		})
+	}
+}
+
+func TestIdentityETag(t *testing.T) {
+	// ARRANGE
+	custom := &Compression{Encodings: []Encoding{{Name: "x-custom"}}}
+	testcases := []struct {
+		etag        string
+		compression *Compression
+		result      string
+		encoding    string
+	}{
+		{etag: `"v1"`, result: `"v1"`},
+		{etag: `"v1-gzip"`, result: `"v1"`, encoding: "gzip"},
+		{etag: `W/"v1-br"`, result: `W/"v1"`, encoding: "br"},
+		{etag: `"v1-2"`, result: `"v1-2"`},
+		{etag: `"v1-x-custom"`, result: `"v1-x-custom"`},
+		{etag: `"v1-x-custom"`, compression: custom, result: `"v1"`, encoding: "x-custom"},
+		{etag: "*", result: "*"},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.etag, func(t *testing.T) {
+			// ACT
+			result, encoding := identityETag(tc.etag, tc.compression)
+
+			// ASSERT
+			test.That(t, result).Equals(tc.result)
+			test.That(t, encoding).Equals(tc.encoding)
+		})
+	}
+}
+
+func TestCompression(t *testing.T) {
+	// ARRANGE
+	large := strings.Repeat("compressible content ", 100)
+	decoders := map[string]func(io.Reader) (io.Reader, error){
+		"gzip":    func(r io.Reader) (io.Reader, error) { return gzip.NewReader(r) },
+		"deflate": func(r io.Reader) (io.Reader, error) { return zlib.NewReader(r) },
+	}
+	decode := func(t *testing.T, rec *httptest.ResponseRecorder) string {
+		t.Helper()
+		r, err := decoders[rec.Header().Get("Content-Encoding")](rec.Body)
+		test.Error(t, err).IsNil()
+		b, err := io.ReadAll(r)
+		test.Error(t, err).IsNil()
+		return string(b)
+	}
+	serve := func(s *Server, rq *http.Request, result any) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		if s == nil {
+			s = &Server{Compression: DefaultCompression}
+		}
+		s.HandlerFunc(func(context.Context, *http.Request) any { return result })(rec, rq)
+		return rec
+	}
+
+	testcases := []struct {
+		scenario string
+		exec     func(t *testing.T)
+	}{
+		{scenario: "encodings",
+			exec: func(t *testing.T) {
+				for name := range decoders {
+					t.Run(name, func(t *testing.T) {
+						// ARRANGE
+						rq := httptest.NewRequest(http.MethodGet, "/", nil)
+						rq.Header.Set("Accept-Encoding", name)
+
+						// ACT
+						rec := serve(nil, rq, large)
+
+						// ASSERT
+						test.That(t, rec.Header().Get("Content-Encoding")).Equals(name)
+						test.That(t, rec.Header().Get("Vary")).Equals("Accept, Accept-Encoding")
+						test.IsTrue(t, rec.Body.Len() < len(large))
+						test.That(t, decode(t, rec)).Equals(`"` + large + `"`)
+					})
+				}
+			},
+		},
+		{scenario: "no Accept-Encoding",
+			exec: func(t *testing.T) {
+				// ARRANGE
+				rq := httptest.NewRequest(http.MethodGet, "/", nil)
+
+				// ACT
+				rec := serve(nil, rq, large)
+
+				// ASSERT
+				test.That(t, rec.Header().Get("Content-Encoding")).Equals("")
+				test.That(t, rec.Header().Get("Vary")).Equals("Accept, Accept-Encoding")
+				test.That(t, rec.Body.String()).Equals(`"` + large + `"`)
+			},
+		},
+		{scenario: "smaller than minimum size",
+			exec: func(t *testing.T) {
+				// ARRANGE
+				rq := httptest.NewRequest(http.MethodGet, "/", nil)
+				rq.Header.Set("Accept-Encoding", "gzip")
+
+				// ACT
+				rec := serve(nil, rq, "small")
+
+				// ASSERT
+				test.That(t, rec.Header().Get("Content-Encoding")).Equals("")
+				test.That(t, rec.Header().Get("Vary")).Equals("Accept, Accept-Encoding")
+				test.That(t, rec.Body.String()).Equals(`"small"`)
+			},
+		},
+		{scenario: "excluded content type",
+			exec: func(t *testing.T) {
+				// ARRANGE
+				rq := httptest.NewRequest(http.MethodGet, "/", nil)
+				rq.Header.Set("Accept-Encoding", "gzip")
+
+				// ACT
+				rec := serve(nil, rq, OK().WithContent("image/png", []byte(large)))
+
+				// ASSERT
+				test.That(t, rec.Header().Get("Content-Encoding")).Equals("")
+				test.That(t, rec.Header().Get("Vary")).Equals("")
+				test.That(t, rec.Body.String()).Equals(large)
+			},
+		},
+		{scenario: "already encoded",
+			exec: func(t *testing.T) {
+				// ARRANGE
+				rq := httptest.NewRequest(http.MethodGet, "/", nil)
+				rq.Header.Set("Accept-Encoding", "gzip")
+
+				// ACT
+				rec := serve(nil, rq, OK().
+					WithContent("text/plain", []byte(large)).
+					WithHeader("Content-Encoding", "custom"))
+
+				// ASSERT
+				test.That(t, rec.Header().Get("Content-Encoding")).Equals("custom")
+				test.That(t, rec.Body.String()).Equals(large)
+			},
+		},
+		{scenario: "not configured",
+			exec: func(t *testing.T) {
+				// ARRANGE
+				rq := httptest.NewRequest(http.MethodGet, "/", nil)
+				rq.Header.Set("Accept-Encoding", "gzip")
+
+				// ACT
+				rec := serve(&Server{}, rq, large)
+
+				// ASSERT
+				test.That(t, rec.Header().Get("Content-Encoding")).Equals("")
+				test.That(t, rec.Header().Get("Vary")).Equals("Accept")
+				test.That(t, rec.Body.String()).Equals(`"` + large + `"`)
+			},
+		},
+		{scenario: "no encodings",
+			exec: func(t *testing.T) {
+				// ARRANGE
+				s := &Server{Compression: &Compression{}}
+				rq := httptest.NewRequest(http.MethodGet, "/", nil)
+				rq.Header.Set("Accept-Encoding", "gzip")
+
+				// ACT
+				rec := serve(s, rq, large)
+
+				// ASSERT
+				test.That(t, rec.Header().Get("Content-Encoding")).Equals("")
+				test.That(t, rec.Header().Get("Vary")).Equals("Accept")
+			},
+		},
+		{scenario: "strong etag",
+			exec: func(t *testing.T) {
+				// ARRANGE
+				rq := httptest.NewRequest(http.MethodGet, "/", nil)
+				rq.Header.Set("Accept-Encoding", "gzip")
+
+				// ACT
+				rec := serve(nil, rq, OK().WithValue(large).WithETag("v1"))
+
+				// ASSERT
+				test.That(t, rec.Header().Get("Content-Encoding")).Equals("gzip")
+				test.That(t, rec.Header().Get("ETag")).Equals(`"v1-gzip"`)
+			},
+		},
+		{scenario: "weak etag",
+			exec: func(t *testing.T) {
+				// ARRANGE
+				rq := httptest.NewRequest(http.MethodGet, "/", nil)
+				rq.Header.Set("Accept-Encoding", "gzip")
+
+				// ACT
+				rec := serve(nil, rq, OK().WithValue(large).WithETag(`W/"v1"`))
+
+				// ASSERT
+				test.That(t, rec.Header().Get("Content-Encoding")).Equals("gzip")
+				test.That(t, rec.Header().Get("ETag")).Equals(`W/"v1"`)
+			},
+		},
+		{scenario: "etag/not compressed",
+			exec: func(t *testing.T) {
+				// ARRANGE
+				rq := httptest.NewRequest(http.MethodGet, "/", nil)
+
+				// ACT
+				rec := serve(nil, rq, OK().WithValue(large).WithETag("v1"))
+
+				// ASSERT
+				test.That(t, rec.Header().Get("Content-Encoding")).Equals("")
+				test.That(t, rec.Header().Get("ETag")).Equals(`"v1"`)
+			},
+		},
+		{scenario: "If-None-Match/encoded etag",
+			exec: func(t *testing.T) {
+				// ARRANGE
+				rq := httptest.NewRequest(http.MethodGet, "/", nil)
+				rq.Header.Set("Accept-Encoding", "gzip")
+				rq.Header.Set("If-None-Match", `"v0-gzip", "v1-gzip"`)
+
+				// ACT
+				rec := serve(nil, rq, OK().WithValue(large).WithETag("v1"))
+
+				// ASSERT
+				test.That(t, rec.Code).Equals(http.StatusNotModified)
+				test.That(t, rec.Header().Get("ETag")).Equals(`"v1-gzip"`)
+				test.That(t, rec.Body.Len()).Equals(0)
+			},
+		},
+		{scenario: "If-None-Match/encoded etag/custom encoding",
+			exec: func(t *testing.T) {
+				// ARRANGE
+				s := &Server{Compression: &Compression{Encodings: []Encoding{{Name: "x-custom", NewWriter: Gzip.NewWriter}}}}
+				rq := httptest.NewRequest(http.MethodGet, "/", nil)
+				rq.Header.Set("If-None-Match", `"v1-x-custom"`)
+
+				// ACT
+				rec := serve(s, rq, OK().WithValue(large).WithETag("v1"))
+
+				// ASSERT
+				test.That(t, rec.Code).Equals(http.StatusNotModified)
+				test.That(t, rec.Header().Get("ETag")).Equals(`"v1-x-custom"`)
+			},
+		},
+		{scenario: "If-None-Match/identity etag",
+			exec: func(t *testing.T) {
+				// ARRANGE
+				rq := httptest.NewRequest(http.MethodGet, "/", nil)
+				rq.Header.Set("If-None-Match", `"v1"`)
+
+				// ACT
+				rec := serve(nil, rq, OK().WithValue(large).WithETag("v1"))
+
+				// ASSERT
+				test.That(t, rec.Code).Equals(http.StatusNotModified)
+				test.That(t, rec.Header().Get("ETag")).Equals(`"v1"`)
+			},
+		},
+		{scenario: "encoding error",
+			exec: func(t *testing.T) {
+				// ARRANGE
+				encerr := errors.New("encoder error")
+				s := &Server{Compression: &Compression{
+					Encodings: []Encoding{{Name: "x", NewWriter: func(io.Writer) (io.WriteCloser, error) { return nil, encerr }}},
+				}}
+				rq := httptest.NewRequest(http.MethodGet, "/", nil)
+				rq.Header.Set("Accept-Encoding", "x")
+				var logged *InternalError
+				defer test.Using(&LogError, func(e InternalError) { logged = &e })()
+
+				// ACT
+				rec := serve(s, rq, "value")
+
+				// ASSERT
+				test.That(t, rec.Header().Get("Content-Encoding")).Equals("")
+				test.That(t, rec.Body.String()).Equals(`"value"`)
+				test.IsTrue(t, logged != nil)
+				test.Error(t, logged.Err).Is(encerr)
+			},
+		},
+		{scenario: "stream",
+			exec: func(t *testing.T) {
+				// ARRANGE
+				rq := httptest.NewRequest(http.MethodGet, "/", nil)
+				rq.Header.Set("Accept-Encoding", "gzip")
+
+				// ACT
+				rec := serve(nil, rq, Stream(func(yield func(int) bool) {
+					_ = yield(1) && yield(2)
+				}))
+
+				// ASSERT
+				test.That(t, rec.Header().Get("Content-Encoding")).Equals("gzip")
+				test.That(t, decode(t, rec)).Equals("[1,2]")
+			},
+		},
+		{scenario: "unseekable reader",
+			exec: func(t *testing.T) {
+				// ARRANGE
+				rq := httptest.NewRequest(http.MethodGet, "/", nil)
+				rq.Header.Set("Accept-Encoding", "gzip")
+
+				// ACT
+				rec := serve(nil, rq, OK().WithReader("text/csv", &closeTracker{Reader: strings.NewReader(large)}))
+
+				// ASSERT
+				test.That(t, rec.Header().Get("Content-Encoding")).Equals("gzip")
+				test.That(t, rec.Header().Get("Content-Length")).Equals("")
+				test.That(t, decode(t, rec)).Equals(large)
+			},
+		},
+		{scenario: "seekable reader",
+			exec: func(t *testing.T) {
+				// ARRANGE
+				rq := httptest.NewRequest(http.MethodGet, "/", nil)
+				rq.Header.Set("Accept-Encoding", "gzip")
+
+				// ACT
+				rec := serve(nil, rq, OK().WithReader("text/csv", bytes.NewReader([]byte(large))))
+
+				// ASSERT
+				test.That(t, rec.Header().Get("Content-Encoding")).Equals("")
+				test.That(t, rec.Body.String()).Equals(large)
+			},
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.scenario, func(t *testing.T) {
+			tc.exec(t)
+		})
+	}
+}
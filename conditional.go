@@ -0,0 +1,248 @@
+package restapi
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// CheckIfMatch checks the If-Match header of a request against the current
+// entity tag of a resource, to be called by an endpoint function before
+// modifying the resource.  The entity tag may be specified with or without
+// quotes (see: Result.WithETag); an empty entity tag indicates that the
+// resource does not exist.
+//
+// If the request has no If-Match header or the header matches the current
+// entity tag (using the strong comparison function), nil is returned.
+// Otherwise a 412 Precondition Failed Error is returned, wrapping
+// ErrPreconditionFailed, with the current entity tag (if any) in an ETag
+// header.
+//
+// To require that requests have an If-Match header, use RequireIfMatch.
+//
+// # example
+//
+//	func PutDocument(ctx context.Context, rq *http.Request) any {
+//	    doc, err := store.Get(ctx, rq.PathValue("id"))
+//	    if err != nil {
+//	        return err
+//	    }
+//	    if err := restapi.CheckIfMatch(rq, doc.Version); err != nil {
+//	        return err
+//	    }
+//	    // ... update the document ...
+//	}
+func CheckIfMatch(rq *http.Request, etag string) error {
+	values := rq.Header.Values("If-Match")
+	if len(values) == 0 {
+		return nil
+	}
+
+	etag = formatETag(etag)
+	if etag != "" && etagMatches(identityETags(parseETags(values), nil), etag, true) {
+		return nil
+	}
+
+	err := PreconditionFailed(ErrPreconditionFailed, rq).
+		WithHelp("the resource has been modified; the If-Match request header must match the current entity tag")
+	if etag != "" {
+		err = err.WithHeader("ETag", etag)
+	}
+	return err
+}
+
+// RequireIfMatch checks the If-Match header of a request against the current
+// entity tag of a resource in the same way as CheckIfMatch, except that a
+// request with no If-Match header results in a 428 Precondition Required
+// Error, wrapping ErrPreconditionRequired, with the current entity tag (if
+// any) in an ETag header.
+func RequireIfMatch(rq *http.Request, etag string) error {
+	if len(rq.Header.Values("If-Match")) > 0 {
+		return CheckIfMatch(rq, etag)
+	}
+
+	err := PreconditionRequired(ErrPreconditionRequired, rq).
+		WithHelp("the request must have an If-Match header identifying the entity tag of the resource to be modified")
+	if etag = formatETag(etag); etag != "" {
+		err = err.WithHeader("ETag", etag)
+	}
+	return err
+}
+
+// contentETag returns a strong entity tag computed from response content.
+func contentETag(content []byte) string {
+	sum := sha256.Sum256(content)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// formatETag returns an entity tag as a quoted string, with any weak indicator
+// (W/).  An entity tag that is not quoted is quoted; an empty string remains
+// empty.
+func formatETag(etag string) string {
+	switch {
+	case etag == "":
+		return ""
+	case strings.HasPrefix(etag, `W/"`) && strings.HasSuffix(etag, `"`) && len(etag) > 3:
+		return etag
+	case strings.HasPrefix(etag, `"`) && strings.HasSuffix(etag, `"`) && len(etag) > 1:
+		return etag
+	default:
+		return `"` + etag + `"`
+	}
+}
+
+// parseETags parses a list of entity tags from the values of an If-Match or
+// If-None-Match header.  Malformed entries are ignored.
+func parseETags(values []string) []string {
+	result := []string{}
+	for _, s := range values {
+		for {
+			s = strings.TrimLeft(s, " \t,")
+			if s == "" {
+				break
+			}
+			if s[0] == '*' {
+				result = append(result, "*")
+				s = s[1:]
+				continue
+			}
+
+			start := 0
+			if strings.HasPrefix(s, "W/") {
+				start = 2
+			}
+			if len(s) <= start || s[start] != '"' {
+				// malformed; skip to the next entry
+				_, s, _ = strings.Cut(s, ",")
+				continue
+			}
+			end := strings.IndexByte(s[start+1:], '"')
+			if end < 0 {
+				break
+			}
+			end += start + 2
+			result = append(result, s[:end])
+			s = s[end:]
+		}
+	}
+	return result
+}
+
+// identityETags returns a list of entity tags with any suffix identifying the
+// encoding of a compressed response removed from each (see: identityETag).
+func identityETags(list []string, c *Compression) []string {
+	result := make([]string, len(list))
+	for i, s := range list {
+		result[i], _ = identityETag(s, c)
+	}
+	return result
+}
+
+// etagMatches returns true if any of a list of entity tags matches an entity
+// tag, using the strong or weak comparison function (RFC 9110, 8.8.3.2).  The
+// wildcard "*" matches any current entity.
+func etagMatches(list []string, etag string, strong bool) bool {
+	isWeak := func(s string) bool { return strings.HasPrefix(s, "W/") }
+	for _, s := range list {
+		switch {
+		case s == "*":
+			return true
+		case etag == "":
+			continue
+		case strong && (isWeak(s) || isWeak(etag)):
+			continue
+		case strings.TrimPrefix(s, "W/") == strings.TrimPrefix(etag, "W/"):
+			return true
+		}
+	}
+	return false
+}
+
+// evaluatePreconditions evaluates the conditional headers of a GET or HEAD
+// request against the entity tag and modification time of a successful
+// (2xx) response, in the order specified by RFC 9110 (13.2.2).  Returns:
+//
+//   - a 412 Precondition Failed error response if an If-Match or
+//     If-Unmodified-Since condition is not met;
+//
+//   - a 304 Not Modified response (with no content) if an If-None-Match or
+//     If-Modified-Since condition is not met;
+//
+//   - the original response otherwise.
+func (r *Response) evaluatePreconditions(rq *Request) *Response {
+	if r == nil || rq.Request == nil ||
+		(rq.Method != http.MethodGet && rq.Method != http.MethodHead) ||
+		r.StatusCode < 200 || r.StatusCode > 299 {
+		return r
+	}
+
+	isModifiedSince := func(header string) (bool, bool) {
+		if r.lastModified.IsZero() {
+			return false, false
+		}
+		t, err := http.ParseTime(rq.Header.Get(header))
+		if err != nil {
+			return false, false
+		}
+		return r.lastModified.After(t), true
+	}
+
+	compression := rq.server.compression()
+
+	if values := rq.Header.Values("If-Match"); len(values) > 0 {
+		if !etagMatches(identityETags(parseETags(values), compression), r.etag, true) {
+			return r.preconditionFailed(rq, "If-Match")
+		}
+	} else if modified, ok := isModifiedSince("If-Unmodified-Since"); ok && modified {
+		return r.preconditionFailed(rq, "If-Unmodified-Since")
+	}
+
+	if values := rq.Header.Values("If-None-Match"); len(values) > 0 {
+		for _, etag := range parseETags(values) {
+			etag, encoding := identityETag(etag, compression)
+			if !etagMatches([]string{etag}, r.etag, false) {
+				continue
+			}
+			// the 304 response identifies the representation held by the
+			// client, which may be compressed
+			result := r.notModified()
+			if encoding != "" {
+				result.etag = encodedETag(r.etag, encoding)
+			}
+			return result
+		}
+	} else if modified, ok := isModifiedSince("If-Modified-Since"); ok && !modified {
+		return r.notModified()
+	}
+
+	return r
+}
+
+// notModified returns a 304 Not Modified response with the headers, entity
+// tag and modification time of the response (and the same Vary header).
+func (r *Response) notModified() *Response {
+	return &Response{
+		StatusCode:   http.StatusNotModified,
+		headers:      r.headers,
+		etag:         r.etag,
+		lastModified: r.lastModified,
+		negotiated:   r.negotiated,
+	}
+}
+
+// preconditionFailed returns a 412 Precondition Failed error response for a
+// request with a conditional header that is not met by the response.  The
+// response includes the current entity tag (if any).
+func (r *Response) preconditionFailed(rq *Request, header string) *Response {
+	err := PreconditionFailed(ErrPreconditionFailed, rq.Request).
+		WithHelp(fmt.Sprintf("the condition in the %s request header is not met by the current resource", header))
+	if r.etag != "" {
+		err = err.WithHeader("ETag", r.etag)
+	}
+	if !r.lastModified.IsZero() {
+		err = err.WithHeader("Last-Modified", r.lastModified)
+	}
+	return err.makeResponse(rq)
+}
@@ -0,0 +1,246 @@
+package restapi
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/blugnu/test"
+)
+
+func TestParseETags(t *testing.T) {
+	// ARRANGE
+	testcases := []struct {
+		values []string
+		result []string
+	}{
+		{values: nil, result: []string{}},
+		{values: []string{`*`}, result: []string{"*"}},
+		{values: []string{`"a"`}, result: []string{`"a"`}},
+		{values: []string{`"a", W/"b"`, `"c,d"`}, result: []string{`"a"`, `W/"b"`, `"c,d"`}},
+		{values: []string{`a, "b"`}, result: []string{`"b"`}},
+		{values: []string{`"a", "b`}, result: []string{`"a"`}},
+	}
+	for _, tc := range testcases {
+		t.Run(fmt.Sprintf("%q", tc.values), func(t *testing.T) {
+			// ACT
+			result := parseETags(tc.values)
+
+			// ASSERT
+			test.That(t, result).Equals(tc.result)
+		})
+	}
+}
+
+func TestETagMatches(t *testing.T) {
+	// ARRANGE
+	testcases := []struct {
+		scenario string
+		list     []string
+		etag     string
+		strong   bool
+		result   bool
+	}{
+		{scenario: "wildcard", list: []string{"*"}, etag: "", result: true},
+		{scenario: "no etag", list: []string{`"a"`}, etag: "", result: false},
+		{scenario: "strong/match", list: []string{`"b"`, `"a"`}, etag: `"a"`, strong: true, result: true},
+		{scenario: "strong/weak etag", list: []string{`"a"`}, etag: `W/"a"`, strong: true, result: false},
+		{scenario: "strong/weak tag in list", list: []string{`W/"a"`}, etag: `"a"`, strong: true, result: false},
+		{scenario: "weak/match", list: []string{`W/"a"`}, etag: `"a"`, result: true},
+		{scenario: "weak/no match", list: []string{`W/"a"`}, etag: `W/"b"`, result: false},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.scenario, func(t *testing.T) {
+			// ACT
+			result := etagMatches(tc.list, tc.etag, tc.strong)
+
+			// ASSERT
+			test.That(t, result).Equals(tc.result)
+		})
+	}
+}
+
+func TestConditionalRequests(t *testing.T) {
+	// ARRANGE
+	modified := time.Date(2010, 9, 8, 7, 6, 5, 0, time.UTC)
+	before := modified.Add(-time.Hour).Format(http.TimeFormat)
+	after := modified.Add(time.Hour).Format(http.TimeFormat)
+
+	defer test.Using(&nowUTC, func() time.Time { return modified })()
+
+	testcases := []struct {
+		scenario   string
+		method     string
+		headers    map[string]string
+		result     any
+		statusCode int
+	}{
+		{scenario: "no conditions",
+			statusCode: http.StatusOK,
+		},
+		{scenario: "If-None-Match/match",
+			headers:    map[string]string{"If-None-Match": `"x", "v1"`},
+			statusCode: http.StatusNotModified,
+		},
+		{scenario: "If-None-Match/weak match",
+			headers:    map[string]string{"If-None-Match": `W/"v1"`},
+			statusCode: http.StatusNotModified,
+		},
+		{scenario: "If-None-Match/no match",
+			headers:    map[string]string{"If-None-Match": `"v0"`},
+			statusCode: http.StatusOK,
+		},
+		{scenario: "If-None-Match/HEAD",
+			method:     http.MethodHead,
+			headers:    map[string]string{"If-None-Match": `*`},
+			statusCode: http.StatusNotModified,
+		},
+		{scenario: "If-None-Match/takes precedence over If-Modified-Since",
+			headers:    map[string]string{"If-None-Match": `"v0"`, "If-Modified-Since": after},
+			statusCode: http.StatusOK,
+		},
+		{scenario: "If-Modified-Since/not modified",
+			headers:    map[string]string{"If-Modified-Since": after},
+			statusCode: http.StatusNotModified,
+		},
+		{scenario: "If-Modified-Since/modified",
+			headers:    map[string]string{"If-Modified-Since": before},
+			statusCode: http.StatusOK,
+		},
+		{scenario: "If-Modified-Since/invalid date",
+			headers:    map[string]string{"If-Modified-Since": "yesterday"},
+			statusCode: http.StatusOK,
+		},
+		{scenario: "If-Match/match",
+			headers:    map[string]string{"If-Match": `"v1"`},
+			statusCode: http.StatusOK,
+		},
+		{scenario: "If-Match/no match",
+			headers:    map[string]string{"If-Match": `"v0"`},
+			statusCode: http.StatusPreconditionFailed,
+		},
+		{scenario: "If-Match/weak tag",
+			headers:    map[string]string{"If-Match": `W/"v1"`},
+			statusCode: http.StatusPreconditionFailed,
+		},
+		{scenario: "If-Unmodified-Since/modified",
+			headers:    map[string]string{"If-Unmodified-Since": before},
+			statusCode: http.StatusPreconditionFailed,
+		},
+		{scenario: "If-Unmodified-Since/not modified",
+			headers:    map[string]string{"If-Unmodified-Since": after},
+			statusCode: http.StatusOK,
+		},
+		{scenario: "not evaluated for other methods",
+			method:     http.MethodPut,
+			headers:    map[string]string{"If-Match": `"v0"`},
+			statusCode: http.StatusOK,
+		},
+		{scenario: "not evaluated for unsuccessful responses",
+			headers:    map[string]string{"If-None-Match": `*`},
+			result:     NotFound(),
+			statusCode: http.StatusNotFound,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.scenario, func(t *testing.T) {
+			// ARRANGE
+			rq := httptest.NewRequest(coalesce(tc.method, http.MethodGet), "/", nil)
+			for k, v := range tc.headers {
+				rq.Header.Set(k, v)
+			}
+			rec := httptest.NewRecorder()
+			result := coalesce(tc.result, any(OK().
+				WithValue("value").
+				WithETag("v1").
+				WithLastModified(modified)))
+
+			// ACT
+			HandlerFunc(func(context.Context, *http.Request) any { return result })(rec, rq)
+
+			// ASSERT
+			test.That(t, rec.Code).Equals(tc.statusCode)
+			switch tc.statusCode {
+			case http.StatusNotModified:
+				test.That(t, rec.Body.Len()).Equals(0)
+				test.That(t, rec.Header().Get("Content-Type")).Equals("")
+				test.That(t, rec.Header().Get("ETag")).Equals(`"v1"`)
+				test.That(t, rec.Header().Get("Last-Modified")).Equals("Wed, 08 Sep 2010 07:06:05 GMT")
+			case http.StatusPreconditionFailed:
+				test.That(t, rec.Header().Get("ETag")).Equals(`"v1"`)
+			}
+		})
+	}
+}
+
+func TestCheckIfMatch(t *testing.T) {
+	// ARRANGE
+	testcases := []struct {
+		scenario string
+		ifMatch  string
+		etag     string
+		require  bool
+		result   error
+	}{
+		{scenario: "no If-Match", etag: "v1"},
+		{scenario: "match", ifMatch: `"v0", "v1"`, etag: "v1"},
+		{scenario: "wildcard", ifMatch: "*", etag: "v1"},
+		{scenario: "match/encoded etag", ifMatch: `"v1-gzip"`, etag: "v1"},
+		{scenario: "no match",
+			ifMatch: `"v0"`,
+			etag:    "v1",
+			result:  PreconditionFailed(ErrPreconditionFailed).WithHeader("ETag", `"v1"`),
+		},
+		{scenario: "weak match",
+			ifMatch: `W/"v1"`,
+			etag:    "v1",
+			result:  PreconditionFailed(ErrPreconditionFailed),
+		},
+		{scenario: "wildcard/resource does not exist",
+			ifMatch: "*",
+			result:  PreconditionFailed(ErrPreconditionFailed),
+		},
+		{scenario: "required/no If-Match",
+			etag:    `W/"v1"`,
+			require: true,
+			result:  PreconditionRequired(ErrPreconditionRequired).WithHeader("ETag", `W/"v1"`),
+		},
+		{scenario: "required/match",
+			ifMatch: `"v1"`,
+			etag:    "v1",
+			require: true,
+		},
+		{scenario: "required/no match",
+			ifMatch: `"v0"`,
+			etag:    "v1",
+			require: true,
+			result:  PreconditionFailed(ErrPreconditionFailed),
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.scenario, func(t *testing.T) {
+			// ARRANGE
+			rq := httptest.NewRequest(http.MethodPut, "/", nil)
+			if tc.ifMatch != "" {
+				rq.Header.Set("If-Match", tc.ifMatch)
+			}
+			check := CheckIfMatch
+			if tc.require {
+				check = RequireIfMatch
+			}
+
+			// ACT
+			err := check(rq, tc.etag)
+
+			// ASSERT
+			if tc.result == nil {
+				test.Error(t, err).IsNil()
+				return
+			}
+			test.Error(t, err).Is(tc.result)
+		})
+	}
+}
@@ -0,0 +1,52 @@
+package restapi
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// setCookie adds a cookie to the Set-Cookie header, replacing any cookie with
+// the same name, path and domain already set.  A copy of the cookie is held so
+// that any subsequent change to the cookie is not reflected in the response.
+//
+// If the cookie is not valid (see: http.Cookie.Valid) the function panics with
+// ErrInvalidArgument.
+func (h headers) setCookie(c *http.Cookie) {
+	if c == nil {
+		panic(fmt.Errorf("%w: cookie is nil", ErrInvalidArgument))
+	}
+	if err := c.Valid(); err != nil {
+		panic(fmt.Errorf("%w: cookie: %w", ErrInvalidArgument, err))
+	}
+
+	var values []any
+	switch existing := h["Set-Cookie"].(type) {
+	case nil:
+	case []any:
+		values = make([]any, 0, len(existing)+1)
+		for _, v := range existing {
+			if ec, ok := v.(*http.Cookie); ok &&
+				ec.Name == c.Name && ec.Path == c.Path && ec.Domain == c.Domain {
+				continue
+			}
+			values = append(values, v)
+		}
+	default:
+		values = []any{existing}
+	}
+
+	cookie := *c
+	h["Set-Cookie"] = append(values, &cookie)
+}
+
+// expiredCookie returns a cookie with a specified name that instructs a client
+// to delete any cookie with that name (and a path of "/").
+func expiredCookie(name string) *http.Cookie {
+	return &http.Cookie{
+		Name:    name,
+		Path:    "/",
+		Expires: time.Unix(0, 0),
+		MaxAge:  -1,
+	}
+}
@@ -0,0 +1,154 @@
+package restapi
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/blugnu/test"
+)
+
+func TestCookies(t *testing.T) {
+	// ARRANGE
+	serve := func(result any) *http.Response {
+		rec := httptest.NewRecorder()
+		HandlerFunc(func(context.Context, *http.Request) any { return result })(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+		return rec.Result()
+	}
+
+	testcases := []struct {
+		scenario string
+		exec     func(t *testing.T)
+	}{
+		{scenario: "result/with cookie",
+			exec: func(t *testing.T) {
+				// ARRANGE
+				session := &http.Cookie{Name: "session", Value: "abc", Path: "/", HttpOnly: true, Secure: true}
+				sut := OK().
+					WithCookie(session).
+					WithCookie(&http.Cookie{Name: "theme", Value: "dark"})
+
+				// ACT
+				result := serve(sut)
+
+				// ASSERT
+				test.That(t, result.Header["Set-Cookie"]).Equals([]string{
+					"session=abc; Path=/; HttpOnly; Secure",
+					"theme=dark",
+				})
+			},
+		},
+		{scenario: "result/cookie replaced",
+			exec: func(t *testing.T) {
+				// ARRANGE
+				sut := OK().
+					WithCookie(&http.Cookie{Name: "session", Value: "old", Path: "/"}).
+					WithCookie(&http.Cookie{Name: "session", Value: "other", Path: "/admin"}).
+					WithCookie(&http.Cookie{Name: "session", Value: "new", Path: "/"})
+
+				// ACT
+				result := serve(sut)
+
+				// ASSERT
+				test.That(t, result.Header["Set-Cookie"]).Equals([]string{
+					"session=other; Path=/admin",
+					"session=new; Path=/",
+				})
+			},
+		},
+		{scenario: "result/cookie copied",
+			exec: func(t *testing.T) {
+				// ARRANGE
+				cookie := &http.Cookie{Name: "session", Value: "abc"}
+				sut := OK().WithCookie(cookie)
+				cookie.Value = "modified"
+
+				// ACT
+				result := serve(sut)
+
+				// ASSERT
+				test.That(t, result.Header["Set-Cookie"]).Equals([]string{"session=abc"})
+			},
+		},
+		{scenario: "result/with header and cookie",
+			exec: func(t *testing.T) {
+				// ARRANGE
+				sut := OK().
+					WithHeader("Set-Cookie", "a=1").
+					WithCookie(&http.Cookie{Name: "b", Value: "2"})
+
+				// ACT
+				result := serve(sut)
+
+				// ASSERT
+				test.That(t, result.Header["Set-Cookie"]).Equals([]string{"a=1", "b=2"})
+			},
+		},
+		{scenario: "result/clear cookie",
+			exec: func(t *testing.T) {
+				// ARRANGE
+				sut := NoContent().ClearCookie("session")
+
+				// ACT
+				result := serve(sut)
+
+				// ASSERT
+				test.That(t, result.Header["Set-Cookie"]).Equals([]string{
+					"session=; Path=/; Expires=Thu, 01 Jan 1970 00:00:00 GMT; Max-Age=0",
+				})
+			},
+		},
+		{scenario: "error/with cookie",
+			exec: func(t *testing.T) {
+				// ARRANGE
+				sut := Unauthorized().
+					WithCookie(&http.Cookie{Name: "attempts", Value: "3"})
+
+				// ACT
+				result := serve(sut)
+
+				// ASSERT
+				test.That(t, result.StatusCode).Equals(http.StatusUnauthorized)
+				test.That(t, result.Header["Set-Cookie"]).Equals([]string{"attempts=3"})
+			},
+		},
+		{scenario: "error/clear cookie",
+			exec: func(t *testing.T) {
+				// ARRANGE
+				sut := Unauthorized().ClearCookie("session")
+
+				// ACT
+				result := serve(sut)
+
+				// ASSERT
+				test.That(t, result.Header["Set-Cookie"]).Equals([]string{
+					"session=; Path=/; Expires=Thu, 01 Jan 1970 00:00:00 GMT; Max-Age=0",
+				})
+			},
+		},
+		{scenario: "nil cookie",
+			exec: func(t *testing.T) {
+				// ARRANGE
+				defer test.ExpectPanic(ErrInvalidArgument).Assert(t)
+
+				// ACT
+				OK().WithCookie(nil)
+			},
+		},
+		{scenario: "invalid cookie",
+			exec: func(t *testing.T) {
+				// ARRANGE
+				defer test.ExpectPanic(ErrInvalidArgument).Assert(t)
+
+				// ACT
+				Unauthorized().WithCookie(&http.Cookie{Name: "in valid"})
+			},
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.scenario, func(t *testing.T) {
+			tc.exec(t)
+		})
+	}
+}
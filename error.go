@@ -26,16 +26,17 @@ var (
 
 		// apply defaults in case the Error was not fully initialised
 		e.statusCode = coalesce(e.statusCode, http.StatusInternalServerError)
-		e.timeStamp = coalesce(e.timeStamp, nowUTC())
+		e.timeStamp = coalesce(e.timeStamp, rq.server.nowUTC())
 		e.request = rq.Request
 
-		p := ProjectError(e.info())
+		info := e.info()
+		info.ContentType = rq.Accept
+		p := rq.projectError(info)
 
 		statusCode := e.statusCode
-		contentType := rq.Accept
-		content, err := rq.MarshalContent(p)
+		contentType, content, err := rq.marshalContent(p)
 		if err != nil {
-			LogError(InternalError{
+			rq.logError(InternalError{
 				Err:     err,
 				Message: "error marshalling error response",
 				Help:    fmt.Sprintf("the original error was: %v", e),
@@ -43,7 +44,7 @@ var (
 			})
 			return &Response{
 				StatusCode:  http.StatusInternalServerError,
-				ContentType: "plain/text",
+				ContentType: "text/plain",
 				Content: []byte(strings.Join([]string{
 					"An error occurred marshalling an error response",
 					"",
@@ -62,6 +63,8 @@ var (
 			StatusCode:  statusCode,
 			ContentType: contentType,
 			Content:     content,
+			headers:     e.headers,
+			negotiated:  true,
 		}
 	}
 )
@@ -86,7 +89,7 @@ var (
 //	        WithHelp("The ID must be a valid UUID provided in the request path: /v1/resource/<ID>")
 //
 //	URL         // the URL of the request that resulted in the error
-//	TimeStamp   // the (UTC) time that the error occurred
+//	TimeStamp   // the (UTC) time of the error response (see: Server.NowUTC)
 //
 // The following additional information may also be provided by a Handler when
 // returning an Error:
@@ -194,7 +197,6 @@ func NewError(args ...any) *Error {
 		err:        err,
 		message:    msg,
 		request:    rq,
-		timeStamp:  nowUTC(),
 	}
 }
 
@@ -318,14 +320,47 @@ func (apierr *Error) makeResponse(rq *Request) *Response {
 	return makeErrorResponse(apierr, rq)
 }
 
+// AddHeader adds a value to a header to be included in the response for the
+// error, retaining any values already set for the header.  Values are formatted
+// as described for WithHeader.
+//
+// The header key is canonicalised using http.CanonicalHeaderKey.
+func (err *Error) AddHeader(k string, v any) *Error {
+	err.hasHeaders().add(k, v)
+	return err
+}
+
+// ClearCookie adds a Set-Cookie header to the response for the error which
+// instructs the client to delete a cookie with the specified name and a Path
+// of "/" (e.g. to end a session that is no longer valid).
+func (err *Error) ClearCookie(name string) *Error {
+	err.hasHeaders().setCookie(expiredCookie(name))
+	return err
+}
+
+// WithCookie adds a Set-Cookie header to the response for the error for the
+// specified cookie.  A cookie with the same Name, Path and Domain as a cookie
+// already set on the Error replaces that cookie.
+//
+// The function panics with ErrInvalidArgument if the cookie is nil or is
+// not valid (see: http.Cookie.Valid).
+func (err *Error) WithCookie(c *http.Cookie) *Error {
+	err.hasHeaders().setCookie(c)
+	return err
+}
+
 // WithHeader sets a header to be included in the response for the error.
 //
 // The specified header will be added to any headers already set on the Error.
 // If the specified header is already set on the Error the existing header will
 // be replaced with the new value.
 //
+// A slice value (e.g. []string) sets multiple values for the header.  Values
+// are formatted according to their type (see: Result.WithHeader).
+//
 // The header key is canonicalised using http.CanonicalHeaderKey.  To set a header
-// with a non-canonical key use WithNonCanonicalHeader.
+// with a non-canonical key use WithNonCanonicalHeader.  To add a value to a
+// header without replacing any existing values use AddHeader.
 func (err *Error) WithHeader(k string, v any) *Error {
 	err.hasHeaders().set(k, v)
 	return err
@@ -400,7 +435,25 @@ func NotFound(args ...any) *Error {
 	return NewError(append([]any{http.StatusNotFound}, args...)...)
 }
 
+// PreconditionFailed returns an ApiError with a status code of 412 and the
+// specified error.
+func PreconditionFailed(args ...any) *Error {
+	return NewError(append([]any{http.StatusPreconditionFailed}, args...)...)
+}
+
+// PreconditionRequired returns an ApiError with a status code of 428 and the
+// specified error.
+func PreconditionRequired(args ...any) *Error {
+	return NewError(append([]any{http.StatusPreconditionRequired}, args...)...)
+}
+
 // Unauthorized returns an ApiError with a status code of 401 and the specified error.
 func Unauthorized(args ...any) *Error {
 	return NewError(append([]any{http.StatusUnauthorized}, args...)...)
 }
+
+// UnprocessableEntity returns an ApiError with a status code of 422 and the
+// specified error.
+func UnprocessableEntity(args ...any) *Error {
+	return NewError(append([]any{http.StatusUnprocessableEntity}, args...)...)
+}
@@ -13,12 +13,16 @@ import (
 // implementations, except when providing an implementation for the restapi.LogError
 // or restapi.ProjectError functions. These functions receive a copy of the Error
 // to be logged or projected in the form of an ErrorInfo.
+//
+// ContentType is the content type negotiated for the error response, in which
+// the projection of the error will be marshalled.
 type ErrorInfo struct {
-	StatusCode int
-	Err        error
-	Help       string
-	Message    string
-	Request    *http.Request
-	Properties map[string]any
-	TimeStamp  time.Time
+	StatusCode  int
+	Err         error
+	Help        string
+	Message     string
+	Request     *http.Request
+	Properties  map[string]any
+	TimeStamp   time.Time
+	ContentType string
 }
@@ -287,9 +287,27 @@ func TestError(t *testing.T) {
 					StatusCode:  404,
 					Content:     []byte("content"),
 					ContentType: "request/Content-Type",
+					negotiated:  true,
 				})
 			},
 		},
+		{scenario: "makeResponse/with headers",
+			exec: func(t *testing.T) {
+				// ARRANGE
+				err := Error{statusCode: 404, headers: headers{"Header": "value"}}
+				rq := &Request{
+					Request:        &http.Request{URL: &url.URL{}},
+					Accept:         "request/Content-Type",
+					MarshalContent: func(v any) ([]byte, error) { return []byte("content"), nil },
+				}
+
+				// ACT
+				response := err.makeResponse(rq)
+
+				// ASSERT
+				test.Map(t, response.headers).Equals(headers{"Header": "value"})
+			},
+		},
 		{scenario: "makeResponse/invalid status code",
 			exec: func(t *testing.T) {
 				// ARRANGE & ASSERT
@@ -331,7 +349,7 @@ func TestError(t *testing.T) {
 					Request: rq.Request,
 				}, "describes the original error")
 				test.That(t, response.StatusCode).Equals(500)
-				test.That(t, response.ContentType).Equals("plain/text")
+				test.That(t, response.ContentType).Equals("text/plain")
 				test.Strings(t, response.Content).Equals([]string{
 					`An error occurred marshalling an error response`,
 					"",
@@ -555,16 +573,16 @@ func TestError(t *testing.T) {
 				// ARRANGE
 				err := errors.New("error")
 
-				// this test ensures that the Error() factory applies the current time
-				// to the timestamp field
-				ts := time.Now()
-				defer test.Using(&nowUTC, func() time.Time { return ts })()
+				// this test ensures that the Error() factory does not apply a
+				// timestamp (the timestamp is applied when making the response,
+				// using the clock of the Server)
+				defer test.Using(&nowUTC, func() time.Time { return time.Now() })()
 
 				// ACT
 				result := NewError(404, err)
 
 				// ASSERT
-				test.That(t, *result).Equals(Error{statusCode: 404, err: err, timeStamp: ts})
+				test.That(t, *result).Equals(Error{statusCode: 404, err: err})
 			},
 		},
 		{scenario: "factory/BadRequest",
@@ -603,6 +621,24 @@ func TestError(t *testing.T) {
 				test.That(t, *result).Equals(Error{statusCode: 404})
 			},
 		},
+		{scenario: "factory/PreconditionFailed",
+			exec: func(t *testing.T) {
+				// ACT
+				result := PreconditionFailed(nil)
+
+				// ASSERT
+				test.That(t, *result).Equals(Error{statusCode: 412})
+			},
+		},
+		{scenario: "factory/PreconditionRequired",
+			exec: func(t *testing.T) {
+				// ACT
+				result := PreconditionRequired(nil)
+
+				// ASSERT
+				test.That(t, *result).Equals(Error{statusCode: 428})
+			},
+		},
 		{scenario: "factory/Unauthorized",
 			exec: func(t *testing.T) {
 				// ACT
@@ -612,6 +648,15 @@ func TestError(t *testing.T) {
 				test.That(t, *result).Equals(Error{statusCode: 401})
 			},
 		},
+		{scenario: "factory/UnprocessableEntity",
+			exec: func(t *testing.T) {
+				// ACT
+				result := UnprocessableEntity(nil)
+
+				// ASSERT
+				test.That(t, *result).Equals(Error{statusCode: 422})
+			},
+		},
 	}
 	for _, tc := range testcases {
 		t.Run(tc.scenario, func(t *testing.T) {
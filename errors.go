@@ -4,13 +4,20 @@ import "errors"
 
 var (
 	ErrBodyRequired            = errors.New("a body is required")
+	ErrErrorReadingContent     = errors.New("error reading content")
 	ErrErrorReadingRequestBody = errors.New("error reading request body")
 	ErrInvalidAcceptHeader     = errors.New("no formatter for content type")
 	ErrInvalidArgument         = errors.New("invalid argument")
 	ErrInvalidOperation        = errors.New("invalid operation")
+	ErrInvalidParameter        = errors.New("invalid parameter")
 	ErrInvalidStatusCode       = errors.New("invalid statuscode")
 	ErrMarshalErrorFailed      = errors.New("error marshalling an Error response")
 	ErrMarshalResultFailed     = errors.New("error marshalling response")
 	ErrNoAcceptHeader          = errors.New("no Accept header")
+	ErrNotHypermedia           = errors.New("not a hypermedia document")
+	ErrPreconditionFailed      = errors.New("precondition failed")
+	ErrPreconditionRequired    = errors.New("precondition required")
 	ErrUnexpectedField         = errors.New("unexpected field")
+	ErrUnsupportedMediaType    = errors.New("unsupported media type")
+	ErrValidationFailed        = errors.New("validation failed")
 )
@@ -1,5 +1,5 @@
 module github.com/blugnu/restapi
 
-go 1.21
+go 1.22
 
 require github.com/blugnu/test v0.5.0
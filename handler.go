@@ -51,6 +51,18 @@ type EndpointHandler interface {
 	ServeAPI(context.Context, *http.Request) any
 }
 
+// notAcceptable returns an Error describing a 406 Not Acceptable response
+// for a request, listing the content types supported by the Server handling
+// the request in an "acceptable" property.
+//
+// Accept is a request header (RFC 9110, section 12.5.1) and is not used to
+// list the content types in the response.
+func notAcceptable(rq *Request) *Error {
+	return NewError(http.StatusNotAcceptable, ErrInvalidAcceptHeader, rq.Request).
+		WithHelp("the request Accept header must accept at least one of the supported content types").
+		WithProperty("acceptable", rq.server.marshallers().ContentTypes())
+}
+
 // HandlerFunc returns a http.HandlerFunc that calls a REST API endpoint
 // function.
 //
@@ -59,27 +71,53 @@ type EndpointHandler interface {
 // arguments, it also returns a value of type 'any'.
 //
 // The returned value is processed by the Handler function to generate
-// an appropriate response.
+// an appropriate response.  For GET and HEAD requests, any conditional request
+// headers are evaluated against the entity tag and modification time of a
+// Result (see: Result.WithETag and Result.WithLastModified), responding with
+// 304 Not Modified or 412 Precondition Failed if appropriate.
+//
+// The returned handler is bound to the Default Server configuration.
 func HandlerFunc(h func(context.Context, *http.Request) any) http.HandlerFunc {
+	return Default.HandlerFunc(h)
+}
+
+// Handler returns a http.HandlerFunc that calls a restapi.EndpointHandler.
+//
+// A restapi.EndpointHandler is an interface that defines a ServeAPI method
+// that accepts a context.Context and a *http.Request argument, returning a
+// value of type 'any'.
+//
+// The returned handler is bound to the Default Server configuration.
+func Handler(h EndpointHandler) http.HandlerFunc {
+	return Default.HandlerFunc(h.ServeAPI)
+}
+
+// HandlerFunc returns a http.HandlerFunc that calls a REST API endpoint
+// function, bound to the configuration of the Server.
+//
+// See: restapi.HandlerFunc() for details.
+func (s *Server) HandlerFunc(h func(context.Context, *http.Request) any) http.HandlerFunc {
 	return func(rw http.ResponseWriter, rq *http.Request) {
-		apirq, err := newRequest(rq)
+		apirq, err := newRequest(s, rq)
 		if err != nil {
-			content, _ := json.Marshal(err.Error())
-			statusCode := http.StatusInternalServerError
-			if errors.Is(err, ErrInvalidAcceptHeader) {
-				//FUTURE: produce the list of supported content types from the marshal map
-				statusCode = http.StatusNotAcceptable
-				content = []byte("[\"application/json\",\"application/xml\",\"text/json\",\"test/xml\",\"*/*\",none]")
-			}
-			LogError(InternalError{
+			s.logError(InternalError{
 				Err:     err,
 				Request: rq,
 				Message: "error initialising request",
 			})
+			if errors.Is(err, ErrInvalidAcceptHeader) {
+				fallback := s.fallbackRequest(rq)
+				notAcceptable(fallback).
+					makeResponse(fallback).
+					write(rw, fallback)
+				return
+			}
+			content, _ := json.Marshal(err.Error())
+			statusCode := http.StatusInternalServerError
 			rw.Header().Set("Content-Type", "application/json")
 			rw.WriteHeader(statusCode)
 			if rwerr := responseWriterWrite(rw, content); rwerr != nil {
-				LogError(InternalError{
+				s.logError(InternalError{
 					Err:     rwerr,
 					Message: "error writing request error response",
 					Help:    fmt.Sprintf("(request error: %s): rw.Write() error: %s", err, rwerr),
@@ -91,28 +129,46 @@ func HandlerFunc(h func(context.Context, *http.Request) any) http.HandlerFunc {
 
 		defer func() {
 			if r := recover(); r != nil {
-				LogError(InternalError{
+				s.logError(InternalError{
 					Err:     fmt.Errorf("%v", r),
 					Message: "handler panic",
 					Request: rq,
 				})
 				InternalServerError(fmt.Errorf("panic: %v", r)).
 					makeResponse(apirq).
-					write(rw, rq)
+					write(rw, apirq)
 			}
 		}()
 
 		result := h(rq.Context(), rq)
-		response := makeRequestResponse(apirq, result)
-		response.write(rw, rq)
+		defer closeResult(result)
+
+		// a request accepting only an event stream is acceptable only to an
+		// endpoint returning an event stream; an error (or other unsuccessful)
+		// result is presented using the default content type
+		if apirq.eventStreamOnly && isSuccessful(result) && !isEventStream(result) {
+			s.logError(InternalError{
+				Err:     ErrInvalidAcceptHeader,
+				Request: rq,
+				Message: "content negotiation failed",
+				Help:    "the request accepts only text/event-stream but the result is not an event stream",
+			})
+			notAcceptable(apirq).
+				makeResponse(apirq).
+				write(rw, apirq)
+			return
+		}
+
+		response := makeRequestResponse(apirq, result).
+			evaluatePreconditions(apirq)
+		response.write(rw, apirq)
 	}
 }
 
-// Handler returns a http.HandlerFunc that calls a restapi.EndpointHandler.
+// Handler returns a http.HandlerFunc that calls a restapi.EndpointHandler,
+// bound to the configuration of the Server.
 //
-// A restapi.EndpointHandler is an interface that defines a ServeAPI method
-// that accepts a context.Context and a *http.Request argument, returning a
-// value of type 'any'.
-func Handler(h EndpointHandler) http.HandlerFunc {
-	return HandlerFunc(h.ServeAPI)
+// See: restapi.Handler() for details.
+func (s *Server) Handler(h EndpointHandler) http.HandlerFunc {
+	return s.HandlerFunc(h.ServeAPI)
 }
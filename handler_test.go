@@ -7,6 +7,7 @@ import (
 	"net/http/httptest"
 	"net/url"
 	"testing"
+	"time"
 
 	"github.com/blugnu/test"
 )
@@ -73,7 +74,11 @@ func TestHandler(t *testing.T) {
 		{scenario: "HandlerFunc/invalid request Accept header",
 			exec: func(t *testing.T) {
 				// ARRANGE
-				rq := &http.Request{Header: http.Header{"Accept": []string{"text/plain"}}}
+				defer test.Using(&nowUTC, func() time.Time { return time.Date(2010, 9, 8, 7, 6, 5, 0, time.UTC) })()
+				rq := &http.Request{
+					Header: http.Header{"Accept": []string{"text/plain"}},
+					URL:    &url.URL{Path: "/path"},
+				}
 				rec := &Recorder{ResponseRecorder: httptest.NewRecorder()}
 				isLogged := false
 				defer test.Using(&LogError, func(InternalError) {
@@ -87,10 +92,66 @@ func TestHandler(t *testing.T) {
 
 				// ASSERT
 				test.That(t, rec.statusCode).Equals(http.StatusNotAcceptable)
-				test.That(t, rec.Content()).Equals("[\"application/json\",\"application/xml\",\"text/json\",\"test/xml\",\"*/*\",none]")
+				test.That(t, rec.Header().Get("Content-Type")).Equals("application/json")
+				test.That(t, rec.Header().Get("Accept")).Equals("")
+				test.That(t, rec.Content()).Equals(`{` +
+					`"status":406,` +
+					`"error":"Not Acceptable",` +
+					`"message":"no formatter for content type",` +
+					`"path":"/path",` +
+					`"timestamp":"2010-09-08T07:06:05Z",` +
+					`"help":"the request Accept header must accept at least one of the supported content types",` +
+					`"additional":{"acceptable":["application/json","application/xml","text/json","text/xml","application/x-ndjson","application/hal+json","application/vnd.api+json"]}` +
+					`}`)
 				test.IsTrue(t, isLogged)
 			},
 		},
+		{scenario: "HandlerFunc/invalid request Accept header/registered content types",
+			exec: func(t *testing.T) {
+				// ARRANGE
+				m := NewMarshallers()
+				m.Unregister("application/json")
+				m.Register("application/cbor", func(any) ([]byte, error) { return []byte("cbor"), nil })
+				sut := &Server{Marshallers: m}
+				rq := &http.Request{
+					Header: http.Header{"Accept": []string{"application/json"}},
+					URL:    &url.URL{Path: "/path"},
+				}
+				rec := &Recorder{ResponseRecorder: httptest.NewRecorder()}
+
+				// ACT
+				sut.HandlerFunc(func(_ context.Context, rq *http.Request) any {
+					return nil
+				})(rec, rq)
+
+				// ASSERT
+				test.That(t, rec.statusCode).Equals(http.StatusNotAcceptable)
+				test.That(t, rec.Header().Get("Content-Type")).Equals("application/xml")
+				test.That(t, rec.Header().Get("Accept")).Equals("")
+				test.String(t, rec.Content()).Contains("<acceptable>[application/xml text/json text/xml application/x-ndjson application/hal+json application/vnd.api+json application/cbor]</acceptable>")
+			},
+		},
+		{scenario: "HandlerFunc/invalid request Accept header/no registered content types",
+			exec: func(t *testing.T) {
+				// ARRANGE
+				sut := &Server{Marshallers: &Marshallers{}}
+				rq := &http.Request{
+					Header: http.Header{"Accept": []string{"application/json"}},
+					URL:    &url.URL{Path: "/path"},
+				}
+				rec := &Recorder{ResponseRecorder: httptest.NewRecorder()}
+
+				// ACT
+				sut.HandlerFunc(func(_ context.Context, rq *http.Request) any {
+					return nil
+				})(rec, rq)
+
+				// ASSERT
+				test.That(t, rec.statusCode).Equals(http.StatusNotAcceptable)
+				test.That(t, rec.Header().Get("Content-Type")).Equals("application/json")
+				test.String(t, rec.Content()).Contains(`"additional":{"acceptable":null}`)
+			},
+		},
 		{scenario: "HandlerFunc/other request error",
 			exec: func(t *testing.T) {
 				// ARRANGE
@@ -98,7 +159,7 @@ func TestHandler(t *testing.T) {
 				rec := &Recorder{ResponseRecorder: httptest.NewRecorder()}
 
 				rqerr := errors.New("request error")
-				defer test.Using(&newRequest, func(*http.Request) (*Request, error) {
+				defer test.Using(&newRequest, func(*Server, *http.Request) (*Request, error) {
 					return nil, rqerr
 				})()
 
@@ -125,7 +186,7 @@ func TestHandler(t *testing.T) {
 				rec := &Recorder{ResponseRecorder: httptest.NewRecorder()}
 
 				rqerr := errors.New("request error")
-				defer test.Using(&newRequest, func(*http.Request) (*Request, error) {
+				defer test.Using(&newRequest, func(*Server, *http.Request) (*Request, error) {
 					return nil, rqerr
 				})()
 
@@ -182,7 +243,7 @@ func TestHandler(t *testing.T) {
 				rq := &http.Request{}
 				rec := &Recorder{ResponseRecorder: httptest.NewRecorder()}
 
-				defer test.Using(&newRequest, func(*http.Request) (*Request, error) {
+				defer test.Using(&newRequest, func(*Server, *http.Request) (*Request, error) {
 					return &Request{}, nil
 				})()
 
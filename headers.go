@@ -1,6 +1,13 @@
 package restapi
 
-import "net/http"
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"time"
+)
 
 // headers is a map of response headers; by using this type
 // rather than explicit map declaration the headers field in the
@@ -8,6 +15,20 @@ import "net/http"
 // worry about nil map errors
 type headers map[string]any
 
+// add adds a value to a header, retaining any values already set.  The
+// values of a header with more than one value are held in an []any.
+func (h headers) add(k string, v any) {
+	k = http.CanonicalHeaderKey(k)
+	switch existing := h[k].(type) {
+	case nil:
+		h[k] = []any{v}
+	case []any:
+		h[k] = append(existing, v)
+	default:
+		h[k] = []any{existing, v}
+	}
+}
+
 // WithHeader sets a header to be included in the response for the error.
 //
 // The specified header will be added to any headers already set on the Error.
@@ -49,3 +70,54 @@ func (h headers) setAll(headers map[string]any) {
 func (h headers) setNonCanonical(k string, v any) {
 	h[k] = v
 }
+
+// headerValues returns the values of a header formatted according to the
+// type of the value held:
+//
+//   - time.Time             // an HTTP-date (RFC 9110, 5.6.7) in UTC
+//   - time.Duration         // a number of (whole) seconds
+//   - *url.URL, url.URL     // the string form of the URL
+//   - *http.Cookie          // a Set-Cookie value (an invalid cookie is omitted)
+//   - http.Cookie           // as for *http.Cookie
+//   - a slice               // one header value for each element of the slice
+//   - nil                   // no value
+//   - <any other type>      // formatted using %v
+func headerValues(v any) []string {
+	switch v := v.(type) {
+	case nil:
+		return nil
+	case string:
+		return []string{v}
+	case []string:
+		return v
+	case []byte:
+		return []string{string(v)}
+	case time.Time:
+		return []string{v.UTC().Format(http.TimeFormat)}
+	case time.Duration:
+		return []string{strconv.FormatInt(int64(v/time.Second), 10)}
+	case url.URL:
+		return []string{v.String()}
+	case *url.URL:
+		if v == nil {
+			return nil
+		}
+		return []string{v.String()}
+	case http.Cookie:
+		return headerValues(&v)
+	case *http.Cookie:
+		if s := v.String(); s != "" {
+			return []string{s}
+		}
+		return nil
+	}
+
+	if rv := reflect.ValueOf(v); rv.Kind() == reflect.Slice {
+		values := make([]string, 0, rv.Len())
+		for i := 0; i < rv.Len(); i++ {
+			values = append(values, headerValues(rv.Index(i).Interface())...)
+		}
+		return values
+	}
+	return []string{fmt.Sprintf("%v", v)}
+}
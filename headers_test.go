@@ -1,7 +1,10 @@
 package restapi
 
 import (
+	"net/http"
+	"net/url"
 	"testing"
+	"time"
 
 	"github.com/blugnu/test"
 )
@@ -69,6 +72,76 @@ func TestHeaders(t *testing.T) {
 				})
 			},
 		},
+		{scenario: "add",
+			exec: func(t *testing.T) {
+				// ARRANGE
+				sut := &Result{headers: headers{
+					"Single": "value (a)",
+				}}
+
+				// ACT
+				_ = sut.AddHeader("single", "value (b)")
+				_ = sut.AddHeader("link", "<a>")
+				_ = sut.AddHeader("link", "<b>")
+
+				// ASSERT
+				test.Map(t, sut.headers).Equals(headers{
+					"Single": []any{"value (a)", "value (b)"},
+					"Link":   []any{"<a>", "<b>"},
+				})
+			},
+		},
+		{scenario: "add/error",
+			exec: func(t *testing.T) {
+				// ARRANGE
+				sut := &Error{}
+
+				// ACT
+				_ = sut.AddHeader("link", "<a>").AddHeader("link", "<b>")
+
+				// ASSERT
+				test.Map(t, sut.headers).Equals(headers{
+					"Link": []any{"<a>", "<b>"},
+				})
+			},
+		},
+		{scenario: "headerValues",
+			exec: func(t *testing.T) {
+				// ARRANGE
+				u, _ := url.Parse("https://example.com/path?q=1")
+				ts := time.Date(2010, 11, 12, 13, 14, 15, 0, time.FixedZone("CET", 3600))
+				testcases := []struct {
+					name   string
+					value  any
+					result []string
+				}{
+					{name: "nil", value: nil, result: nil},
+					{name: "string", value: "value", result: []string{"value"}},
+					{name: "[]string", value: []string{"a", "b"}, result: []string{"a", "b"}},
+					{name: "[]any", value: []any{"a", 1, time.Minute}, result: []string{"a", "1", "60"}},
+					{name: "int", value: 42, result: []string{"42"}},
+					{name: "time.Time", value: ts, result: []string{"Fri, 12 Nov 2010 12:14:15 GMT"}},
+					{name: "time.Duration", value: 90*time.Second + 500*time.Millisecond, result: []string{"90"}},
+					{name: "*url.URL", value: u, result: []string{"https://example.com/path?q=1"}},
+					{name: "*url.URL/nil", value: (*url.URL)(nil), result: nil},
+					{name: "url.URL", value: *u, result: []string{"https://example.com/path?q=1"}},
+					{name: "http.Cookie", value: http.Cookie{Name: "id", Value: "a1"}, result: []string{"id=a1"}},
+					{name: "*http.Cookie", value: &http.Cookie{Name: "id", Value: "a1", Path: "/"}, result: []string{"id=a1; Path=/"}},
+					{name: "*http.Cookie/invalid", value: &http.Cookie{Name: "in valid"}, result: nil},
+					{name: "[]*http.Cookie", value: []*http.Cookie{{Name: "a", Value: "1"}, {Name: "b", Value: "2"}}, result: []string{"a=1", "b=2"}},
+					{name: "[]byte", value: []byte("bytes"), result: []string{"bytes"}},
+				}
+				for _, tc := range testcases {
+					t.Run(tc.name, func(t *testing.T) {
+						// ACT
+						result := headerValues(tc.value)
+
+						// ASSERT
+						test.That(t, result).Equals(tc.result)
+					})
+				}
+			},
+		},
 	}
 	for _, tc := range testcases {
 		t.Run(tc.scenario, func(t *testing.T) {
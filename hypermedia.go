@@ -0,0 +1,516 @@
+package restapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"mime"
+)
+
+// content types of hypermedia representations of a Resource or Collection
+const (
+	halContentType     = "application/hal+json"
+	jsonAPIContentType = "application/vnd.api+json"
+)
+
+// isHypermedia returns true if a content type is a hypermedia content type.
+func isHypermedia(contentType string) bool {
+	mt, _, _ := mime.ParseMediaType(contentType)
+	return mt == halContentType || mt == jsonAPIContentType
+}
+
+// link is a link from a Resource or Collection to a related resource.
+type link struct {
+	rel  string
+	href string
+}
+
+// Resource is a value with links to related resources and related resources
+// that are embedded (or included) with it, presented as a hypermedia document
+// when a hypermedia content type is negotiated:
+//
+//	application/hal+json       // a HAL document (_links and _embedded)
+//	application/vnd.api+json   // a JSON:API document (data, included and links)
+//
+// For any other content type, the value is marshalled as-is (the links and
+// embedded resources are omitted), so the same endpoint serves plain content
+// or hypermedia according to the request Accept header.
+//
+// Only a Resource or Collection is presented as a hypermedia document; any
+// other result (including an error) of a request for which a hypermedia
+// content type is negotiated is presented as application/json.
+//
+// The type and id of a Resource identify it in a JSON:API document; the value
+// provides its attributes (excluding any "id" or "type" field or a field named
+// as a relationship).  The value of
+// a Resource must marshal as a json object (or be nil).
+type Resource struct {
+	typ      string
+	id       string
+	value    any
+	links    []link
+	embedded []embedded
+}
+
+// embedded is a Resource or Collection of Resources embedded with a Resource,
+// identified by a relation name.  If linkage is true, the resources are only
+// identified in the relationships of a JSON:API resource object (they are not
+// included in the document or embedded in a HAL document).
+type embedded struct {
+	rel       string
+	resources []*Resource
+	many      bool
+	linkage   bool
+}
+
+// Collection is a list of Resources with links to related resources (e.g. the
+// pages of the collection), presented as a hypermedia document when a
+// hypermedia content type is negotiated (see: Resource).
+//
+// For any other content type, the values of the resources are marshalled as an
+// array (or a sequence of elements in xml).
+type Collection struct {
+	rel   string
+	items []*Resource
+	links []link
+}
+
+// NewResource returns a new Resource of a specified type and id with a value
+// providing its attributes.
+func NewResource(typ, id string, value any) *Resource {
+	return &Resource{typ: typ, id: id, value: value}
+}
+
+// NewCollection returns a new Collection of Resources.  In a HAL document the
+// items are embedded using the specified relation name (e.g. "orders").
+func NewCollection(rel string, items ...*Resource) *Collection {
+	return &Collection{rel: rel, items: items}
+}
+
+// WithLink adds a link to a related resource (e.g. "self").  A Resource may
+// have more than one link with the same relation in a HAL document; in a
+// JSON:API document only the first is presented.
+func (r *Resource) WithLink(rel, href string) *Resource {
+	r.links = append(r.links, link{rel: rel, href: href})
+	return r
+}
+
+// WithEmbedded embeds a related Resource (e.g. the customer of an order).  In a
+// JSON:API document the resource is identified in the relationships of the
+// Resource and included in the document.
+func (r *Resource) WithEmbedded(rel string, resource *Resource) *Resource {
+	r.embedded = append(r.embedded, embedded{rel: rel, resources: []*Resource{resource}})
+	return r
+}
+
+// WithEmbeddedCollection embeds a list of related Resources (e.g. the lines of
+// an order), presented as an array even if the list has only one item.
+func (r *Resource) WithEmbeddedCollection(rel string, resources ...*Resource) *Resource {
+	r.embedded = append(r.embedded, embedded{rel: rel, resources: resources, many: true})
+	return r
+}
+
+// WithRelationship identifies a related resource (e.g. the customer of an
+// order) in the relationships of a JSON:API resource object, without including
+// it in the document.  If the id is empty the relationship is null.  The
+// relationship is not presented in a HAL document.
+func (r *Resource) WithRelationship(rel, typ, id string) *Resource {
+	e := embedded{rel: rel, linkage: true}
+	if id != "" {
+		e.resources = []*Resource{NewResource(typ, id, nil)}
+	}
+	r.embedded = append(r.embedded, e)
+	return r
+}
+
+// WithRelationships identifies a list of related resources (e.g. the tags of an
+// article) in the relationships of a JSON:API resource object, without
+// including them in the document (see: WithRelationship).
+func (r *Resource) WithRelationships(rel, typ string, ids ...string) *Resource {
+	e := embedded{rel: rel, many: true, linkage: true, resources: []*Resource{}}
+	for _, id := range ids {
+		e.resources = append(e.resources, NewResource(typ, id, nil))
+	}
+	r.embedded = append(r.embedded, e)
+	return r
+}
+
+// WithLink adds a link to a related resource (e.g. "self" or "next").
+func (c *Collection) WithLink(rel, href string) *Collection {
+	c.links = append(c.links, link{rel: rel, href: href})
+	return c
+}
+
+// MarshalJSON marshals the value of the Resource.
+func (r *Resource) MarshalJSON() ([]byte, error) {
+	return json.Marshal(r.value)
+}
+
+// MarshalXML marshals the value of the Resource.
+func (r *Resource) MarshalXML(e *xml.Encoder, _ xml.StartElement) error {
+	return e.Encode(r.value)
+}
+
+// MarshalJSON marshals the values of the Resources in the Collection as an
+// array.
+func (c *Collection) MarshalJSON() ([]byte, error) {
+	values := make([]any, len(c.items))
+	for i, item := range c.items {
+		values[i] = item.value
+	}
+	return json.Marshal(values)
+}
+
+// MarshalXML marshals the values of the Resources in the Collection as a
+// sequence of elements.
+func (c *Collection) MarshalXML(e *xml.Encoder, _ xml.StartElement) error {
+	for _, item := range c.items {
+		if err := e.Encode(item.value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// jsonObject is a json object with members in the order in which they are
+// added.
+type jsonObject struct {
+	bytes.Buffer
+}
+
+// add adds a member to the object, marshalling a value unless it is a
+// json.RawMessage.
+func (o *jsonObject) add(name string, value any) error {
+	raw, ok := value.(json.RawMessage)
+	if !ok {
+		var err error
+		if raw, err = json.Marshal(value); err != nil {
+			return err
+		}
+	}
+	if o.Len() == 0 {
+		o.WriteByte('{')
+	} else {
+		o.WriteByte(',')
+	}
+	key, _ := json.Marshal(name)
+	o.Write(key)
+	o.WriteByte(':')
+	o.Write(raw)
+	return nil
+}
+
+// addMembers adds the members of a marshalled json object (other than any
+// with an excluded name) to the object.
+func (o *jsonObject) addMembers(raw []byte, exclude ...string) error {
+	var members map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &members); err != nil || members == nil {
+		return fmt.Errorf("%w: resource value must marshal as a json object", ErrInvalidArgument)
+	}
+
+	// members are added in the order in which they appear in the value
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	_, _ = dec.Token()
+	for dec.More() {
+		tok, _ := dec.Token()
+		name := tok.(string)
+		var value json.RawMessage
+		_ = dec.Decode(&value)
+		if isExcluded(name, exclude) {
+			continue
+		}
+		if err := o.add(name, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// isExcluded returns true if a name is one of a list of excluded names.
+func isExcluded(name string, exclude []string) bool {
+	for _, x := range exclude {
+		if name == x {
+			return true
+		}
+	}
+	return false
+}
+
+// close returns the marshalled object.
+func (o *jsonObject) close() json.RawMessage {
+	if o.Len() == 0 {
+		return json.RawMessage("{}")
+	}
+	o.WriteByte('}')
+	return o.Bytes()
+}
+
+// halLinks returns the _links of a HAL document.  A relation with more than one
+// link is presented as an array.
+func halLinks(links []link) json.RawMessage {
+	obj := &jsonObject{}
+	rels := []string{}
+	byRel := map[string][]map[string]string{}
+	for _, l := range links {
+		if _, ok := byRel[l.rel]; !ok {
+			rels = append(rels, l.rel)
+		}
+		byRel[l.rel] = append(byRel[l.rel], map[string]string{"href": l.href})
+	}
+	for _, rel := range rels {
+		if v := byRel[rel]; len(v) == 1 {
+			_ = obj.add(rel, v[0])
+		} else {
+			_ = obj.add(rel, v)
+		}
+	}
+	return obj.close()
+}
+
+// hal returns the HAL representation of the Resource.
+func (r *Resource) hal() (json.RawMessage, error) {
+	obj := &jsonObject{}
+	if r.value != nil {
+		raw, err := json.Marshal(r.value)
+		if err != nil {
+			return nil, err
+		}
+		if string(raw) != "null" {
+			if err := obj.addMembers(raw, "_links", "_embedded"); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if len(r.links) > 0 {
+		_ = obj.add("_links", halLinks(r.links))
+	}
+
+	emb := &jsonObject{}
+	for _, e := range r.embedded {
+		if e.linkage {
+			continue
+		}
+		items := make([]json.RawMessage, len(e.resources))
+		for i, res := range e.resources {
+			var err error
+			if items[i], err = res.hal(); err != nil {
+				return nil, err
+			}
+		}
+		if e.many {
+			_ = emb.add(e.rel, items)
+		} else {
+			_ = emb.add(e.rel, items[0])
+		}
+	}
+	if emb.Len() > 0 {
+		_ = obj.add("_embedded", emb.close())
+	}
+	return obj.close(), nil
+}
+
+// hal returns the HAL representation of the Collection.
+func (c *Collection) hal() (json.RawMessage, error) {
+	obj := &jsonObject{}
+	if len(c.links) > 0 {
+		_ = obj.add("_links", halLinks(c.links))
+	}
+	items := make([]json.RawMessage, len(c.items))
+	for i, item := range c.items {
+		var err error
+		if items[i], err = item.hal(); err != nil {
+			return nil, err
+		}
+	}
+	emb := &jsonObject{}
+	_ = emb.add(c.rel, items)
+	_ = obj.add("_embedded", emb.close())
+	return obj.close(), nil
+}
+
+// MarshalHAL marshals a Resource or Collection as an application/hal+json
+// document.  Any other value is not a HAL document; ErrNotHypermedia is
+// returned and the value is presented as application/json.
+func MarshalHAL(v any) ([]byte, error) {
+	switch v := v.(type) {
+	case *Resource:
+		return v.hal()
+	case *Collection:
+		return v.hal()
+	}
+	return nil, fmt.Errorf("%w: %T", ErrNotHypermedia, v)
+}
+
+// jsonAPILinks returns the links of a JSON:API document or resource object.
+// Only the first link for each relation is presented.
+func jsonAPILinks(links []link) json.RawMessage {
+	obj := &jsonObject{}
+	seen := map[string]bool{}
+	for _, l := range links {
+		if !seen[l.rel] {
+			seen[l.rel] = true
+			_ = obj.add(l.rel, l.href)
+		}
+	}
+	return obj.close()
+}
+
+// resourceIdentifier identifies a resource in the relationships of a JSON:API
+// resource object.
+type resourceIdentifier struct {
+	Type string `json:"type"`
+	ID   string `json:"id"`
+}
+
+// identifier returns the JSON:API resource identifier of the Resource.
+func (r *Resource) identifier() resourceIdentifier {
+	return resourceIdentifier{Type: r.typ, ID: r.id}
+}
+
+// jsonAPIDocument accumulates the included resources of a JSON:API document,
+// each resource being included once.
+type jsonAPIDocument struct {
+	included []json.RawMessage
+	seen     map[[2]string]bool
+}
+
+// resourceObject returns the JSON:API resource object for a Resource, adding
+// any embedded resources to the included resources of the document.
+func (doc *jsonAPIDocument) resourceObject(r *Resource, withLinks bool) (json.RawMessage, error) {
+	if r.typ == "" {
+		return nil, fmt.Errorf("%w: a JSON:API resource must have a type", ErrInvalidArgument)
+	}
+
+	obj := &jsonObject{}
+	_ = obj.add("type", r.typ)
+	if r.id != "" {
+		_ = obj.add("id", r.id)
+	}
+
+	if r.value != nil {
+		raw, err := json.Marshal(r.value)
+		if err != nil {
+			return nil, err
+		}
+		if string(raw) != "null" {
+			// attributes share a namespace with relationships, so any member of
+			// the value named as a relationship is omitted
+			exclude := []string{"id", "type"}
+			for _, e := range r.embedded {
+				exclude = append(exclude, e.rel)
+			}
+			attrs := &jsonObject{}
+			if err := attrs.addMembers(raw, exclude...); err != nil {
+				return nil, err
+			}
+			_ = obj.add("attributes", attrs.close())
+		}
+	}
+
+	if len(r.embedded) > 0 {
+		rels := &jsonObject{}
+		for _, e := range r.embedded {
+			ids := make([]resourceIdentifier, len(e.resources))
+			for i, res := range e.resources {
+				ids[i] = res.identifier()
+				if e.linkage {
+					continue
+				}
+				if err := doc.include(res); err != nil {
+					return nil, err
+				}
+			}
+			switch {
+			case e.many:
+				_ = rels.add(e.rel, map[string]any{"data": ids})
+			case len(ids) == 0:
+				_ = rels.add(e.rel, map[string]any{"data": nil})
+			default:
+				_ = rels.add(e.rel, map[string]any{"data": ids[0]})
+			}
+		}
+		_ = obj.add("relationships", rels.close())
+	}
+
+	if withLinks && len(r.links) > 0 {
+		_ = obj.add("links", jsonAPILinks(r.links))
+	}
+	return obj.close(), nil
+}
+
+// include adds a Resource to the included resources of the document (if not
+// already included).
+func (doc *jsonAPIDocument) include(r *Resource) error {
+	key := [2]string{r.typ, r.id}
+	if doc.seen[key] {
+		return nil
+	}
+	doc.seen[key] = true
+
+	obj, err := doc.resourceObject(r, true)
+	if err != nil {
+		return err
+	}
+	doc.included = append(doc.included, obj)
+	return nil
+}
+
+// marshal returns the JSON:API document with primary data and links.
+func (doc *jsonAPIDocument) marshal(data any, links []link) []byte {
+	obj := &jsonObject{}
+	_ = obj.add("data", data)
+	if len(doc.included) > 0 {
+		_ = obj.add("included", doc.included)
+	}
+	if len(links) > 0 {
+		_ = obj.add("links", jsonAPILinks(links))
+	}
+	return obj.close()
+}
+
+// JSONAPIMarshaler is the interface implemented by a value (other than a
+// Resource or Collection) that marshals itself as a JSON:API document, e.g. an
+// error document (see: jsonapi.ProjectError).
+type JSONAPIMarshaler interface {
+	MarshalJSONAPI() ([]byte, error)
+}
+
+// MarshalJSONAPI marshals a value as an application/vnd.api+json document.  A
+// Resource or Collection is marshalled as a JSON:API document, with the links
+// of the Resource (or Collection) as the links of the document and any
+// embedded resources included in the document; a JSONAPIMarshaler marshals
+// itself.
+//
+// Any other value is not a JSON:API document; ErrNotHypermedia is returned
+// and the value is presented as application/json.
+func MarshalJSONAPI(v any) ([]byte, error) {
+	doc := &jsonAPIDocument{seen: map[[2]string]bool{}}
+	switch v := v.(type) {
+	case *Resource:
+		doc.seen[[2]string{v.typ, v.id}] = true
+		data, err := doc.resourceObject(v, false)
+		if err != nil {
+			return nil, err
+		}
+		return doc.marshal(data, v.links), nil
+
+	case *Collection:
+		for _, item := range v.items {
+			doc.seen[[2]string{item.typ, item.id}] = true
+		}
+		data := make([]json.RawMessage, len(v.items))
+		for i, item := range v.items {
+			var err error
+			if data[i], err = doc.resourceObject(item, true); err != nil {
+				return nil, err
+			}
+		}
+		return doc.marshal(data, v.links), nil
+
+	case JSONAPIMarshaler:
+		return v.MarshalJSONAPI()
+	}
+	return nil, fmt.Errorf("%w: %T", ErrNotHypermedia, v)
+}
@@ -0,0 +1,319 @@
+package restapi
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/blugnu/test"
+)
+
+func TestHypermedia(t *testing.T) {
+	// ARRANGE
+	type customer struct {
+		ID   string `json:"id" xml:"id"`
+		Name string `json:"name" xml:"name"`
+	}
+	type line struct {
+		Product string `json:"product"`
+		Qty     int    `json:"qty"`
+	}
+	type order struct {
+		XMLName struct{} `json:"-" xml:"order"`
+		ID      string   `json:"id" xml:"id"`
+		Total   int      `json:"total" xml:"total"`
+	}
+
+	newOrder := func() *Resource {
+		return NewResource("orders", "1", order{ID: "1", Total: 42}).
+			WithLink("self", "/orders/1").
+			WithEmbedded("customer", NewResource("customers", "9", customer{ID: "9", Name: "Jane"}).
+				WithLink("self", "/customers/9")).
+			WithEmbeddedCollection("lines", NewResource("lines", "1-1", line{Product: "widget", Qty: 2}))
+	}
+	serve := func(accept string, result any) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		rq := httptest.NewRequest(http.MethodGet, "/orders/1", nil)
+		rq.Header.Set("Accept", accept)
+		HandlerFunc(func(context.Context, *http.Request) any { return result })(rec, rq)
+		return rec
+	}
+
+	testcases := []struct {
+		scenario string
+		exec     func(t *testing.T)
+	}{
+		{scenario: "resource/json",
+			exec: func(t *testing.T) {
+				// ACT
+				rec := serve("application/json", newOrder())
+
+				// ASSERT
+				test.That(t, rec.Header().Get("Content-Type")).Equals("application/json")
+				test.That(t, rec.Body.String()).Equals(`{"id":"1","total":42}`)
+			},
+		},
+		{scenario: "resource/xml",
+			exec: func(t *testing.T) {
+				// ACT
+				rec := serve("application/xml", newOrder())
+
+				// ASSERT
+				test.That(t, rec.Body.String()).Equals(`<order><id>1</id><total>42</total></order>`)
+			},
+		},
+		{scenario: "resource/hal",
+			exec: func(t *testing.T) {
+				// ACT
+				rec := serve("application/hal+json", newOrder())
+
+				// ASSERT
+				test.That(t, rec.Header().Get("Content-Type")).Equals("application/hal+json")
+				test.That(t, rec.Body.String()).Equals(`{` +
+					`"id":"1","total":42,` +
+					`"_links":{"self":{"href":"/orders/1"}},` +
+					`"_embedded":{` +
+					`"customer":{"id":"9","name":"Jane","_links":{"self":{"href":"/customers/9"}}},` +
+					`"lines":[{"product":"widget","qty":2}]` +
+					`}}`)
+			},
+		},
+		{scenario: "resource/hal/multiple links with same relation",
+			exec: func(t *testing.T) {
+				// ARRANGE
+				r := NewResource("", "", nil).
+					WithLink("item", "/a").
+					WithLink("self", "/").
+					WithLink("item", "/b")
+
+				// ACT
+				result, err := MarshalHAL(r)
+
+				// ASSERT
+				test.Error(t, err).IsNil()
+				test.That(t, string(result)).Equals(`{"_links":{"item":[{"href":"/a"},{"href":"/b"}],"self":{"href":"/"}}}`)
+			},
+		},
+		{scenario: "resource/jsonapi",
+			exec: func(t *testing.T) {
+				// ACT
+				rec := serve("application/vnd.api+json", newOrder())
+
+				// ASSERT
+				test.That(t, rec.Header().Get("Content-Type")).Equals("application/vnd.api+json")
+				test.That(t, rec.Body.String()).Equals(`{` +
+					`"data":{"type":"orders","id":"1","attributes":{"total":42},"relationships":{` +
+					`"customer":{"data":{"type":"customers","id":"9"}},` +
+					`"lines":{"data":[{"type":"lines","id":"1-1"}]}` +
+					`}},` +
+					`"included":[` +
+					`{"type":"customers","id":"9","attributes":{"name":"Jane"},"links":{"self":"/customers/9"}},` +
+					`{"type":"lines","id":"1-1","attributes":{"product":"widget","qty":2}}` +
+					`],` +
+					`"links":{"self":"/orders/1"}` +
+					`}`)
+			},
+		},
+		{scenario: "resource/jsonapi/included once",
+			exec: func(t *testing.T) {
+				// ARRANGE
+				c := NewResource("customers", "9", nil)
+				r := NewResource("orders", "1", nil).
+					WithEmbedded("customer", c).
+					WithEmbedded("billTo", c)
+
+				// ACT
+				result, err := MarshalJSONAPI(r)
+
+				// ASSERT
+				test.Error(t, err).IsNil()
+				test.That(t, string(result)).Equals(`{` +
+					`"data":{"type":"orders","id":"1","relationships":{` +
+					`"customer":{"data":{"type":"customers","id":"9"}},` +
+					`"billTo":{"data":{"type":"customers","id":"9"}}` +
+					`}},` +
+					`"included":[{"type":"customers","id":"9"}]` +
+					`}`)
+			},
+		},
+		{scenario: "resource/relationships",
+			exec: func(t *testing.T) {
+				// ARRANGE
+				r := NewResource("articles", "1", map[string]any{"title": "x", "author": "9"}).
+					WithRelationship("author", "people", "9").
+					WithRelationship("editor", "people", "").
+					WithRelationships("tags", "tags", "a", "b").
+					WithRelationships("comments", "comments")
+
+				// ACT
+				hal, halErr := MarshalHAL(r)
+				jsonAPI, jsonAPIErr := MarshalJSONAPI(r)
+
+				// ASSERT
+				test.Error(t, halErr).IsNil()
+				test.Error(t, jsonAPIErr).IsNil()
+				test.That(t, string(hal)).Equals(`{"author":"9","title":"x"}`)
+				test.That(t, string(jsonAPI)).Equals(`{` +
+					`"data":{"type":"articles","id":"1","attributes":{"title":"x"},"relationships":{` +
+					`"author":{"data":{"type":"people","id":"9"}},` +
+					`"editor":{"data":null},` +
+					`"tags":{"data":[{"type":"tags","id":"a"},{"type":"tags","id":"b"}]},` +
+					`"comments":{"data":[]}` +
+					`}}` +
+					`}`)
+			},
+		},
+		{scenario: "resource/jsonapi/no type",
+			exec: func(t *testing.T) {
+				// ACT
+				_, err := MarshalJSONAPI(NewResource("", "1", nil))
+
+				// ASSERT
+				test.Error(t, err).Is(ErrInvalidArgument)
+			},
+		},
+		{scenario: "resource/value is not an object",
+			exec: func(t *testing.T) {
+				// ARRANGE
+				r := NewResource("numbers", "1", 42)
+
+				// ACT
+				_, halErr := MarshalHAL(r)
+				_, jsonAPIErr := MarshalJSONAPI(r)
+
+				// ASSERT
+				test.Error(t, halErr).Is(ErrInvalidArgument)
+				test.Error(t, jsonAPIErr).Is(ErrInvalidArgument)
+			},
+		},
+		{scenario: "collection/json",
+			exec: func(t *testing.T) {
+				// ACT
+				rec := serve("application/json", NewCollection("orders", newOrder()).WithLink("self", "/orders"))
+
+				// ASSERT
+				test.That(t, rec.Body.String()).Equals(`[{"id":"1","total":42}]`)
+			},
+		},
+		{scenario: "collection/xml",
+			exec: func(t *testing.T) {
+				// ACT
+				rec := serve("application/xml", NewCollection("orders", newOrder(), newOrder()))
+
+				// ASSERT
+				test.That(t, rec.Body.String()).Equals(`<order><id>1</id><total>42</total></order><order><id>1</id><total>42</total></order>`)
+			},
+		},
+		{scenario: "collection/hal",
+			exec: func(t *testing.T) {
+				// ARRANGE
+				c := NewCollection("orders", NewResource("orders", "1", order{ID: "1", Total: 42}).WithLink("self", "/orders/1")).
+					WithLink("self", "/orders")
+
+				// ACT
+				rec := serve("application/hal+json", c)
+
+				// ASSERT
+				test.That(t, rec.Body.String()).Equals(`{` +
+					`"_links":{"self":{"href":"/orders"}},` +
+					`"_embedded":{"orders":[{"id":"1","total":42,"_links":{"self":{"href":"/orders/1"}}}]}` +
+					`}`)
+			},
+		},
+		{scenario: "collection/jsonapi",
+			exec: func(t *testing.T) {
+				// ARRANGE
+				c := NewCollection("orders",
+					NewResource("orders", "1", nil).WithLink("self", "/orders/1"),
+					NewResource("orders", "2", nil).WithEmbedded("parent", NewResource("orders", "1", nil)),
+				).WithLink("self", "/orders")
+
+				// ACT
+				rec := serve("application/vnd.api+json", c)
+
+				// ASSERT
+				test.That(t, rec.Body.String()).Equals(`{` +
+					`"data":[` +
+					`{"type":"orders","id":"1","links":{"self":"/orders/1"}},` +
+					`{"type":"orders","id":"2","relationships":{"parent":{"data":{"type":"orders","id":"1"}}}}` +
+					`],` +
+					`"links":{"self":"/orders"}` +
+					`}`)
+			},
+		},
+		{scenario: "other values",
+			exec: func(t *testing.T) {
+				// ACT
+				hal, halErr := MarshalHAL(map[string]int{"a": 1})
+				jsonAPI, jsonAPIErr := MarshalJSONAPI([]int{1})
+
+				// ASSERT
+				test.Error(t, halErr).Is(ErrNotHypermedia)
+				test.Error(t, jsonAPIErr).Is(ErrNotHypermedia)
+				test.IsTrue(t, hal == nil)
+				test.IsTrue(t, jsonAPI == nil)
+			},
+		},
+		{scenario: "other values/hal",
+			exec: func(t *testing.T) {
+				// ACT
+				rec := serve("application/hal+json", map[string]int{"a": 1})
+
+				// ASSERT
+				test.That(t, rec.Header().Get("Content-Type")).Equals("application/json")
+				test.That(t, rec.Header().Get("Vary")).Equals("Accept")
+				test.That(t, rec.Body.String()).Equals(`{"a":1}`)
+			},
+		},
+		{scenario: "other values/jsonapi",
+			exec: func(t *testing.T) {
+				// ACT
+				rec := serve("application/vnd.api+json", []int{1})
+
+				// ASSERT
+				test.That(t, rec.Header().Get("Content-Type")).Equals("application/json")
+				test.That(t, rec.Body.String()).Equals(`[1]`)
+			},
+		},
+		{scenario: "other values/error",
+			exec: func(t *testing.T) {
+				// ACT
+				rec := serve("application/vnd.api+json", errors.New("failed"))
+
+				// ASSERT
+				test.That(t, rec.Code).Equals(http.StatusInternalServerError)
+				test.That(t, rec.Header().Get("Content-Type")).Equals("application/json")
+				test.String(t, rec.Body.String()).Contains(`"status":500`)
+			},
+		},
+		{scenario: "other values/problem",
+			exec: func(t *testing.T) {
+				// ACT
+				rec := serve("application/hal+json", &Problem{Status: http.StatusConflict, Title: "conflict"})
+
+				// ASSERT
+				test.That(t, rec.Header().Get("Content-Type")).Equals("application/problem+json")
+				test.String(t, rec.Body.String()).Contains(`"title":"conflict"`)
+			},
+		},
+		{scenario: "other values/stream",
+			exec: func(t *testing.T) {
+				// ACT
+				rec := serve("application/hal+json", Stream(func(yield func(*Resource) bool) {
+					_ = yield(NewResource("orders", "1", order{ID: "1", Total: 42}).WithLink("self", "/orders/1"))
+				}))
+
+				// ASSERT
+				test.That(t, rec.Header().Get("Content-Type")).Equals("application/json")
+				test.That(t, rec.Body.String()).Equals(`[{"id":"1","total":42}]`)
+			},
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.scenario, func(t *testing.T) {
+			tc.exec(t)
+		})
+	}
+}
@@ -0,0 +1,270 @@
+// Package bind provides functions for binding string values (such as form
+// fields) to the fields of a struct, identified by struct tags.
+package bind
+
+import (
+	"encoding"
+	"fmt"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+	durationType        = reflect.TypeOf(time.Duration(0))
+	timeType            = reflect.TypeOf(time.Time{})
+)
+
+// field identifies a struct field to which values may be bound.
+type field struct {
+	name  string
+	value reflect.Value
+	tag   reflect.StructTag
+}
+
+// Source provides values for the fields of a struct identified by a
+// particular tag.
+type Source struct {
+	// Tag is the struct tag identifying fields to be bound from the source
+	Tag string
+
+	// Values returns the values for a named field; if the source has no
+	// values for the field, an empty slice (or nil) is returned.
+	Values func(name string) []string
+}
+
+// fields returns the fields of a struct value that are identified by a
+// specified tag.  Fields without the tag are identified by the field name.
+// Fields tagged "-" and unexported fields are ignored.
+//
+// The fields of embedded (anonymous) structs are treated as fields of the
+// containing struct, unless the embedded struct is itself tagged.
+func fields(v reflect.Value, tag string) []field {
+	result := []field{}
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		name, _, _ := strings.Cut(sf.Tag.Get(tag), ",")
+		switch {
+		case name == "-":
+			continue
+
+		case sf.Anonymous && name == "" && sf.Type.Kind() == reflect.Struct:
+			result = append(result, fields(v.Field(i), tag)...)
+			continue
+
+		case !sf.IsExported():
+			continue
+		}
+		result = append(result, field{name: coalesce(name, sf.Name), value: v.Field(i), tag: sf.Tag})
+	}
+	return result
+}
+
+// coalesce returns the first non-empty string.
+func coalesce(s ...string) string {
+	for _, s := range s {
+		if s != "" {
+			return s
+		}
+	}
+	return ""
+}
+
+// Form binds url.Values to the fields of the struct referenced by v.  Fields
+// are identified by a `form` tag or, if the field has no `form` tag, the field
+// name.
+//
+// If strict is true, any values that do not correspond to a field of the
+// struct result in an ErrUnknownField error.
+//
+// Errors binding individual fields are returned as an Errors collection.
+func Form(values url.Values, v any, strict bool) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("%w: %T", ErrNotAStruct, v)
+	}
+
+	errs := Errors{}
+	known := map[string]bool{}
+	for _, f := range fields(rv.Elem(), "form") {
+		known[f.name] = true
+		s, ok := values[f.name]
+		if !ok {
+			continue
+		}
+		if err := Value(f.value, s, f.tag.Get("format")); err != nil {
+			errs = append(errs, FieldError{Name: f.name, Err: err})
+		}
+	}
+
+	if strict {
+		for k := range values {
+			if !known[k] {
+				errs = append(errs, FieldError{Name: k, Err: ErrUnknownField})
+			}
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// Struct binds values from one or more sources to the fields of the struct
+// referenced by v.  A field is bound from the first source for which the field
+// has a tag.  Fields with no tag for any source are not bound.
+//
+// If a source provides no values for a field, the value of any `default` tag
+// on the field is used.  For slices, a default value may specify multiple
+// comma-separated values.
+//
+// A `format` tag may be used to specify the layout of time.Time values (the
+// default is time.RFC3339).
+//
+// Errors binding individual fields are returned as an Errors collection.
+func Struct(v any, sources ...Source) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("%w: %T", ErrNotAStruct, v)
+	}
+
+	errs := Errors{}
+	for _, f := range fields(rv.Elem(), "") {
+		for _, src := range sources {
+			name, ok := f.tag.Lookup(src.Tag)
+			if !ok || name == "" || name == "-" {
+				continue
+			}
+
+			values := src.Values(name)
+			if def, ok := f.tag.Lookup("default"); ok && len(values) == 0 {
+				values = []string{def}
+				if f.value.Kind() == reflect.Slice {
+					values = strings.Split(def, ",")
+				}
+			}
+
+			if err := Value(f.value, values, f.tag.Get("format")); err != nil {
+				errs = append(errs, FieldError{Source: src.Tag, Name: name, Err: err})
+			}
+			break
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// Value sets a value from one or more strings.  Slices are set with an element
+// for each string; for any other type only the first string is used.
+//
+// The following types are supported (including pointers to and slices of):
+//
+//	string
+//	bool
+//	int, int8, int16, int32, int64
+//	uint, uint8, uint16, uint32, uint64
+//	float32, float64
+//	time.Duration    // parsed using time.ParseDuration
+//	time.Time        // parsed using the specified layout (default: time.RFC3339)
+//	encoding.TextUnmarshaler
+func Value(v reflect.Value, values []string, layout string) error {
+	if len(values) == 0 {
+		return nil
+	}
+
+	if v.Kind() == reflect.Slice && !v.Addr().Type().Implements(textUnmarshalerType) {
+		s := reflect.MakeSlice(v.Type(), len(values), len(values))
+		for i, value := range values {
+			if err := scalar(s.Index(i), value, layout); err != nil {
+				return err
+			}
+		}
+		v.Set(s)
+		return nil
+	}
+
+	return scalar(v, values[0], layout)
+}
+
+// scalar sets a (non-slice) value from a string.
+func scalar(v reflect.Value, s string, layout string) error {
+	if v.Kind() == reflect.Pointer {
+		p := reflect.New(v.Type().Elem())
+		if err := scalar(p.Elem(), s, layout); err != nil {
+			return err
+		}
+		v.Set(p)
+		return nil
+	}
+
+	switch v.Type() {
+	case timeType:
+		t, err := time.Parse(coalesce(layout, time.RFC3339), s)
+		if err != nil {
+			return fmt.Errorf("%w: %q: expected time in format %s", ErrInvalidValue, s, coalesce(layout, time.RFC3339))
+		}
+		v.Set(reflect.ValueOf(t))
+		return nil
+
+	case durationType:
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return fmt.Errorf("%w: %q: expected duration", ErrInvalidValue, s)
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+
+	if v.Addr().Type().Implements(textUnmarshalerType) {
+		if err := v.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s)); err != nil {
+			return fmt.Errorf("%w: %w", ErrInvalidValue, err)
+		}
+		return nil
+	}
+
+	var err error
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+
+	case reflect.Bool:
+		var b bool
+		if b, err = strconv.ParseBool(s); err == nil {
+			v.SetBool(b)
+		}
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		var i int64
+		if i, err = strconv.ParseInt(s, 10, v.Type().Bits()); err == nil {
+			v.SetInt(i)
+		}
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		var u uint64
+		if u, err = strconv.ParseUint(s, 10, v.Type().Bits()); err == nil {
+			v.SetUint(u)
+		}
+
+	case reflect.Float32, reflect.Float64:
+		var f float64
+		if f, err = strconv.ParseFloat(s, v.Type().Bits()); err == nil {
+			v.SetFloat(f)
+		}
+
+	default:
+		return fmt.Errorf("%w: %s", ErrUnsupportedType, v.Type())
+	}
+
+	if err != nil {
+		return fmt.Errorf("%w: %q", ErrInvalidValue, s)
+	}
+	return nil
+}
@@ -0,0 +1,276 @@
+package bind
+
+import (
+	"errors"
+	"net"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/blugnu/test"
+)
+
+func TestBind(t *testing.T) {
+	// ARRANGE
+	type embedded struct {
+		E string `form:"e"`
+	}
+	type target struct {
+		embedded
+		S        string  `form:"s"`
+		B        bool    `form:"b"`
+		I        int8    `form:"i"`
+		U        uint16  `form:"u"`
+		F        float64 `form:"f"`
+		P        *int    `form:"p"`
+		Slice    []int   `form:"slice"`
+		IP       net.IP  `form:"ip"`
+		Ignored  string  `form:"-"`
+		Untagged string
+		private  string
+	}
+
+	testcases := []struct {
+		scenario string
+		exec     func(t *testing.T)
+	}{
+		{scenario: "Form",
+			exec: func(t *testing.T) {
+				// ARRANGE
+				values := url.Values{
+					"e":        {"embedded"},
+					"s":        {"string"},
+					"b":        {"true"},
+					"i":        {"-8"},
+					"u":        {"16"},
+					"f":        {"1.5"},
+					"p":        {"42"},
+					"slice":    {"1", "2", "3"},
+					"ip":       {"127.0.0.1"},
+					"Untagged": {"untagged"},
+				}
+				result := &target{}
+
+				// ACT
+				err := Form(values, result, true)
+
+				// ASSERT
+				test.Error(t, err).IsNil()
+				test.That(t, result).Equals(&target{
+					embedded: embedded{E: "embedded"},
+					S:        "string",
+					B:        true,
+					I:        -8,
+					U:        16,
+					F:        1.5,
+					P:        test.AddressOf(42),
+					Slice:    []int{1, 2, 3},
+					IP:       net.ParseIP("127.0.0.1"),
+					Untagged: "untagged",
+				})
+			},
+		},
+		{scenario: "Form/not a struct",
+			exec: func(t *testing.T) {
+				// ACT
+				err := Form(url.Values{}, test.AddressOf(1), false)
+
+				// ASSERT
+				test.Error(t, err).Is(ErrNotAStruct)
+			},
+		},
+		{scenario: "Form/invalid values",
+			exec: func(t *testing.T) {
+				// ARRANGE
+				values := url.Values{
+					"b":     {"not a bool"},
+					"i":     {"1000"},
+					"slice": {"1", "x"},
+					"ip":    {"not an ip"},
+				}
+
+				// ACT
+				err := Form(values, &target{}, false)
+
+				// ASSERT
+				test.Error(t, err).Is(ErrInvalidValue)
+				errs := Errors{}
+				test.IsTrue(t, errors.As(err, &errs))
+				test.That(t, len(errs)).Equals(4)
+			},
+		},
+		{scenario: "Form/unknown fields",
+			exec: func(t *testing.T) {
+				// ARRANGE
+				values := url.Values{"s": {"string"}, "Ignored": {"value"}, "private": {"value"}}
+
+				// ACT
+				lenient := Form(values, &target{}, false)
+				strict := Form(values, &target{}, true)
+
+				// ASSERT
+				test.Error(t, lenient).IsNil()
+				test.Error(t, strict).Is(ErrUnknownField)
+				test.That(t, len(strict.(Errors))).Equals(2)
+			},
+		},
+		{scenario: "Value/unsupported type",
+			exec: func(t *testing.T) {
+				// ARRANGE
+				v := struct{ C chan int }{}
+
+				// ACT
+				err := Value(reflect.ValueOf(&v).Elem().Field(0), []string{"x"}, "")
+
+				// ASSERT
+				test.Error(t, err).Is(ErrUnsupportedType)
+			},
+		},
+		{scenario: "Value/no values",
+			exec: func(t *testing.T) {
+				// ARRANGE
+				v := struct{ S string }{S: "unchanged"}
+
+				// ACT
+				err := Value(reflect.ValueOf(&v).Elem().Field(0), nil, "")
+
+				// ASSERT
+				test.Error(t, err).IsNil()
+				test.That(t, v.S).Equals("unchanged")
+			},
+		},
+		{scenario: "Value/time and duration",
+			exec: func(t *testing.T) {
+				// ARRANGE
+				var v struct {
+					T time.Time
+					D time.Duration
+					F time.Time
+				}
+				rv := reflect.ValueOf(&v).Elem()
+
+				// ACT
+				errT := Value(rv.Field(0), []string{"2010-09-08T07:06:05Z"}, "")
+				errD := Value(rv.Field(1), []string{"1m30s"}, "")
+				errF := Value(rv.Field(2), []string{"2010-09-08"}, "2006-01-02")
+
+				// ASSERT
+				test.Error(t, errT).IsNil()
+				test.Error(t, errD).IsNil()
+				test.Error(t, errF).IsNil()
+				test.That(t, v.T).Equals(time.Date(2010, 9, 8, 7, 6, 5, 0, time.UTC))
+				test.That(t, v.D).Equals(90 * time.Second)
+				test.That(t, v.F).Equals(time.Date(2010, 9, 8, 0, 0, 0, 0, time.UTC))
+			},
+		},
+		{scenario: "Value/invalid time and duration",
+			exec: func(t *testing.T) {
+				// ARRANGE
+				var v struct {
+					T time.Time
+					D time.Duration
+				}
+				rv := reflect.ValueOf(&v).Elem()
+
+				// ACT
+				errT := Value(rv.Field(0), []string{"2010-09-08"}, "")
+				errD := Value(rv.Field(1), []string{"soon"}, "")
+
+				// ASSERT
+				test.Error(t, errT).Is(ErrInvalidValue)
+				test.Error(t, errD).Is(ErrInvalidValue)
+			},
+		},
+		{scenario: "Struct",
+			exec: func(t *testing.T) {
+				// ARRANGE
+				type params struct {
+					ID      int      `path:"id"`
+					Limit   int      `query:"limit" default:"10"`
+					Tags    []string `query:"tag" default:"a,b"`
+					Tenant  string   `header:"X-Tenant" query:"tenant"`
+					Unbound string
+				}
+				src := func(tag string, values map[string][]string) Source {
+					return Source{Tag: tag, Values: func(name string) []string { return values[name] }}
+				}
+				result := &params{}
+
+				// ACT
+				err := Struct(result,
+					src("path", map[string][]string{"id": {"42"}}),
+					src("header", map[string][]string{"X-Tenant": {"acme"}}),
+					src("query", map[string][]string{"tenant": {"ignored"}}),
+				)
+
+				// ASSERT
+				test.Error(t, err).IsNil()
+				test.That(t, result).Equals(&params{
+					ID:     42,
+					Limit:  10,
+					Tags:   []string{"a", "b"},
+					Tenant: "acme",
+				})
+			},
+		},
+		{scenario: "Struct/not a struct",
+			exec: func(t *testing.T) {
+				// ACT
+				err := Struct(42)
+
+				// ASSERT
+				test.Error(t, err).Is(ErrNotAStruct)
+			},
+		},
+		{scenario: "Struct/invalid values",
+			exec: func(t *testing.T) {
+				// ARRANGE
+				type params struct {
+					ID    int `path:"id"`
+					Limit int `query:"limit" default:"ten"`
+				}
+				src := func(tag string, values map[string][]string) Source {
+					return Source{Tag: tag, Values: func(name string) []string { return values[name] }}
+				}
+
+				// ACT
+				err := Struct(&params{},
+					src("path", map[string][]string{"id": {"x"}}),
+					src("query", nil),
+				)
+
+				// ASSERT
+				var errs Errors
+				test.IsTrue(t, errors.As(err, &errs))
+				test.That(t, len(errs)).Equals(2)
+				test.That(t, errs[0].Source).Equals("path")
+				test.That(t, errs[0].Name).Equals("id")
+				test.That(t, errs[1].Source).Equals("query")
+				test.That(t, errs[1].Name).Equals("limit")
+				test.Error(t, err).Is(ErrInvalidValue)
+			},
+		},
+		{scenario: "FieldError",
+			exec: func(t *testing.T) {
+				// ARRANGE
+				errs := Errors{
+					{Name: "a", Err: ErrInvalidValue},
+					{Name: "b", Err: ErrUnknownField},
+				}
+
+				// ACT
+				s := errs.Error()
+
+				// ASSERT
+				test.That(t, s).Equals("a: invalid value; b: unknown field")
+				test.Error(t, errs).Is(ErrUnknownField)
+			},
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.scenario, func(t *testing.T) {
+			tc.exec(t)
+		})
+	}
+}
@@ -0,0 +1,54 @@
+package bind
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	ErrInvalidValue    = errors.New("invalid value")
+	ErrNotAStruct      = errors.New("not a pointer to a struct")
+	ErrUnknownField    = errors.New("unknown field")
+	ErrUnsupportedType = errors.New("unsupported type")
+)
+
+// FieldError describes an error binding a value to a named field.  Source
+// identifies the tag of the source from which the value was bound (if any).
+type FieldError struct {
+	Source string
+	Name   string
+	Err    error
+}
+
+// Error implements the error interface for a FieldError.
+func (e FieldError) Error() string {
+	return fmt.Sprintf("%s: %v", e.Name, e.Err)
+}
+
+// Unwrap returns the error wrapped by the FieldError.
+func (e FieldError) Unwrap() error {
+	return e.Err
+}
+
+// Errors is a collection of errors binding values to the fields of a struct.
+type Errors []FieldError
+
+// Error implements the error interface for Errors, returning the individual
+// errors separated by semi-colons.
+func (e Errors) Error() string {
+	s := make([]string, len(e))
+	for i, err := range e {
+		s[i] = err.Error()
+	}
+	return strings.Join(s, "; ")
+}
+
+// Unwrap returns the individual errors in the collection.
+func (e Errors) Unwrap() []error {
+	result := make([]error, len(e))
+	for i, err := range e {
+		result[i] = err
+	}
+	return result
+}
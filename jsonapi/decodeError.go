@@ -0,0 +1,60 @@
+package jsonapi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/blugnu/restapi"
+)
+
+// unknownFieldPrefix is the prefix of the error returned by a json.Decoder
+// when DisallowUnknownFields is set and an unknown field is encountered.
+const unknownFieldPrefix = "json: unknown field "
+
+// decodeError returns a 400 Bad Request Error for an error returned by a
+// json.Decoder, wrapping a sentinel error identifying the cause together with
+// properties locating the problem in the request body:
+//
+//	ErrSyntax                   // "offset"
+//	ErrTypeMismatch             // "offset", "field", "type" and "value"
+//	restapi.ErrUnexpectedField  // "field"
+//
+// Any other error is wrapped with ErrDecoder.
+func decodeError(err error) *restapi.Error {
+	var (
+		syntaxErr *json.SyntaxError
+		typeErr   *json.UnmarshalTypeError
+	)
+	switch {
+	case errors.As(err, &syntaxErr):
+		return restapi.BadRequest(fmt.Errorf("%w: %w", ErrSyntax, err)).
+			WithProperty("offset", syntaxErr.Offset)
+
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		return restapi.BadRequest(fmt.Errorf("%w: %w", ErrSyntax, err))
+
+	case errors.As(err, &typeErr):
+		return restapi.BadRequest(fmt.Errorf("%w: %w", ErrTypeMismatch, err)).
+			WithProperty("offset", typeErr.Offset).
+			WithProperty("field", typeErr.Field).
+			WithProperty("type", typeErr.Type.String()).
+			WithProperty("value", typeErr.Value)
+
+	// an unpleasant but necessary hack to detect unknown fields since the json
+	// decoder does not provide a specific error type which could be used to
+	// determine the cause of the error more reliably and precisely :(
+	case strings.HasPrefix(err.Error(), unknownFieldPrefix):
+		apierr := restapi.BadRequest(fmt.Errorf("%w: %w", restapi.ErrUnexpectedField, err))
+		if name, err := strconv.Unquote(strings.TrimPrefix(err.Error(), unknownFieldPrefix)); err == nil {
+			apierr = apierr.WithProperty("field", name)
+		}
+		return apierr
+
+	default:
+		return restapi.BadRequest(fmt.Errorf("%w: %w", ErrDecoder, err))
+	}
+}
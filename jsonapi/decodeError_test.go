@@ -0,0 +1,69 @@
+package jsonapi
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/blugnu/restapi"
+	"github.com/blugnu/test"
+)
+
+func TestDecodeError(t *testing.T) {
+	// ARRANGE
+	type target struct {
+		ID   int `json:"id"`
+		Item struct {
+			Count int `json:"count"`
+		} `json:"item"`
+	}
+	decode := func(s string) error {
+		dc := json.NewDecoder(strings.NewReader(s))
+		dc.DisallowUnknownFields()
+		return dc.Decode(&target{})
+	}
+
+	testcases := []struct {
+		scenario string
+		err      error
+		result   *restapi.Error
+	}{
+		{scenario: "syntax error",
+			err: decode(`{"id":}`),
+			result: restapi.BadRequest(ErrSyntax).
+				WithProperty("offset", int64(7)),
+		},
+		{scenario: "unexpected end of input",
+			err:    decode(`{"id":1`),
+			result: restapi.BadRequest(ErrSyntax),
+		},
+		{scenario: "type mismatch",
+			err: decode(`{"item":{"count":"many"}}`),
+			result: restapi.BadRequest(ErrTypeMismatch).
+				WithProperty("offset", int64(23)).
+				WithProperty("field", "item.count").
+				WithProperty("type", "int").
+				WithProperty("value", "string"),
+		},
+		{scenario: "unknown field",
+			err: decode(`{"name":"x"}`),
+			result: restapi.BadRequest(restapi.ErrUnexpectedField).
+				WithProperty("field", "name"),
+		},
+		{scenario: "other error",
+			err:    errors.New("other"),
+			result: restapi.BadRequest(ErrDecoder),
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.scenario, func(t *testing.T) {
+			// ACT
+			result := decodeError(tc.err)
+
+			// ASSERT
+			test.Error(t, result).Is(tc.result)
+			test.Error(t, result).Is(tc.err)
+		})
+	}
+}
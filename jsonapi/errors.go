@@ -3,5 +3,12 @@ package jsonapi
 import "errors"
 
 var (
-	ErrDecoder = errors.New("json.Decoder error")
+	ErrBodyTooLarge         = errors.New("request body too large")
+	ErrDecoder              = errors.New("json.Decoder error")
+	ErrInvalidDocument      = errors.New("invalid JSON:API document")
+	ErrMediaTypeParameter   = errors.New("unsupported JSON:API media type parameter")
+	ErrResourceTypeConflict = errors.New("resource type conflict")
+	ErrSyntax               = errors.New("malformed json")
+	ErrTrailingData         = errors.New("unexpected data after json value")
+	ErrTypeMismatch         = errors.New("json value has the wrong type")
 )
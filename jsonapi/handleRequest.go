@@ -3,10 +3,10 @@ package jsonapi
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
-	"strings"
 
 	"github.com/blugnu/restapi"
 )
@@ -16,9 +16,38 @@ var (
 	ioReadAll = io.ReadAll
 )
 
+// Options specifies options applied when handling a request.
+type Options struct {
+	// MaxBodySize is the maximum size (in bytes) of a request body.  A limit of
+	// zero (or less) is not applied.
+	MaxBodySize int64
+
+	// Stream specifies that the request body is to be decoded directly from
+	// the request, without buffering.  The request Body is consumed and cannot
+	// be re-read by the handler function.
+	Stream bool
+}
+
+// DefaultOptions are the options applied by HandleRequest and StrictRequest
+// if no options are specified in the call.  By default, the size of a request
+// body is not limited; a limit may be applied to all requests by setting
+// DefaultOptions.MaxBodySize, e.g:
+//
+//	jsonapi.DefaultOptions.MaxBodySize = 1 << 20 // 1 MiB
+var DefaultOptions = Options{}
+
+// bodyTooLarge returns a 413 Request Entity Too Large Error for a request
+// with a body exceeding a specified limit.
+func bodyTooLarge(limit int64) *restapi.Error {
+	return restapi.NewError(http.StatusRequestEntityTooLarge,
+		fmt.Errorf("%w: exceeds %d bytes", ErrBodyTooLarge, limit)).
+		WithProperty("limit", limit)
+}
+
 // handle reads the request body and unmarshals it into a value of type T
-// which is then passed to the supplied function to handle the request.  After
-// being read, the request Body is replaced with a new ReadCloser so that it
+// which is then passed to the supplied function to handle the request.  Unless
+// the Stream option is specified, the body is read in full before being
+// unmarshalled and the request Body is replaced with a new ReadCloser so that it
 // may be re-read by the handler function if required.
 //
 // If the strict argument is true then the request is required to have a non-empty
@@ -27,56 +56,107 @@ var (
 // If the strict argument is false then an empty body is allowed (the specified
 // function will be called with a `nil` argument) and any fields in the request
 // body that are not expected by the type T are silently ignored and discarded.
-func handle[T any](rq *http.Request, strict bool, h func(c *T) any) any {
-	body, err := ioReadAll(rq.Body)
-	if err != nil {
-		return fmt.Errorf("%w: %w", restapi.ErrErrorReadingRequestBody, err) //NOSONAR
+//
+// In either case, a body exceeding the MaxBodySize option results in a 413
+// Request Entity Too Large error and an unmarshalled value is validated using
+// restapi.Validate before being passed to the supplied function.
+func handle[T any](rq *http.Request, strict bool, h func(c *T) any, opts []Options) any {
+	opt := DefaultOptions
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+
+	var body io.Reader = rq.Body
+	if opt.MaxBodySize > 0 {
+		if rq.ContentLength > opt.MaxBodySize {
+			return bodyTooLarge(opt.MaxBodySize)
+		}
+		body = http.MaxBytesReader(nil, rq.Body, opt.MaxBodySize)
 	}
-	defer rq.Body.Close()
-	rq.Body = io.NopCloser(bytes.NewReader(body))
 
-	if len(body) == 0 {
-		// in strict mode, an empty or missing body constitutes a bad request
-		if strict {
-			return restapi.BadRequest(restapi.ErrBodyRequired)
+	if !opt.Stream {
+		content, err := ioReadAll(body)
+		if err != nil {
+			var mbe *http.MaxBytesError
+			if errors.As(err, &mbe) {
+				return bodyTooLarge(mbe.Limit)
+			}
+			return fmt.Errorf("%w: %w", restapi.ErrErrorReadingRequestBody, err) //NOSONAR
 		}
-		// otherwise an empty body may be expected by the handler so we let the
-		// handler decide what to do with a 'nil body'
-		return h(nil)
+		defer rq.Body.Close()
+		rq.Body = io.NopCloser(bytes.NewReader(content))
+		body = bytes.NewReader(content)
 	}
 
-	dc := json.NewDecoder(bytes.NewReader(body))
+	dc := json.NewDecoder(body)
 	if strict {
 		dc.DisallowUnknownFields()
 	}
 
 	c := new(T)
 	if err := dc.Decode(c); err != nil {
-		// an unpleasant but necessary hack to detect unknown fields since the json
-		// decoder does not provide specific errors types which could be used to
-		// determine the cause of the error more reliably and precisely :(
-		if strings.HasPrefix(err.Error(), "json: unknown field") {
-			return restapi.BadRequest(fmt.Errorf("%w: %w", restapi.ErrUnexpectedField, err))
+		var mbe *http.MaxBytesError
+		switch {
+		case err == io.EOF:
+			// in strict mode, an empty or missing body constitutes a bad request
+			if strict {
+				return restapi.BadRequest(restapi.ErrBodyRequired)
+			}
+			// otherwise an empty body may be expected by the handler so we let the
+			// handler decide what to do with a 'nil body'
+			return h(nil)
+
+		case errors.As(err, &mbe):
+			return bodyTooLarge(mbe.Limit)
+
+		default:
+			return decodeError(err)
 		}
-		return fmt.Errorf("%w: %w", ErrDecoder, err)
+	}
+
+	// the body must contain a single json value; anything other than whitespace
+	// following the value is an error
+	offset := dc.InputOffset()
+	if _, err := dc.Token(); err != io.EOF {
+		var mbe *http.MaxBytesError
+		if errors.As(err, &mbe) {
+			return bodyTooLarge(mbe.Limit)
+		}
+		return restapi.BadRequest(ErrTrailingData).
+			WithProperty("offset", offset)
+	}
+
+	if err := restapi.Validate(c); err != nil {
+		return err
 	}
 
 	return h(c)
 }
 
 // HandleRequest reads the request body and unmarshals it into a value of type T
-// which is then passed to the supplied function to handle the request.  After
-// being read, the request Body is replaced with a new ReadCloser so that it
-// may be re-read by the handler function if required.
+// which is then passed to the supplied function to handle the request.  Unless
+// the Stream option is specified, the request Body is replaced with a new
+// ReadCloser after being read so that it may be re-read by the handler function
+// if required.
 //
 //   - if the request body is empty, the handler function is called with a nil value.
 //
 //   - if the request body is not empty but cannot be unmarshalled into a value of type T,
-//     an error is returned.
+//     a 400 Bad Request error is returned, wrapping ErrSyntax, ErrTypeMismatch or
+//     ErrTrailingData according to the problem with the body.
 //
 //   - if the request body contains fields that are not expected by the handler function,
 //     they are ignored and discarded
 //
+//   - if the request body exceeds the maximum body size, a 413 Request Entity Too
+//     Large error is returned, wrapping ErrBodyTooLarge.
+//
+//   - if the unmarshalled value is not valid (see: restapi.Validate), the error returned
+//     by restapi.Validate is returned (422 Unprocessable Entity).
+//
+// The maximum body size and whether the body is buffered are specified by an
+// optional Options argument; if not specified, DefaultOptions are applied.
+//
 // To automatically treat unexpected fields or an empty body as an error, use
 // the StrictRequest function.
 //
@@ -99,24 +179,36 @@ func handle[T any](rq *http.Request, strict bool, h func(c *T) any) any {
 //	    return restapi.Created().WithValue(r)
 //	  })
 //	}
-func HandleRequest[T any](rq *http.Request, h func(c *T) any) any {
-	return handle[T](rq, false, h)
+func HandleRequest[T any](rq *http.Request, h func(c *T) any, opts ...Options) any {
+	return handle[T](rq, false, h, opts)
 }
 
 // StrictRequest reads the request body and unmarshals it into a value of type T
-// which is then passed to the supplied function to handle the request.  After
-// being read, the request Body is replaced with a new ReadCloser so that it
-// may be re-read by the handler function if required.
+// which is then passed to the supplied function to handle the request.  Unless
+// the Stream option is specified, the request Body is replaced with a new
+// ReadCloser after being read so that it may be re-read by the handler function
+// if required.
 //
 // The supplied function is not called if:
 //
 //   - the request body is not empty but cannot be unmarshalled into a value of type T;
+//     a 400 Bad Request error is returned, wrapping ErrSyntax, ErrTypeMismatch or
+//     ErrTrailingData according to the problem with the body;
 //
 //   - the request body is empty; restapi.BadRequest(restapi.ErrBodyRequired)
 //     is returned;
 //
 //   - the request body contains fields that are not expected by the marshalled
-//     value type; restapi.BadRequest(restapi.ErrUnexpectedField) is returned.
+//     value type; restapi.BadRequest(restapi.ErrUnexpectedField) is returned;
+//
+//   - the request body exceeds the maximum body size; a 413 Request Entity Too Large
+//     error is returned, wrapping ErrBodyTooLarge;
+//
+//   - the unmarshalled value is not valid (see: restapi.Validate); the error returned
+//     by restapi.Validate is returned (422 Unprocessable Entity).
+//
+// The maximum body size and whether the body is buffered are specified by an
+// optional Options argument; if not specified, DefaultOptions are applied.
 //
 // To accept requests with no body or which may contain additional fields not
 // supported by the type parameter T, use HandleRequest.
@@ -143,6 +235,6 @@ func HandleRequest[T any](rq *http.Request, h func(c *T) any) any {
 //	      return restapi.Created().WithValue(r)
 //	   })
 //	}
-func StrictRequest[T any](rq *http.Request, h func(c *T) any) any {
-	return handle[T](rq, true, h)
+func StrictRequest[T any](rq *http.Request, h func(c *T) any, opts ...Options) any {
+	return handle[T](rq, true, h, opts)
 }
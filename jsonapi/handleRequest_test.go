@@ -5,6 +5,7 @@ import (
 	"errors"
 	"io"
 	"net/http"
+	"strings"
 	"testing"
 
 	"github.com/blugnu/restapi"
@@ -92,7 +93,178 @@ func TestHandleRequest(t *testing.T) {
 				})
 
 				// ASSERT
-				test.Error(t, result.(error)).Is(ErrDecoder)
+				test.Error(t, result.(error)).Is(restapi.BadRequest(ErrSyntax))
+			},
+		},
+		{scenario: "trailing data",
+			exec: func(t *testing.T) {
+				// ARRANGE
+				rq := &http.Request{
+					Body: io.NopCloser(bytes.NewReader([]byte(`{"ID":1} {"ID":2}`))),
+				}
+
+				// ACT
+				result := HandleRequest(rq, func(c *struct {
+					ID int
+				}) any {
+					return http.StatusOK
+				})
+
+				// ASSERT
+				test.Error(t, result.(error)).Is(restapi.BadRequest(ErrTrailingData).WithProperty("offset", int64(8)))
+			},
+		},
+		{scenario: "trailing whitespace",
+			exec: func(t *testing.T) {
+				// ARRANGE
+				rq := &http.Request{
+					Body: io.NopCloser(bytes.NewReader([]byte("{\"ID\":1}\n"))),
+				}
+
+				// ACT
+				result := HandleRequest(rq, func(c *struct {
+					ID int
+				}) any {
+					return http.StatusOK
+				})
+
+				// ASSERT
+				test.That(t, result).Equals(http.StatusOK)
+			},
+		},
+		{scenario: "body too large/content length",
+			exec: func(t *testing.T) {
+				// ARRANGE
+				rq := &http.Request{
+					Body:          io.NopCloser(bytes.NewReader([]byte(`{"ID":1}`))),
+					ContentLength: 8,
+				}
+
+				// ACT
+				result := HandleRequest(rq, func(c *struct {
+					ID int
+				}) any {
+					return http.StatusOK
+				}, Options{MaxBodySize: 4})
+
+				// ASSERT
+				test.Error(t, result.(error)).Is(restapi.NewError(http.StatusRequestEntityTooLarge, ErrBodyTooLarge).WithProperty("limit", int64(4)))
+			},
+		},
+		{scenario: "body too large/unknown length",
+			exec: func(t *testing.T) {
+				// ARRANGE
+				rq := &http.Request{
+					Body:          io.NopCloser(bytes.NewReader([]byte(`{"ID":1}`))),
+					ContentLength: -1,
+				}
+
+				// ACT
+				result := HandleRequest(rq, func(c *struct {
+					ID int
+				}) any {
+					return http.StatusOK
+				}, Options{MaxBodySize: 4})
+
+				// ASSERT
+				test.Error(t, result.(error)).Is(restapi.NewError(http.StatusRequestEntityTooLarge, ErrBodyTooLarge))
+			},
+		},
+		{scenario: "body too large/default limit",
+			exec: func(t *testing.T) {
+				// ARRANGE
+				defer test.Using(&DefaultOptions, Options{MaxBodySize: 4})()
+				rq := &http.Request{
+					Body: io.NopCloser(bytes.NewReader([]byte(`{"ID":1}`))),
+				}
+
+				// ACT
+				result := HandleRequest(rq, func(c *struct {
+					ID int
+				}) any {
+					return http.StatusOK
+				})
+
+				// ASSERT
+				test.Error(t, result.(error)).Is(restapi.NewError(http.StatusRequestEntityTooLarge, ErrBodyTooLarge))
+			},
+		},
+		{scenario: "body size/no default limit",
+			exec: func(t *testing.T) {
+				// ARRANGE
+				name := strings.Repeat("a", 2<<20)
+				rq := &http.Request{
+					Body:          io.NopCloser(strings.NewReader(`{"Name":"` + name + `"}`)),
+					ContentLength: int64(len(name) + 11),
+				}
+
+				// ACT
+				result := HandleRequest(rq, func(c *struct {
+					Name string
+				}) any {
+					return len(c.Name)
+				})
+
+				// ASSERT
+				test.That(t, result).Equals(any(2 << 20))
+			},
+		},
+		{scenario: "stream",
+			exec: func(t *testing.T) {
+				// ARRANGE
+				body := io.NopCloser(bytes.NewReader([]byte(`{"ID":1}`)))
+				rq := &http.Request{Body: body}
+				defer test.Using(&ioReadAll, func(io.Reader) ([]byte, error) {
+					t.Error("body was buffered")
+					return nil, nil
+				})()
+
+				// ACT
+				result := HandleRequest(rq, func(c *struct {
+					ID int
+				}) any {
+					test.That(t, c.ID).Equals(1)
+					return http.StatusOK
+				}, Options{Stream: true})
+
+				// ASSERT
+				test.That(t, result).Equals(http.StatusOK)
+				test.IsTrue(t, rq.Body == body)
+			},
+		},
+		{scenario: "stream/body too large",
+			exec: func(t *testing.T) {
+				// ARRANGE
+				rq := &http.Request{
+					Body:          io.NopCloser(bytes.NewReader([]byte(`{"Name":"a long name"}`))),
+					ContentLength: -1,
+				}
+
+				// ACT
+				result := HandleRequest(rq, func(c *struct {
+					Name string
+				}) any {
+					return http.StatusOK
+				}, Options{MaxBodySize: 8, Stream: true})
+
+				// ASSERT
+				test.Error(t, result.(error)).Is(restapi.NewError(http.StatusRequestEntityTooLarge, ErrBodyTooLarge))
+			},
+		},
+		{scenario: "stream/empty body",
+			exec: func(t *testing.T) {
+				// ARRANGE
+				rq := &http.Request{Body: io.NopCloser(bytes.NewReader([]byte{}))}
+
+				// ACT
+				result := StrictRequest(rq, func(c *struct {
+					Name string
+				}) any {
+					return http.StatusOK
+				}, Options{Stream: true})
+
+				// ASSERT
+				test.Error(t, result.(error)).Is(restapi.BadRequest(restapi.ErrBodyRequired))
 			},
 		},
 		{scenario: "strict request/no body",
@@ -137,6 +309,29 @@ func TestHandleRequest(t *testing.T) {
 				}
 			},
 		},
+		{scenario: "invalid body",
+			exec: func(t *testing.T) {
+				// ARRANGE
+				rq := &http.Request{
+					Body: io.NopCloser(bytes.NewReader([]byte(`{"ID":1}`))),
+				}
+
+				// ACT
+				result := StrictRequest(rq, func(_ *struct {
+					ID   int
+					Name string `validate:"required"`
+				}) any {
+					return http.StatusOK
+				})
+
+				// ASSERT
+				err, isErr := result.(error)
+				test.IsTrue(t, isErr)
+				if isErr {
+					test.Error(t, err).Is(restapi.UnprocessableEntity(restapi.ErrValidationFailed))
+				}
+			},
+		},
 	}
 	for _, tc := range testcases {
 		t.Run(tc.scenario, func(t *testing.T) {
@@ -0,0 +1,92 @@
+package jsonapi
+
+import (
+	"fmt"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/blugnu/restapi"
+)
+
+// MediaType is the JSON:API media type.
+const MediaType = "application/vnd.api+json"
+
+// unsupportedParameter returns the name of the first parameter of a JSON:API
+// media type that is not supported, or an empty string if all parameters are
+// supported.
+//
+// The profile parameter is supported (profiles may be safely ignored).  No
+// extensions are supported, so the ext parameter is not.
+func unsupportedParameter(params map[string]string) string {
+	for k := range params {
+		if k != "profile" {
+			return k
+		}
+	}
+	return ""
+}
+
+// acceptsMediaType returns the number of instances of the JSON:API media type
+// in an Accept header and the number of those instances that are acceptable
+// (i.e. have no unsupported media type parameters and a non-zero q-value).
+func acceptsMediaType(accept string) (instances, acceptable int) {
+	for _, s := range strings.Split(accept, ",") {
+		mt, params, err := mime.ParseMediaType(strings.TrimSpace(s))
+		if err != nil || mt != MediaType {
+			continue
+		}
+		instances++
+
+		// q is an accept parameter, not a parameter of the media type
+		q, qerr := strconv.ParseFloat(params["q"], 64)
+		delete(params, "q")
+		if unsupportedParameter(params) == "" && (qerr != nil || q > 0) {
+			acceptable++
+		}
+	}
+	return instances, acceptable
+}
+
+// CheckMediaType applies the JSON:API rules for media type parameters to a
+// request, returning an error if:
+//
+//   - the request Content-Type is the JSON:API media type with any parameter
+//     other than profile; a 415 Unsupported Media Type error is returned,
+//     wrapping ErrMediaTypeParameter;
+//
+//   - the request Accept header contains the JSON:API media type but every
+//     instance has a parameter other than profile; a 406 Not Acceptable error is
+//     returned, wrapping ErrMediaTypeParameter.
+//
+// No extensions are supported, so a media type with an ext parameter is not
+// supported.  A request with some other Content-Type, or with an Accept header
+// that does not contain the JSON:API media type, is not checked.
+//
+// CheckMediaType is applied by HandleResource; other endpoints serving JSON:API
+// documents (e.g. a GET) may call it directly:
+//
+//	func GetArticle(ctx context.Context, rq *http.Request) any {
+//	    if err := jsonapi.CheckMediaType(rq); err != nil {
+//	        return err
+//	    }
+//	    ...
+//	}
+func CheckMediaType(rq *http.Request) error {
+	if ct := rq.Header.Get("Content-Type"); ct != "" {
+		mt, params, err := mime.ParseMediaType(ct)
+		if err == nil && mt == MediaType {
+			if p := unsupportedParameter(params); p != "" {
+				return restapi.NewError(http.StatusUnsupportedMediaType,
+					fmt.Errorf("%w: %w: %s", restapi.ErrUnsupportedMediaType, ErrMediaTypeParameter, p))
+			}
+		}
+	}
+
+	if n, acceptable := acceptsMediaType(strings.Join(rq.Header.Values("Accept"), ",")); n > 0 && acceptable == 0 {
+		return restapi.NewError(http.StatusNotAcceptable, ErrMediaTypeParameter).
+			WithHelp("the JSON:API media type may only be specified with a profile parameter")
+	}
+	return nil
+}
@@ -0,0 +1,139 @@
+package jsonapi
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/blugnu/restapi"
+	"github.com/blugnu/test"
+)
+
+func TestCheckMediaType(t *testing.T) {
+	// ARRANGE
+	testcases := []struct {
+		scenario    string
+		contentType string
+		accept      []string
+		result      error
+	}{
+		{scenario: "no headers"},
+		{scenario: "json:api media type",
+			contentType: MediaType,
+			accept:      []string{MediaType},
+		},
+		{scenario: "profile parameter",
+			contentType: MediaType + `; profile="https://example.com/p"`,
+			accept:      []string{MediaType + `; profile="https://example.com/p"`},
+		},
+		{scenario: "other content type with parameters",
+			contentType: "application/json; charset=utf-8",
+		},
+		{scenario: "content type with charset",
+			contentType: MediaType + "; charset=utf-8",
+			result:      restapi.NewError(http.StatusUnsupportedMediaType, ErrMediaTypeParameter),
+		},
+		{scenario: "content type with extension",
+			contentType: MediaType + `; ext="https://example.com/ext"`,
+			result:      restapi.NewError(http.StatusUnsupportedMediaType, restapi.ErrUnsupportedMediaType),
+		},
+		{scenario: "accept with parameter",
+			accept: []string{MediaType + "; version=1"},
+			result: restapi.NewError(http.StatusNotAcceptable, ErrMediaTypeParameter),
+		},
+		{scenario: "accept with q-value",
+			accept: []string{MediaType + "; q=0.5"},
+		},
+		{scenario: "accept with q-value of zero",
+			accept: []string{MediaType + "; q=0"},
+			result: restapi.NewError(http.StatusNotAcceptable, ErrMediaTypeParameter),
+		},
+		{scenario: "accept with one instance without parameters",
+			accept: []string{MediaType + "; version=1", "application/json, " + MediaType},
+		},
+		{scenario: "accept without json:api media type",
+			accept: []string{"application/json; version=1"},
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.scenario, func(t *testing.T) {
+			// ARRANGE
+			rq := &http.Request{Header: http.Header{}}
+			if tc.contentType != "" {
+				rq.Header.Set("Content-Type", tc.contentType)
+			}
+			for _, a := range tc.accept {
+				rq.Header.Add("Accept", a)
+			}
+
+			// ACT
+			err := CheckMediaType(rq)
+
+			// ASSERT
+			if tc.result == nil {
+				test.Error(t, err).IsNil()
+				return
+			}
+			test.Error(t, err).Is(tc.result)
+		})
+	}
+}
+
+func TestMediaTypeNegotiation(t *testing.T) {
+	// ARRANGE
+	type article struct {
+		ID    string `json:"-" jsonapi:"primary,articles"`
+		Title string `json:"title"`
+	}
+	sut := &restapi.Server{ProjectError: ProjectError}
+	h := sut.HandlerFunc(func(_ context.Context, rq *http.Request) any {
+		if err := CheckMediaType(rq); err != nil {
+			return err
+		}
+		return NewResource(article{ID: "1", Title: "JSON:API"})
+	})
+
+	testcases := []struct {
+		scenario    string
+		accept      string
+		statusCode  int
+		contentType string
+		result      string
+	}{
+		{scenario: "profile parameter",
+			accept:      MediaType + `; profile="https://example.com/p"`,
+			statusCode:  http.StatusOK,
+			contentType: MediaType,
+			result:      `{"data":{"type":"articles","id":"1","attributes":{"title":"JSON:API"}}}`,
+		},
+		{scenario: "extension parameter",
+			accept:      MediaType + `; ext="https://example.com/e"`,
+			statusCode:  http.StatusNotAcceptable,
+			contentType: "application/json",
+		},
+		{scenario: "extension parameter/other acceptable content type",
+			accept:      MediaType + `; ext="https://example.com/e", application/json;q=0.5`,
+			statusCode:  http.StatusNotAcceptable,
+			contentType: "application/json",
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.scenario, func(t *testing.T) {
+			// ARRANGE
+			rec := httptest.NewRecorder()
+			rq := httptest.NewRequest(http.MethodGet, "/articles/1", nil)
+			rq.Header.Set("Accept", tc.accept)
+
+			// ACT
+			h(rec, rq)
+
+			// ASSERT
+			test.That(t, rec.Code).Equals(tc.statusCode)
+			test.That(t, rec.Header().Get("Content-Type")).Equals(tc.contentType)
+			if tc.result != "" {
+				test.That(t, rec.Body.String()).Equals(tc.result)
+			}
+		})
+	}
+}
@@ -0,0 +1,126 @@
+package jsonapi
+
+import (
+	"encoding/json"
+	"maps"
+	"mime"
+	"net/http"
+	"strconv"
+
+	"github.com/blugnu/restapi"
+)
+
+// ErrorDocument is a JSON:API document containing an array of error objects.
+type ErrorDocument struct {
+	Errors []ErrorObject `json:"errors"`
+}
+
+// MarshalJSONAPI implements restapi.JSONAPIMarshaler, marshalling the document
+// as application/vnd.api+json.
+func (doc ErrorDocument) MarshalJSONAPI() ([]byte, error) {
+	return json.Marshal(doc)
+}
+
+// ErrorObject is a JSON:API error object.
+type ErrorObject struct {
+	Status string         `json:"status"`
+	Title  string         `json:"title"`
+	Detail string         `json:"detail,omitempty"`
+	Source *ErrorSource   `json:"source,omitempty"`
+	Meta   map[string]any `json:"meta,omitempty"`
+}
+
+// ErrorSource identifies the source of a JSON:API error: a JSON pointer to a
+// value in the request document, or a query parameter or header of the request.
+type ErrorSource struct {
+	Pointer   string `json:"pointer,omitempty"`
+	Parameter string `json:"parameter,omitempty"`
+	Header    string `json:"header,omitempty"`
+}
+
+// ProjectError projects a restapi.Error as a JSON:API ErrorDocument if the
+// JSON:API media type is negotiated for the error response.  Otherwise the
+// error is projected using restapi.ProjectError.
+//
+// The document contains an error object for each problem described by the
+// Error:
+//
+//   - a "parameters" property ([]restapi.ParameterError, e.g. from
+//     restapi.ParseQuery) provides an error object for each parameter, with the
+//     parameter (or header) as the source;
+//
+//   - an "errors" property (restapi.FieldErrors, from restapi.Validate)
+//     provides an error object for each field, with a pointer to the attribute
+//     as the source;
+//
+//   - otherwise the document contains a single error object, with the message
+//     of the Error as the detail and any "pointer" property as the source.
+//
+// Any other properties (and help) of the Error are presented as meta.
+//
+// To present errors as JSON:API error documents, configure a restapi.Server
+// with this function:
+//
+//	api := &restapi.Server{ProjectError: jsonapi.ProjectError}
+func ProjectError(err restapi.ErrorInfo) any {
+	if mt, _, _ := mime.ParseMediaType(err.ContentType); mt != MediaType {
+		return restapi.ProjectError(err)
+	}
+
+	status := strconv.Itoa(err.StatusCode)
+	title := http.StatusText(err.StatusCode)
+
+	props := map[string]any{}
+	maps.Copy(props, err.Properties)
+	if err.Help != "" {
+		props["help"] = err.Help
+	}
+	meta := func() map[string]any {
+		if len(props) == 0 {
+			return nil
+		}
+		return props
+	}
+
+	if params, ok := props["parameters"].([]restapi.ParameterError); ok {
+		delete(props, "parameters")
+		doc := ErrorDocument{Errors: make([]ErrorObject, len(params))}
+		for i, p := range params {
+			src := &ErrorSource{Parameter: p.Name}
+			if p.In == "header" {
+				src = &ErrorSource{Header: p.Name}
+			}
+			doc.Errors[i] = ErrorObject{Status: status, Title: title, Detail: p.Reason, Source: src, Meta: meta()}
+		}
+		return doc
+	}
+
+	if fields, ok := props["errors"].(restapi.FieldErrors); ok {
+		delete(props, "errors")
+		doc := ErrorDocument{Errors: make([]ErrorObject, len(fields))}
+		for i, f := range fields {
+			src := &ErrorSource{Pointer: "/data"}
+			if f.Pointer != "" {
+				src.Pointer = "/data/attributes" + f.Pointer
+			}
+			doc.Errors[i] = ErrorObject{Status: status, Title: title, Detail: f.Reason, Source: src, Meta: meta()}
+		}
+		return doc
+	}
+
+	obj := ErrorObject{Status: status, Title: title, Detail: err.Message}
+	switch {
+	case obj.Detail == "" && err.Err != nil:
+		obj.Detail = err.Err.Error()
+
+	case obj.Detail != "" && err.Err != nil:
+		obj.Detail = err.Err.Error() + ": " + obj.Detail
+	}
+	if pointer, ok := props["pointer"].(string); ok {
+		delete(props, "pointer")
+		obj.Source = &ErrorSource{Pointer: pointer}
+	}
+	obj.Meta = meta()
+
+	return ErrorDocument{Errors: []ErrorObject{obj}}
+}
@@ -0,0 +1,90 @@
+package jsonapi
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/blugnu/restapi"
+	"github.com/blugnu/test"
+)
+
+func TestProjectError(t *testing.T) {
+	// ARRANGE
+	sut := &restapi.Server{ProjectError: ProjectError}
+	serve := func(accept string, err error) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		rq := httptest.NewRequest(http.MethodGet, "/articles", nil)
+		rq.Header.Set("Accept", accept)
+		sut.HandlerFunc(func(context.Context, *http.Request) any { return err })(rec, rq)
+		return rec
+	}
+
+	testcases := []struct {
+		scenario    string
+		accept      string
+		contentType string
+		err         error
+		result      string
+	}{
+		{scenario: "error",
+			accept: MediaType,
+			err:    restapi.NotFound(errors.New("article not found")).WithHelp("check the id"),
+			result: `{"errors":[{"status":"404","title":"Not Found","detail":"article not found","meta":{"help":"check the id"}}]}`,
+		},
+		{scenario: "error with message and pointer",
+			accept: MediaType,
+			err: restapi.NewError(http.StatusConflict, ErrResourceTypeConflict).
+				WithMessage("people").
+				WithProperty("pointer", "/data/type"),
+			result: `{"errors":[{"status":"409","title":"Conflict","detail":"resource type conflict: people","source":{"pointer":"/data/type"}}]}`,
+		},
+		{scenario: "parameters",
+			accept: MediaType,
+			err: restapi.BadRequest(restapi.ErrInvalidParameter).
+				WithProperty("parameters", []restapi.ParameterError{
+					{In: "query", Name: "sort", Reason: "unknown field: x"},
+					{In: "header", Name: "X-Version", Reason: "must be an integer"},
+				}),
+			result: `{"errors":[` +
+				`{"status":"400","title":"Bad Request","detail":"unknown field: x","source":{"parameter":"sort"}},` +
+				`{"status":"400","title":"Bad Request","detail":"must be an integer","source":{"header":"X-Version"}}` +
+				`]}`,
+		},
+		{scenario: "validation errors",
+			accept: MediaType,
+			err: restapi.UnprocessableEntity(restapi.ErrValidationFailed).
+				WithProperty("errors", restapi.FieldErrors{
+					{Pointer: "/title", Reason: "is required"},
+					{Pointer: "", Reason: "invalid article"},
+				}),
+			result: `{"errors":[` +
+				`{"status":"422","title":"Unprocessable Entity","detail":"is required","source":{"pointer":"/data/attributes/title"}},` +
+				`{"status":"422","title":"Unprocessable Entity","detail":"invalid article","source":{"pointer":"/data"}}` +
+				`]}`,
+		},
+		{scenario: "not a json:api request",
+			accept: "application/json",
+			err:    restapi.NotFound(),
+			result: `{"status":404,"error":"Not Found","path":"/articles","timestamp":`,
+		},
+		{scenario: "json:api media type not negotiated",
+			accept:      "application/json, " + MediaType + ";q=0.5",
+			contentType: "application/json",
+			err:         restapi.NotFound(),
+			result:      `{"status":404,"error":"Not Found","path":"/articles","timestamp":`,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.scenario, func(t *testing.T) {
+			// ACT
+			rec := serve(tc.accept, tc.err)
+
+			// ASSERT
+			test.That(t, rec.Header().Get("Content-Type")).Equals(coalesce(tc.contentType, tc.accept))
+			test.String(t, rec.Body.String()).Contains(tc.result)
+		})
+	}
+}
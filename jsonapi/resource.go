@@ -0,0 +1,515 @@
+package jsonapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/blugnu/restapi"
+)
+
+// document is a JSON:API request document.  Members other than the primary
+// data are accepted but ignored.
+type document struct {
+	Data     *resourceObject `json:"data"`
+	Included json.RawMessage `json:"included"`
+	Meta     json.RawMessage `json:"meta"`
+	Links    json.RawMessage `json:"links"`
+	JSONAPI  json.RawMessage `json:"jsonapi"`
+}
+
+// resourceObject is the primary data of a JSON:API request document.
+type resourceObject struct {
+	Type          string                  `json:"type"`
+	ID            *string                 `json:"id"`
+	LID           string                  `json:"lid"`
+	Attributes    json.RawMessage         `json:"attributes"`
+	Relationships map[string]relationship `json:"relationships"`
+	Links         json.RawMessage         `json:"links"`
+	Meta          json.RawMessage         `json:"meta"`
+}
+
+// relationship is a relationship of a resource object.  The data (resource
+// linkage) is null, a resource identifier or an array of resource identifiers.
+type relationship struct {
+	Data  json.RawMessage `json:"data"`
+	Links json.RawMessage `json:"links"`
+	Meta  json.RawMessage `json:"meta"`
+}
+
+// identifier is a JSON:API resource identifier.
+type identifier struct {
+	Type string `json:"type"`
+	ID   string `json:"id"`
+}
+
+// resourceField is a field of a struct identified by a `jsonapi` tag.
+type resourceField struct {
+	index    int
+	name     string // the relationship name (relation fields only)
+	typ      string // the resource type (or related resource type)
+	jsonName string // the name of the field in json
+}
+
+// resourceType describes a struct type with `jsonapi` tags.
+type resourceType struct {
+	primary   resourceField
+	relations []resourceField
+}
+
+// resourceTypeOf returns the resourceType of a struct type (or pointer to a
+// struct type).
+//
+// # panics
+//
+// resourceTypeOf will panic with restapi.ErrInvalidArgument if the type is not
+// a struct with a `jsonapi:"primary,<type>"` field, has an invalid `jsonapi`
+// tag or has a `jsonapi` tag on an unexported field (which cannot be set when
+// decoding a document).
+func resourceTypeOf(t reflect.Type) resourceType {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		panic(fmt.Errorf("%w: %s is not a struct", restapi.ErrInvalidArgument, t))
+	}
+
+	rt := resourceType{primary: resourceField{index: -1}}
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		tag, ok := sf.Tag.Lookup("jsonapi")
+		if !ok {
+			continue
+		}
+		if !sf.IsExported() {
+			panic(fmt.Errorf("%w: %s.%s: jsonapi tag on unexported field", restapi.ErrInvalidArgument, t, sf.Name))
+		}
+		jsonName, _, _ := strings.Cut(sf.Tag.Get("json"), ",")
+		rf := resourceField{index: i, jsonName: coalesce(jsonName, sf.Name)}
+
+		args := strings.Split(tag, ",")
+		switch {
+		case args[0] == "primary" && len(args) == 2 && args[1] != "" && isID(sf.Type):
+			rf.typ = args[1]
+			rt.primary = rf
+
+		case args[0] == "relation" && len(args) == 3 && args[1] != "" && args[2] != "" && isRelation(sf.Type):
+			rf.name, rf.typ = args[1], args[2]
+			rt.relations = append(rt.relations, rf)
+
+		default:
+			panic(fmt.Errorf("%w: %s.%s: invalid jsonapi tag: %q", restapi.ErrInvalidArgument, t, sf.Name, tag))
+		}
+	}
+	if rt.primary.index == -1 {
+		panic(fmt.Errorf("%w: %s has no jsonapi primary field", restapi.ErrInvalidArgument, t))
+	}
+	return rt
+}
+
+// coalesce returns the first non-empty string.
+func coalesce(s ...string) string {
+	for _, s := range s {
+		if s != "" {
+			return s
+		}
+	}
+	return ""
+}
+
+// isID returns true if a type may hold a resource id (a string or integer).
+func isID(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.String,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	}
+	return false
+}
+
+// isRelation returns true if a type may hold a relationship: an id (to-one),
+// a pointer to an id (a to-one relationship that may be null) or a slice of
+// ids (to-many).
+func isRelation(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Pointer, reflect.Slice:
+		return isID(t.Elem())
+	}
+	return isID(t)
+}
+
+// formatID returns the string representation of an id field.
+func formatID(v reflect.Value) string {
+	switch v.Kind() {
+	case reflect.String:
+		return v.String()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10)
+	default:
+		return strconv.FormatInt(v.Int(), 10)
+	}
+}
+
+// parseID sets an id field from its string representation.
+func parseID(v reflect.Value, s string) error {
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+	default:
+		n, err := strconv.ParseInt(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	}
+	return nil
+}
+
+// invalidDocument returns a 400 Bad Request Error for an invalid document,
+// with a pointer locating the problem in the document.
+func invalidDocument(pointer string, reason string) *restapi.Error {
+	return restapi.BadRequest(fmt.Errorf("%w: %s", ErrInvalidDocument, reason)).
+		WithProperty("pointer", pointer)
+}
+
+// typeConflict returns a 409 Conflict Error for a resource (or related
+// resource) of a type not supported by the endpoint.
+func typeConflict(pointer string, got, want string) *restapi.Error {
+	return restapi.NewError(http.StatusConflict,
+		fmt.Errorf("%w: got %q, expected %q", ErrResourceTypeConflict, got, want)).
+		WithProperty("pointer", pointer)
+}
+
+// attributesError returns a 400 Bad Request Error for an error decoding the
+// attributes of a resource object, adding a pointer to any decodeError
+// properties locating the problem in the document.
+func attributesError(err error) *restapi.Error {
+	apierr := decodeError(err)
+
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &typeErr):
+		return apierr.WithProperty("pointer", "/data/attributes/"+strings.ReplaceAll(typeErr.Field, ".", "/"))
+
+	case strings.HasPrefix(err.Error(), unknownFieldPrefix):
+		if name, err := strconv.Unquote(strings.TrimPrefix(err.Error(), unknownFieldPrefix)); err == nil {
+			return apierr.WithProperty("pointer", "/data/attributes/"+name)
+		}
+	}
+	return apierr.WithProperty("pointer", "/data/attributes")
+}
+
+// decodeAttributes decodes the attributes of a resource object into a value.
+// Attributes are the json fields of the value; an attribute with a reserved
+// name or the name of a field with a `jsonapi` tag is not allowed.
+func (rt resourceType) decodeAttributes(attributes json.RawMessage, v any) *restapi.Error {
+	if len(attributes) == 0 || string(attributes) == "null" {
+		return nil
+	}
+
+	var members map[string]json.RawMessage
+	if err := json.Unmarshal(attributes, &members); err != nil || members == nil {
+		return invalidDocument("/data/attributes", "attributes must be an object")
+	}
+	reserved := []string{"id", "type", "relationships", "links", rt.primary.jsonName}
+	for _, rf := range rt.relations {
+		reserved = append(reserved, rf.name, rf.jsonName)
+	}
+	for _, name := range reserved {
+		if _, ok := members[name]; ok {
+			return restapi.BadRequest(fmt.Errorf("%w: %s", restapi.ErrUnexpectedField, name)).
+				WithProperty("field", name).
+				WithProperty("pointer", "/data/attributes/"+name)
+		}
+	}
+
+	dc := json.NewDecoder(bytes.NewReader(attributes))
+	dc.DisallowUnknownFields()
+	if err := dc.Decode(v); err != nil {
+		return attributesError(err)
+	}
+	return nil
+}
+
+// decodeRelationship sets a relation field from the resource linkage of a
+// relationship.
+func (rf resourceField) decodeRelationship(v reflect.Value, data json.RawMessage) *restapi.Error {
+	pointer := "/data/relationships/" + rf.name + "/data"
+
+	set := func(v reflect.Value, id identifier, pointer string) *restapi.Error {
+		if id.Type != rf.typ {
+			return typeConflict(pointer+"/type", id.Type, rf.typ)
+		}
+		if err := parseID(v, id.ID); err != nil {
+			return restapi.BadRequest(fmt.Errorf("%w: %w", ErrTypeMismatch, err)).
+				WithProperty("pointer", pointer+"/id")
+		}
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.Slice:
+		ids := []identifier{}
+		if err := json.Unmarshal(data, &ids); err != nil || string(data) == "null" {
+			return invalidDocument(pointer, "resource linkage must be an array of resource identifiers")
+		}
+		s := reflect.MakeSlice(v.Type(), len(ids), len(ids))
+		for i, id := range ids {
+			if err := set(s.Index(i), id, pointer+"/"+strconv.Itoa(i)); err != nil {
+				return err
+			}
+		}
+		v.Set(s)
+		return nil
+
+	case reflect.Pointer:
+		if string(data) == "null" {
+			v.SetZero()
+			return nil
+		}
+		id := identifier{}
+		if err := json.Unmarshal(data, &id); err != nil || len(data) == 0 {
+			return invalidDocument(pointer, "resource linkage must be null or a resource identifier")
+		}
+		p := reflect.New(v.Type().Elem())
+		if err := set(p.Elem(), id, pointer); err != nil {
+			return err
+		}
+		v.Set(p)
+		return nil
+
+	default:
+		id := identifier{}
+		if err := json.Unmarshal(data, &id); err != nil || len(data) == 0 || string(data) == "null" {
+			return invalidDocument(pointer, "resource linkage must be a resource identifier")
+		}
+		return set(v, id, pointer)
+	}
+}
+
+// decode decodes the primary data of a document into a value.
+func (rt resourceType) decode(data *resourceObject, c any) *restapi.Error {
+	switch {
+	case data == nil:
+		return invalidDocument("/data", "primary data is required")
+	case data.Type == "":
+		return invalidDocument("/data/type", "type is required")
+	case data.Type != rt.primary.typ:
+		return typeConflict("/data/type", data.Type, rt.primary.typ)
+	}
+
+	if err := rt.decodeAttributes(data.Attributes, c); err != nil {
+		return err
+	}
+
+	v := reflect.ValueOf(c).Elem()
+	if data.ID != nil {
+		if err := parseID(v.Field(rt.primary.index), *data.ID); err != nil {
+			return restapi.BadRequest(fmt.Errorf("%w: %w", ErrTypeMismatch, err)).
+				WithProperty("pointer", "/data/id")
+		}
+	}
+
+	for name, rel := range data.Relationships {
+		i := -1
+		for j, rf := range rt.relations {
+			if rf.name == name {
+				i = j
+				break
+			}
+		}
+		if i == -1 {
+			return restapi.BadRequest(fmt.Errorf("%w: %s", restapi.ErrUnexpectedField, name)).
+				WithProperty("field", name).
+				WithProperty("pointer", "/data/relationships/"+name)
+		}
+		if rel.Data == nil {
+			// a relationship with no data (e.g. only links) leaves the field unchanged
+			continue
+		}
+		rf := rt.relations[i]
+		if err := rf.decodeRelationship(v.Field(rf.index), rel.Data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// HandleResource reads a JSON:API document from the request body and decodes
+// the primary data (a resource object) into a value of type T which is then
+// passed to the supplied function to handle the request.
+//
+// T must be a struct identifying the resource type and id using `jsonapi` tags.
+// A field tagged `jsonapi:"primary,<type>"` holds the resource id and
+// identifies the type of the resource; fields tagged
+// `jsonapi:"relation,<name>,<type>"` hold the ids of related resources:
+//
+//	string, int, etc    // a to-one relationship
+//	*string, *int, etc  // a to-one relationship that may be null
+//	[]string, []int etc // a to-many relationship
+//
+// The attributes of the resource are decoded into the json fields of T; an
+// id field and relation fields should usually be excluded from json (using a
+// `json:"-"` tag) or have the same name as the relationship.
+//
+// The request must have a Content-Type of application/vnd.api+json and is
+// checked using CheckMediaType.  An Error is returned if:
+//
+//   - the request has some other Content-Type; a 415 Unsupported Media Type
+//     error is returned, wrapping restapi.ErrUnsupportedMediaType;
+//
+//   - the request body is empty; restapi.BadRequest(restapi.ErrBodyRequired) is
+//     returned;
+//
+//   - the body is not valid json or cannot be decoded; a 400 Bad Request error
+//     is returned (see: HandleRequest);
+//
+//   - the body is not a JSON:API document with primary data, or has an
+//     attribute or relationship not supported by T; a 400 Bad Request error is
+//     returned, wrapping ErrInvalidDocument or restapi.ErrUnexpectedField;
+//
+//   - the resource (or a related resource) is not of the type identified by
+//     the `jsonapi` tags of T; a 409 Conflict error is returned, wrapping
+//     ErrResourceTypeConflict;
+//
+//   - the decoded value is not valid (see: restapi.Validate); the error
+//     returned by restapi.Validate is returned (422 Unprocessable Entity).
+//
+// Errors locating a problem in the document have a "pointer" property (a JSON
+// pointer, presented as the error source by ProjectError).
+//
+// The maximum body size is specified by an optional Options argument; if not
+// specified, DefaultOptions are applied.
+//
+// # example
+//
+//	type article struct {
+//	    ID     string `json:"-" jsonapi:"primary,articles"`
+//	    Title  string `json:"title" validate:"required"`
+//	    Author string `json:"-" jsonapi:"relation,author,people"`
+//	}
+//
+//	func PostArticle(ctx context.Context, rq *http.Request) any {
+//	    return jsonapi.HandleResource(rq, func(a *article) any {
+//	        a.ID = uuid.New().String()
+//
+//	        // ... create the new article ...
+//
+//	        return restapi.Created().WithValue(jsonapi.NewResource(a))
+//	    })
+//	}
+//
+// # panics
+//
+// HandleResource will panic with restapi.ErrInvalidArgument if T is not a
+// struct with a valid `jsonapi:"primary,<type>"` field or has an invalid
+// `jsonapi` tag.
+func HandleResource[T any](rq *http.Request, h func(c *T) any, opts ...Options) any {
+	rt := resourceTypeOf(reflect.TypeFor[T]())
+
+	if mt, _, _ := mime.ParseMediaType(rq.Header.Get("Content-Type")); mt != MediaType {
+		return restapi.NewError(http.StatusUnsupportedMediaType,
+			fmt.Errorf("%w: %s", restapi.ErrUnsupportedMediaType, coalesce(mt, "no content type"))).
+			WithProperty("supported", []string{MediaType})
+	}
+	if err := CheckMediaType(rq); err != nil {
+		return err
+	}
+
+	return handle(rq, true, func(doc *document) any {
+		c := new(T)
+		if err := rt.decode(doc.Data, c); err != nil {
+			return err
+		}
+		if err := restapi.Validate(c); err != nil {
+			return err
+		}
+		return h(c)
+	}, opts)
+}
+
+// NewResource returns a restapi.Resource for a value of a struct type (or
+// pointer to a struct) with `jsonapi` tags (see: HandleResource).  The id and
+// relationships of the resource are obtained from the tagged fields; the value
+// provides its attributes.
+//
+// When a JSON:API document is negotiated (see: restapi.MarshalJSONAPI), the
+// relationships are identified in the resource object; related resources are
+// not included.  Links and related resources to be included may be added
+// using the methods of the restapi.Resource.
+//
+// # panics
+//
+// NewResource will panic with restapi.ErrInvalidArgument if the value is not a
+// struct with a valid `jsonapi:"primary,<type>"` field or has an invalid
+// `jsonapi` tag.
+func NewResource(v any) *restapi.Resource {
+	rv := reflect.ValueOf(v)
+	if !rv.IsValid() {
+		panic(fmt.Errorf("%w: resource is nil", restapi.ErrInvalidArgument))
+	}
+	rt := resourceTypeOf(rv.Type())
+	for rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			panic(fmt.Errorf("%w: resource is nil", restapi.ErrInvalidArgument))
+		}
+		rv = rv.Elem()
+	}
+
+	r := restapi.NewResource(rt.primary.typ, formatID(rv.Field(rt.primary.index)), v)
+	for _, rf := range rt.relations {
+		fv := rv.Field(rf.index)
+		switch fv.Kind() {
+		case reflect.Slice:
+			ids := make([]string, fv.Len())
+			for i := range ids {
+				ids[i] = formatID(fv.Index(i))
+			}
+			r = r.WithRelationships(rf.name, rf.typ, ids...)
+
+		case reflect.Pointer:
+			id := ""
+			if !fv.IsNil() {
+				id = formatID(fv.Elem())
+			}
+			r = r.WithRelationship(rf.name, rf.typ, id)
+
+		default:
+			r = r.WithRelationship(rf.name, rf.typ, formatID(fv))
+		}
+	}
+	return r
+}
+
+// NewCollection returns a restapi.Collection of resources for a slice of
+// values of a struct type (or pointer to a struct) with `jsonapi` tags (see:
+// NewResource).  In a HAL document, the items are embedded using the
+// resource type as the relation name.
+//
+// # panics
+//
+// NewCollection will panic with restapi.ErrInvalidArgument if T is not a
+// struct with a valid `jsonapi:"primary,<type>"` field or has an invalid
+// `jsonapi` tag.
+func NewCollection[T any](items []T) *restapi.Collection {
+	rt := resourceTypeOf(reflect.TypeFor[T]())
+
+	resources := make([]*restapi.Resource, len(items))
+	for i, item := range items {
+		resources[i] = NewResource(item)
+	}
+	return restapi.NewCollection(rt.primary.typ, resources...)
+}
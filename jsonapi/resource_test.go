@@ -0,0 +1,322 @@
+package jsonapi
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/blugnu/restapi"
+	"github.com/blugnu/test"
+)
+
+type article struct {
+	ID       string   `json:"-" jsonapi:"primary,articles"`
+	Title    string   `json:"title" validate:"required"`
+	Author   string   `json:"-" jsonapi:"relation,author,people"`
+	Editor   *int     `json:"-" jsonapi:"relation,editor,people"`
+	Tags     []string `json:"-" jsonapi:"relation,tags,tags"`
+	Comments int      `json:"comments,omitempty"`
+}
+
+func TestHandleResource(t *testing.T) {
+	// ARRANGE
+	request := func(body string) *http.Request {
+		return &http.Request{
+			Header: http.Header{"Content-Type": []string{MediaType}},
+			Body:   io.NopCloser(bytes.NewReader([]byte(body))),
+		}
+	}
+	handle := func(rq *http.Request) (*article, any) {
+		var result *article
+		r := HandleResource(rq, func(a *article) any {
+			result = a
+			return http.StatusCreated
+		})
+		return result, r
+	}
+
+	testcases := []struct {
+		scenario string
+		exec     func(t *testing.T)
+	}{
+		{scenario: "successful",
+			exec: func(t *testing.T) {
+				// ARRANGE
+				rq := request(`{"data":{` +
+					`"type":"articles","id":"1",` +
+					`"attributes":{"title":"JSON:API"},` +
+					`"relationships":{` +
+					`"author":{"data":{"type":"people","id":"9"}},` +
+					`"editor":{"data":{"type":"people","id":"7"}},` +
+					`"tags":{"data":[{"type":"tags","id":"a"},{"type":"tags","id":"b"}]}` +
+					`}},` +
+					`"meta":{"x":1}}`)
+
+				// ACT
+				a, result := handle(rq)
+
+				// ASSERT
+				test.That(t, result).Equals(http.StatusCreated)
+				editor := 7
+				test.That(t, *a).Equals(article{ID: "1", Title: "JSON:API", Author: "9", Editor: &editor, Tags: []string{"a", "b"}})
+			},
+		},
+		{scenario: "no id/null relationship",
+			exec: func(t *testing.T) {
+				// ARRANGE
+				rq := request(`{"data":{"type":"articles","attributes":{"title":"x"},"relationships":{"editor":{"data":null}}}}`)
+
+				// ACT
+				a, result := handle(rq)
+
+				// ASSERT
+				test.That(t, result).Equals(http.StatusCreated)
+				test.That(t, *a).Equals(article{Title: "x"})
+			},
+		},
+		{scenario: "unsupported content type",
+			exec: func(t *testing.T) {
+				// ARRANGE
+				rq := request(`{}`)
+				rq.Header.Set("Content-Type", "application/json")
+
+				// ACT
+				_, result := handle(rq)
+
+				// ASSERT
+				test.Error(t, result.(error)).Is(restapi.NewError(http.StatusUnsupportedMediaType, restapi.ErrUnsupportedMediaType))
+			},
+		},
+		{scenario: "unsupported media type parameter",
+			exec: func(t *testing.T) {
+				// ARRANGE
+				rq := request(`{}`)
+				rq.Header.Set("Content-Type", MediaType+"; charset=utf-8")
+
+				// ACT
+				_, result := handle(rq)
+
+				// ASSERT
+				test.Error(t, result.(error)).Is(ErrMediaTypeParameter)
+			},
+		},
+		{scenario: "empty body",
+			exec: func(t *testing.T) {
+				// ACT
+				_, result := handle(request(``))
+
+				// ASSERT
+				test.Error(t, result.(error)).Is(restapi.BadRequest(restapi.ErrBodyRequired))
+			},
+		},
+		{scenario: "invalid documents",
+			exec: func(t *testing.T) {
+				// ARRANGE
+				testcases := []struct {
+					body   string
+					result *restapi.Error
+				}{
+					{body: `{"data":null}`,
+						result: restapi.BadRequest(ErrInvalidDocument).WithProperty("pointer", "/data")},
+					{body: `{"data":{"id":"1"}}`,
+						result: restapi.BadRequest(ErrInvalidDocument).WithProperty("pointer", "/data/type")},
+					{body: `{"data":{"type":"articles","attributes":[]}}`,
+						result: restapi.BadRequest(ErrInvalidDocument).WithProperty("pointer", "/data/attributes")},
+					{body: `{"data":{"type":"articles","relationships":{"tags":{"data":{"type":"tags","id":"a"}}}}}`,
+						result: restapi.BadRequest(ErrInvalidDocument).WithProperty("pointer", "/data/relationships/tags/data")},
+					{body: `{"data":{"type":"articles","relationships":{"author":{"data":null}}}}`,
+						result: restapi.BadRequest(ErrInvalidDocument).WithProperty("pointer", "/data/relationships/author/data")},
+					{body: `{"data":{"type":"articles","relationships":{"editor":{"data":[]}}}}`,
+						result: restapi.BadRequest(ErrInvalidDocument).WithProperty("pointer", "/data/relationships/editor/data")},
+					{body: `{"errors":[]}`,
+						result: restapi.BadRequest(restapi.ErrUnexpectedField)},
+					{body: `{"data":{"type":"articles","attributes":{"id":"1"}}}`,
+						result: restapi.BadRequest(restapi.ErrUnexpectedField).WithProperty("field", "id").WithProperty("pointer", "/data/attributes/id")},
+					{body: `{"data":{"type":"articles","attributes":{"author":"9"}}}`,
+						result: restapi.BadRequest(restapi.ErrUnexpectedField).WithProperty("field", "author").WithProperty("pointer", "/data/attributes/author")},
+					{body: `{"data":{"type":"articles","attributes":{"subtitle":"x"}}}`,
+						result: restapi.BadRequest(restapi.ErrUnexpectedField).WithProperty("field", "subtitle").WithProperty("pointer", "/data/attributes/subtitle")},
+					{body: `{"data":{"type":"articles","relationships":{"owner":{"data":null}}}}`,
+						result: restapi.BadRequest(restapi.ErrUnexpectedField).WithProperty("field", "owner").WithProperty("pointer", "/data/relationships/owner")},
+					{body: `{"data":{"type":"articles","attributes":{"comments":"many"}}}`,
+						result: restapi.BadRequest(ErrTypeMismatch).
+							WithProperty("offset", int64(18)).
+							WithProperty("field", "comments").
+							WithProperty("type", "int").
+							WithProperty("value", "string").
+							WithProperty("pointer", "/data/attributes/comments")},
+					{body: `{"data":{"type":"articles","relationships":{"editor":{"data":{"type":"people","id":"x"}}}}}`,
+						result: restapi.BadRequest(ErrTypeMismatch).WithProperty("pointer", "/data/relationships/editor/data/id")},
+					{body: `{"data":{"type":"articles","id":1}}`,
+						result: restapi.BadRequest(ErrTypeMismatch)},
+				}
+				for _, tc := range testcases {
+					t.Run(tc.body, func(t *testing.T) {
+						// ACT
+						_, result := handle(request(tc.body))
+
+						// ASSERT
+						test.Error(t, result.(error)).Is(tc.result)
+					})
+				}
+			},
+		},
+		{scenario: "resource type conflict",
+			exec: func(t *testing.T) {
+				// ARRANGE
+				testcases := []struct {
+					body    string
+					pointer string
+				}{
+					{body: `{"data":{"type":"people"}}`, pointer: "/data/type"},
+					{body: `{"data":{"type":"articles","relationships":{"author":{"data":{"type":"tags","id":"9"}}}}}`,
+						pointer: "/data/relationships/author/data/type"},
+					{body: `{"data":{"type":"articles","relationships":{"tags":{"data":[{"type":"tags","id":"a"},{"type":"people","id":"9"}]}}}}`,
+						pointer: "/data/relationships/tags/data/1/type"},
+				}
+				for _, tc := range testcases {
+					t.Run(tc.pointer, func(t *testing.T) {
+						// ACT
+						_, result := handle(request(tc.body))
+
+						// ASSERT
+						test.Error(t, result.(error)).Is(restapi.NewError(http.StatusConflict, ErrResourceTypeConflict).
+							WithProperty("pointer", tc.pointer))
+					})
+				}
+			},
+		},
+		{scenario: "validation failed",
+			exec: func(t *testing.T) {
+				// ACT
+				_, result := handle(request(`{"data":{"type":"articles"}}`))
+
+				// ASSERT
+				test.Error(t, result.(error)).Is(restapi.UnprocessableEntity(restapi.ErrValidationFailed))
+			},
+		},
+		{scenario: "invalid resource type",
+			exec: func(t *testing.T) {
+				// ARRANGE
+				defer test.ExpectPanic(restapi.ErrInvalidArgument).Assert(t)
+
+				// ACT
+				HandleResource(request(`{}`), func(*struct{ ID string }) any { return nil })
+			},
+		},
+		{scenario: "unexported primary field",
+			exec: func(t *testing.T) {
+				// ARRANGE
+				type article struct {
+					id    string `jsonapi:"primary,articles"`
+					Title string `json:"title"`
+				}
+				defer test.ExpectPanic(restapi.ErrInvalidArgument).Assert(t)
+
+				// ACT
+				HandleResource(request(`{"data":{"type":"articles","id":"1","attributes":{"title":"t"}}}`), func(a *article) any { return a.id })
+			},
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.scenario, func(t *testing.T) {
+			tc.exec(t)
+		})
+	}
+}
+
+func TestResourceTypeOf(t *testing.T) {
+	// ARRANGE
+	testcases := []struct {
+		scenario string
+		value    any
+	}{
+		{scenario: "not a struct", value: 1},
+		{scenario: "no primary field", value: struct{ Name string }{}},
+		{scenario: "primary field/no type", value: struct {
+			ID string `jsonapi:"primary"`
+		}{}},
+		{scenario: "primary field/not an id", value: struct {
+			ID float64 `jsonapi:"primary,things"`
+		}{}},
+		{scenario: "relation field/no type", value: struct {
+			ID    string `jsonapi:"primary,things"`
+			Owner string `jsonapi:"relation,owner"`
+		}{}},
+		{scenario: "relation field/not an id", value: struct {
+			ID    string         `jsonapi:"primary,things"`
+			Owner map[string]int `jsonapi:"relation,owner,people"`
+		}{}},
+		{scenario: "primary field/unexported", value: struct {
+			id string `jsonapi:"primary,things"`
+		}{}},
+		{scenario: "relation field/unexported", value: struct {
+			ID    string `jsonapi:"primary,things"`
+			owner string `jsonapi:"relation,owner,people"`
+		}{}},
+		{scenario: "unknown tag", value: struct {
+			ID   string `jsonapi:"primary,things"`
+			Name string `jsonapi:"attr,name"`
+		}{}},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.scenario, func(t *testing.T) {
+			// ARRANGE
+			defer test.ExpectPanic(restapi.ErrInvalidArgument).Assert(t)
+
+			// ACT
+			NewResource(tc.value)
+		})
+	}
+}
+
+func TestNewResource(t *testing.T) {
+	// ARRANGE
+	editor := 7
+	a := &article{ID: "1", Title: "JSON:API", Author: "9", Editor: &editor, Tags: []string{"a"}}
+
+	// ACT
+	result, err := restapi.MarshalJSONAPI(NewResource(a).WithLink("self", "/articles/1"))
+
+	// ASSERT
+	test.Error(t, err).IsNil()
+	test.That(t, string(result)).Equals(`{` +
+		`"data":{"type":"articles","id":"1","attributes":{"title":"JSON:API"},"relationships":{` +
+		`"author":{"data":{"type":"people","id":"9"}},` +
+		`"editor":{"data":{"type":"people","id":"7"}},` +
+		`"tags":{"data":[{"type":"tags","id":"a"}]}` +
+		`}},` +
+		`"links":{"self":"/articles/1"}` +
+		`}`)
+
+	t.Run("nil", func(t *testing.T) {
+		// ARRANGE
+		defer test.ExpectPanic(restapi.ErrInvalidArgument).Assert(t)
+
+		// ACT
+		NewResource((*article)(nil))
+	})
+}
+
+func TestNewCollection(t *testing.T) {
+	// ARRANGE
+	type tag struct {
+		ID   uint   `json:"id" jsonapi:"primary,tags"`
+		Name string `json:"name"`
+	}
+
+	// ACT
+	c := NewCollection([]tag{{ID: 1, Name: "a"}, {ID: 2, Name: "b"}})
+	jsonAPI, jsonAPIErr := restapi.MarshalJSONAPI(c)
+	hal, halErr := restapi.MarshalHAL(c)
+
+	// ASSERT
+	test.Error(t, errors.Join(jsonAPIErr, halErr)).IsNil()
+	test.That(t, string(jsonAPI)).Equals(`{"data":[` +
+		`{"type":"tags","id":"1","attributes":{"name":"a"}},` +
+		`{"type":"tags","id":"2","attributes":{"name":"b"}}` +
+		`]}`)
+	test.That(t, string(hal)).Equals(`{"_embedded":{"tags":[{"id":1,"name":"a"},{"id":2,"name":"b"}]}}`)
+}
@@ -23,4 +23,7 @@ type InternalError struct {
 // i.e. no log is emitted.  Applications should replace the implementation
 // with one that produces an appropriate log using the logger configured
 // in their application.
+//
+// LogError is not called for requests handled by a Server that has its own
+// LogError function configured.
 var LogError = func(InternalError) { /* NO-OP */ }
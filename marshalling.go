@@ -3,20 +3,205 @@ package restapi
 import (
 	"encoding/json"
 	"encoding/xml"
+	"fmt"
+	"mime"
+	"reflect"
+	"slices"
+	"sync"
 )
 
-type marshalFunc = func(any) ([]byte, error)
+// MarshalFunc is the signature of a function that marshals a value to
+// the representation required for a particular content type.
+type MarshalFunc = func(any) ([]byte, error)
 
-// marshal is a map that associates each supported Content-Type to an
-// appropriate marshalling function.
+// Marshallers is a registry of supported content types, associating each
+// content type with an appropriate marshalling function.  The content types
+// in the registry are the content types that may be negotiated using a
+// request Accept header.
+//
+// Content types are maintained in the order in which they are registered;
+// this order determines the server preference when more than one content
+// type is equally acceptable to a client.  The first registered content type
+// is the default, used when a request does not express a preference.
+//
+// A Marshallers registry is safe for concurrent use, though it is expected
+// that content types will be registered during application startup.
+//
+// The zero value is an empty registry, ready to use; NewMarshallers returns a
+// registry initialised with the default content types.
+type Marshallers struct {
+	mu           sync.RWMutex
+	contentTypes []string
+	funcs        map[string]MarshalFunc
+}
+
+// marshal is the registry of content types and marshalling functions used
+// by default.
 //
 // FUTURE: more complete xml support (charset, etc.)
-// FUTURE: support q weights
-// FUTURE: additional content types (e.g. yaml)
-// FUTURE: configurable content types and marshallers
-var marshal = map[string]marshalFunc{
-	"application/json": json.Marshal,
-	"application/xml":  xml.Marshal,
-	"text/json":        func(v any) ([]byte, error) { return json.MarshalIndent(v, "", "  ") },
-	"text/xml":         func(v any) ([]byte, error) { return xml.MarshalIndent(v, "", "    ") },
+var marshal = NewMarshallers()
+
+// NewMarshallers returns a new registry initialised with marshalling functions
+// for the following content types (in order of preference):
+//
+//	application/json
+//	application/xml
+//	text/json                  // indented json
+//	text/xml                   // indented xml
+//	application/x-ndjson       // newline delimited json (see: MarshalNDJSON)
+//	application/hal+json       // a Resource or Collection (see: MarshalHAL)
+//	application/vnd.api+json   // a Resource or Collection (see: MarshalJSONAPI)
+//
+// A marshalling function returning ErrNotHypermedia for a value (e.g. a
+// hypermedia content type negotiated for a result that is not a Resource) is
+// not applied; the value is marshalled as application/json.
+func NewMarshallers() *Marshallers {
+	m := &Marshallers{funcs: map[string]MarshalFunc{}}
+	m.Register("application/json", json.Marshal)
+	m.Register("application/xml", xml.Marshal)
+	m.Register("text/json", func(v any) ([]byte, error) { return json.MarshalIndent(v, "", "  ") })
+	m.Register("text/xml", func(v any) ([]byte, error) { return xml.MarshalIndent(v, "", "    ") })
+	m.Register("application/x-ndjson", MarshalNDJSON)
+	m.Register(halContentType, MarshalHAL)
+	m.Register(jsonAPIContentType, MarshalJSONAPI)
+	return m
+}
+
+// MarshalNDJSON marshals a value as newline delimited json.  Each element of a
+// slice or array is marshalled as a separate line; any other value is marshalled
+// as a single line.
+func MarshalNDJSON(v any) ([]byte, error) {
+	rv := reflect.ValueOf(v)
+	if (rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array) || rv.Type().Elem().Kind() == reflect.Uint8 {
+		b, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		return append(b, '\n'), nil
+	}
+
+	buf := []byte{}
+	for i := 0; i < rv.Len(); i++ {
+		b, err := json.Marshal(rv.Index(i).Interface())
+		if err != nil {
+			return nil, err
+		}
+		buf = append(append(buf, b...), '\n')
+	}
+	return buf, nil
+}
+
+// normaliseContentType parses and formats a content type to ensure that
+// equivalent content types (e.g. differing only in case or whitespace) are
+// represented consistently.
+func normaliseContentType(contentType string) (string, error) {
+	mt, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return "", err
+	}
+	return mime.FormatMediaType(mt, params), nil
+}
+
+// ContentTypes returns the content types in the registry in order of
+// preference.
+func (m *Marshallers) ContentTypes() []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return slices.Clone(m.contentTypes)
+}
+
+// Register adds a content type to the registry with the specified marshalling
+// function.  If the content type is already registered the existing marshalling
+// function is replaced; the preference order of the content type is unchanged.
+//
+// # panics
+//
+// Register will panic with ErrInvalidArgument if the content type is not a valid
+// media type or the marshalling function is nil.
+func (m *Marshallers) Register(contentType string, fn MarshalFunc) {
+	ct, err := normaliseContentType(contentType)
+	if err != nil {
+		panic(fmt.Errorf("%w: content type: %w", ErrInvalidArgument, err))
+	}
+	if fn == nil {
+		panic(fmt.Errorf("%w: marshalling function for %s is nil", ErrInvalidArgument, ct))
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.funcs == nil {
+		m.funcs = map[string]MarshalFunc{}
+	}
+	if _, exists := m.funcs[ct]; !exists {
+		m.contentTypes = append(m.contentTypes, ct)
+	}
+	m.funcs[ct] = fn
+}
+
+// Unregister removes a content type from the registry.  If the content type
+// is not registered the call has no effect.
+func (m *Marshallers) Unregister(contentType string) {
+	ct, err := normaliseContentType(contentType)
+	if err != nil {
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.funcs, ct)
+	m.contentTypes = slices.DeleteFunc(m.contentTypes, func(s string) bool { return s == ct })
+}
+
+// get returns the marshalling function for a content type and a bool indicating
+// whether the content type is registered.
+func (m *Marshallers) get(contentType string) (MarshalFunc, bool) {
+	ct, err := normaliseContentType(contentType)
+	if err != nil {
+		return nil, false
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	fn, ok := m.funcs[ct]
+	return fn, ok
+}
+
+// negotiate returns the registered content type (and marshalling function)
+// that is most acceptable according to an Accept header.  The returned bool
+// is false if no registered content type is acceptable.
+func (m *Marshallers) negotiate(accept string) (string, MarshalFunc, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	ct, ok := negotiateContentType(accept, m.contentTypes)
+	if !ok {
+		return "", nil, false
+	}
+	return ct, m.funcs[ct], true
+}
+
+// RegisterMarshaller registers a content type and marshalling function with
+// the default registry.  If the content type is already registered the existing
+// marshalling function is replaced.
+//
+// # example
+//
+//	restapi.RegisterMarshaller("application/x-yaml", yaml.Marshal)
+//
+// # panics
+//
+// RegisterMarshaller will panic with ErrInvalidArgument if the content type is not
+// a valid media type or the marshalling function is nil.
+func RegisterMarshaller(contentType string, fn MarshalFunc) {
+	marshal.Register(contentType, fn)
+}
+
+// UnregisterMarshaller removes a content type from the default registry.
+func UnregisterMarshaller(contentType string) {
+	marshal.Unregister(contentType)
+}
+
+// SupportedContentTypes returns the content types in the default registry,
+// in order of preference.
+func SupportedContentTypes() []string {
+	return marshal.ContentTypes()
 }
@@ -1,7 +1,9 @@
 package restapi
 
 import (
+	"encoding/json"
 	"encoding/xml"
+	"sync"
 	"testing"
 
 	"github.com/blugnu/test"
@@ -16,7 +18,8 @@ func TestMarshalling(t *testing.T) {
 		{scenario: "application/json",
 			exec: func(t *testing.T) {
 				// ACT
-				result, _ := marshal["application/json"](struct{ A int }{A: 1})
+				fn, _ := marshal.get("application/json")
+				result, _ := fn(struct{ A int }{A: 1})
 
 				// ASSERT
 				test.That(t, string(result)).Equals(`{"A":1}`)
@@ -25,7 +28,8 @@ func TestMarshalling(t *testing.T) {
 		{scenario: "application/xml",
 			exec: func(t *testing.T) {
 				// ACT
-				result, _ := marshal["application/xml"](struct {
+				fn, _ := marshal.get("application/xml")
+				result, _ := fn(struct {
 					XMLName xml.Name
 					A       int
 				}{
@@ -40,7 +44,8 @@ func TestMarshalling(t *testing.T) {
 		{scenario: "text/json",
 			exec: func(t *testing.T) {
 				// ACT
-				result, _ := marshal["text/json"](struct{ A int }{A: 1})
+				fn, _ := marshal.get("text/json")
+				result, _ := fn(struct{ A int }{A: 1})
 
 				// ASSERT
 				test.That(t, string(result)).Equals("{\n  \"A\": 1\n}")
@@ -49,7 +54,8 @@ func TestMarshalling(t *testing.T) {
 		{scenario: "text/xml",
 			exec: func(t *testing.T) {
 				// ACT
-				result, _ := marshal["text/xml"](struct {
+				fn, _ := marshal.get("text/xml")
+				result, _ := fn(struct {
 					XMLName xml.Name
 					A       int
 				}{
@@ -61,6 +67,167 @@ func TestMarshalling(t *testing.T) {
 				test.That(t, string(result)).Equals("<struct>\n    <A>1</A>\n</struct>")
 			},
 		},
+		{scenario: "application/x-ndjson",
+			exec: func(t *testing.T) {
+				// ACT
+				fn, _ := marshal.get("application/x-ndjson")
+				single, _ := fn(struct{ A int }{A: 1})
+				multiple, _ := fn([]struct{ A int }{{A: 1}, {A: 2}})
+				bytes, _ := fn([]byte("abc"))
+				_, err := fn([]any{func() {}})
+
+				// ASSERT
+				test.That(t, string(single)).Equals("{\"A\":1}\n")
+				test.That(t, string(multiple)).Equals("{\"A\":1}\n{\"A\":2}\n")
+				test.That(t, string(bytes)).Equals("\"YWJj\"\n")
+				test.IsTrue(t, err != nil)
+			},
+		},
+		{scenario: "ContentTypes",
+			exec: func(t *testing.T) {
+				// ACT
+				result := NewMarshallers().ContentTypes()
+
+				// ASSERT
+				test.That(t, result).Equals([]string{"application/json", "application/xml", "text/json", "text/xml", "application/x-ndjson", "application/hal+json", "application/vnd.api+json"})
+			},
+		},
+		{scenario: "Register/new content type",
+			exec: func(t *testing.T) {
+				// ARRANGE
+				sut := NewMarshallers()
+
+				// ACT
+				sut.Register("Application/Vnd.Acme+JSON; Version=2", json.Marshal)
+
+				// ASSERT
+				test.That(t, sut.ContentTypes()).Equals([]string{"application/json", "application/xml", "text/json", "text/xml", "application/x-ndjson", "application/hal+json", "application/vnd.api+json", "application/vnd.acme+json; version=2"})
+				_, ok := sut.get("application/vnd.acme+json;version=2")
+				test.IsTrue(t, ok)
+			},
+		},
+		{scenario: "Register/replace existing content type",
+			exec: func(t *testing.T) {
+				// ARRANGE
+				sut := NewMarshallers()
+
+				// ACT
+				sut.Register("application/xml", func(any) ([]byte, error) { return []byte("replaced"), nil })
+
+				// ASSERT
+				test.That(t, sut.ContentTypes()).Equals([]string{"application/json", "application/xml", "text/json", "text/xml", "application/x-ndjson", "application/hal+json", "application/vnd.api+json"})
+				fn, _ := sut.get("application/xml")
+				result, _ := fn(nil)
+				test.That(t, string(result)).Equals("replaced")
+			},
+		},
+		{scenario: "Register/zero value",
+			exec: func(t *testing.T) {
+				// ARRANGE
+				sut := &Marshallers{}
+
+				// ACT
+				sut.Register("application/cbor", json.Marshal)
+
+				// ASSERT
+				test.That(t, sut.ContentTypes()).Equals([]string{"application/cbor"})
+				_, ok := sut.get("application/cbor")
+				test.IsTrue(t, ok)
+			},
+		},
+		{scenario: "Register/invalid content type",
+			exec: func(t *testing.T) {
+				// ARRANGE
+				defer test.ExpectPanic(ErrInvalidArgument).Assert(t)
+
+				// ACT
+				NewMarshallers().Register("not a content type", json.Marshal)
+			},
+		},
+		{scenario: "Register/nil function",
+			exec: func(t *testing.T) {
+				// ARRANGE
+				defer test.ExpectPanic(ErrInvalidArgument).Assert(t)
+
+				// ACT
+				NewMarshallers().Register("application/cbor", nil)
+			},
+		},
+		{scenario: "Register/concurrent",
+			exec: func(t *testing.T) {
+				// ARRANGE
+				sut := NewMarshallers()
+				wg := sync.WaitGroup{}
+
+				// ACT
+				for _, ct := range []string{"application/a", "application/b", "application/c", "application/d"} {
+					wg.Add(1)
+					go func(ct string) {
+						defer wg.Done()
+						sut.Register(ct, json.Marshal)
+						_, _, _ = sut.negotiate(ct)
+					}(ct)
+				}
+				wg.Wait()
+
+				// ASSERT
+				test.That(t, len(sut.ContentTypes())).Equals(11)
+			},
+		},
+		{scenario: "Unregister",
+			exec: func(t *testing.T) {
+				// ARRANGE
+				sut := NewMarshallers()
+
+				// ACT
+				sut.Unregister("application/json")
+				sut.Unregister("not a content type")
+
+				// ASSERT
+				test.That(t, sut.ContentTypes()).Equals([]string{"application/xml", "text/json", "text/xml", "application/x-ndjson", "application/hal+json", "application/vnd.api+json"})
+				_, ok := sut.get("application/json")
+				test.IsFalse(t, ok)
+			},
+		},
+		{scenario: "negotiate",
+			exec: func(t *testing.T) {
+				// ARRANGE
+				sut := NewMarshallers()
+				sut.Unregister("application/json")
+
+				// ACT
+				ct, fn, ok := sut.negotiate("")
+
+				// ASSERT
+				test.IsTrue(t, ok)
+				test.That(t, ct).Equals("application/xml")
+				test.That(t, fn).IsNotNil()
+
+				// ACT
+				_, _, ok = sut.negotiate("application/json")
+
+				// ASSERT
+				test.IsFalse(t, ok)
+			},
+		},
+		{scenario: "RegisterMarshaller/UnregisterMarshaller",
+			exec: func(t *testing.T) {
+				// ARRANGE
+				defer test.Using(&marshal, NewMarshallers())()
+
+				// ACT
+				RegisterMarshaller("application/cbor", json.Marshal)
+
+				// ASSERT
+				test.That(t, SupportedContentTypes()).Equals([]string{"application/json", "application/xml", "text/json", "text/xml", "application/x-ndjson", "application/hal+json", "application/vnd.api+json", "application/cbor"})
+
+				// ACT
+				UnregisterMarshaller("application/cbor")
+
+				// ASSERT
+				test.That(t, SupportedContentTypes()).Equals([]string{"application/json", "application/xml", "text/json", "text/xml", "application/x-ndjson", "application/hal+json", "application/vnd.api+json"})
+			},
+		},
 	}
 	for _, tc := range testcases {
 		t.Run(tc.scenario, func(t *testing.T) {
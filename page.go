@@ -0,0 +1,294 @@
+package restapi
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// query parameters identifying a page of a collection
+const (
+	pageCursorParam = "cursor"
+	pageLimitParam  = "limit"
+	pageOffsetParam = "offset"
+)
+
+// PageRequest identifies a page of a collection requested using limit, offset
+// and cursor query parameters (see: ParsePageRequest).
+type PageRequest struct {
+	Limit  int
+	Offset int
+	Cursor string
+}
+
+// ParsePageRequest returns the page of a collection requested by the limit,
+// offset and cursor query parameters of a request:
+//
+//	limit     // the maximum number of items in the page
+//	offset    // the number of items preceding the page (for offset pagination)
+//	cursor    // an opaque cursor identifying the page (for cursor pagination)
+//
+// If no limit is specified, the defaultLimit is applied.  A limit greater than
+// maxLimit is reduced to maxLimit; a maxLimit of zero (or less) applies no
+// maximum.
+//
+// If the limit is not a positive integer or the offset is not an integer of
+// zero or more, an Error with a status of 400 Bad Request is returned, wrapping
+// ErrInvalidParameter, with a "parameters" property listing each invalid
+// parameter (as ParameterErrors).
+//
+// # panics
+//
+// ParsePageRequest will panic with ErrInvalidArgument if the defaultLimit is
+// less than 1 or greater than a maxLimit.
+//
+// # example
+//
+//	func GetItems(ctx context.Context, rq *http.Request) any {
+//	    pg, err := restapi.ParsePageRequest(rq, 20, 100)
+//	    if err != nil {
+//	        return err
+//	    }
+//	    items, total, err := store.List(ctx, pg.Offset, pg.Limit)
+//	    if err != nil {
+//	        return err
+//	    }
+//	    return restapi.OffsetPage(items, pg.Offset, pg.Limit, total)
+//	}
+func ParsePageRequest(rq *http.Request, defaultLimit, maxLimit int) (PageRequest, error) {
+	if defaultLimit < 1 || (maxLimit > 0 && defaultLimit > maxLimit) {
+		panic(fmt.Errorf("%w: ParsePageRequest: invalid limits (default %d, max %d)", ErrInvalidArgument, defaultLimit, maxLimit))
+	}
+
+	query := rq.URL.Query()
+	result := PageRequest{
+		Limit:  defaultLimit,
+		Cursor: query.Get(pageCursorParam),
+	}
+
+	var errs []ParameterError
+	if s := query.Get(pageLimitParam); s != "" {
+		if n, err := strconv.Atoi(s); err != nil || n < 1 {
+			errs = append(errs, ParameterError{In: "query", Name: pageLimitParam, Reason: "must be a positive integer"})
+		} else {
+			result.Limit = n
+		}
+	}
+	if s := query.Get(pageOffsetParam); s != "" {
+		if n, err := strconv.Atoi(s); err != nil || n < 0 {
+			errs = append(errs, ParameterError{In: "query", Name: pageOffsetParam, Reason: "must be an integer of zero or more"})
+		} else {
+			result.Offset = n
+		}
+	}
+	if len(errs) > 0 {
+		return PageRequest{}, BadRequest(ErrInvalidParameter, rq).
+			WithProperty("parameters", errs)
+	}
+
+	if maxLimit > 0 && result.Limit > maxLimit {
+		result.Limit = maxLimit
+	}
+	return result, nil
+}
+
+// paginated is implemented by Result content that is a page of a collection.
+type paginated interface {
+	// paginate adds the headers describing the page to the headers of a
+	// response to a request for the page at a URL, returning the value to be
+	// marshalled as the content of the response (with the items projected to
+	// any specified fields)
+	paginate(u *url.URL, h headers, fields []string) any
+}
+
+// Page is a page of items from a collection, using either offset or cursor
+// pagination (see: OffsetPage and CursorPage).
+//
+// A Page may be returned by an endpoint function or set as the value of a
+// Result.  The response includes Link headers (RFC 8288) identifying the
+// first, prev, next and last pages of the collection (as applicable), derived
+// from the request URL, and the content is an envelope holding the items with
+// the details of the page:
+//
+//	{
+//	    "items": [ ... ],
+//	    "limit": 20,
+//	    "offset": 40,
+//	    "total": 95
+//	}
+//
+// To respond with an array of the items, with the details of the page in
+// headers only, use WithoutEnvelope.
+type Page[T any] struct {
+	items    []T
+	limit    int
+	offset   int
+	total    int
+	cursor   bool
+	next     string
+	prev     string
+	envelope bool
+}
+
+// pageEnvelope is the content of a response for a Page.  The Items are those
+// of the Page, or a projection of them.
+type pageEnvelope struct {
+	XMLName xml.Name `json:"-" xml:"page"`
+	Items   any      `json:"items" xml:"items>item"`
+	Limit   int      `json:"limit" xml:"limit"`
+	Offset  *int     `json:"offset,omitempty" xml:"offset,omitempty"`
+	Total   *int     `json:"total,omitempty" xml:"total,omitempty"`
+	Next    string   `json:"next,omitempty" xml:"next,omitempty"`
+	Prev    string   `json:"prev,omitempty" xml:"prev,omitempty"`
+}
+
+// envelopeMask implements the sparseEnvelope interface for a pageEnvelope,
+// applying a mask to the items in the page and retaining the details of the
+// page.
+func (env pageEnvelope) envelopeMask(m fieldMask, format string) fieldMask {
+	if format == "xml" {
+		m = fieldMask{"item": m}
+	}
+	return fieldMask{
+		"items":  m,
+		"limit":  nil,
+		"offset": nil,
+		"total":  nil,
+		"next":   nil,
+		"prev":   nil,
+	}
+}
+
+// newPage returns a new Page with specified items and limit.
+func newPage[T any](items []T, limit int) *Page[T] {
+	if limit < 1 {
+		panic(fmt.Errorf("%w: page limit must be 1 or more", ErrInvalidArgument))
+	}
+	if items == nil {
+		items = []T{}
+	}
+	return &Page[T]{items: items, limit: limit, total: -1, envelope: true}
+}
+
+// OffsetPage returns a Page of items from a collection using offset pagination,
+// where offset is the number of items in the collection preceding the page
+// and limit is the maximum number of items in a page.  The total number of
+// items in the collection is specified by total, or -1 if not known; if the
+// total is not known there is assumed to be a next page if the page is full.
+//
+// OffsetPage will panic with ErrInvalidArgument if limit is less than 1.
+func OffsetPage[T any](items []T, offset, limit, total int) *Page[T] {
+	p := newPage(items, limit)
+	p.offset = max(offset, 0)
+	p.total = max(total, -1)
+	return p
+}
+
+// CursorPage returns a Page of items from a collection using cursor pagination,
+// where limit is the maximum number of items in a page and next and prev are
+// opaque cursors identifying the next and previous pages (an empty cursor
+// indicates that there is no such page).
+//
+// CursorPage will panic with ErrInvalidArgument if limit is less than 1.
+func CursorPage[T any](items []T, limit int, next, prev string) *Page[T] {
+	p := newPage(items, limit)
+	p.cursor = true
+	p.next = next
+	p.prev = prev
+	return p
+}
+
+// Items returns the items in the Page.
+func (p *Page[T]) Items() []T {
+	return p.items
+}
+
+// WithoutEnvelope specifies that the content of the response for the Page is
+// an array of the items in the page, rather than an envelope.  The total
+// number of items in the collection (if known) is returned in an
+// X-Total-Count header.
+func (p *Page[T]) WithoutEnvelope() *Page[T] {
+	p.envelope = false
+	return p
+}
+
+// links returns the Link header values for the Page, for a request at a
+// specified URL.
+func (p *Page[T]) links(u *url.URL) []string {
+	link := func(rel string, params map[string]string) string {
+		q := u.Query()
+		for k, v := range params {
+			if v == "" {
+				q.Del(k)
+				continue
+			}
+			q.Set(k, v)
+		}
+		ref := url.URL{Path: u.Path, RawPath: u.RawPath, RawQuery: q.Encode()}
+		return fmt.Sprintf("<%s>; rel=%q", ref.String(), rel)
+	}
+	limit := strconv.Itoa(p.limit)
+
+	if p.cursor {
+		links := []string{link("first", map[string]string{pageCursorParam: "", pageLimitParam: limit})}
+		if p.prev != "" {
+			links = append(links, link("prev", map[string]string{pageCursorParam: p.prev, pageLimitParam: limit}))
+		}
+		if p.next != "" {
+			links = append(links, link("next", map[string]string{pageCursorParam: p.next, pageLimitParam: limit}))
+		}
+		return links
+	}
+
+	offset := func(n int) map[string]string {
+		if n == 0 {
+			return map[string]string{pageOffsetParam: "", pageLimitParam: limit}
+		}
+		return map[string]string{pageOffsetParam: strconv.Itoa(n), pageLimitParam: limit}
+	}
+
+	links := []string{link("first", offset(0))}
+	if p.offset > 0 {
+		links = append(links, link("prev", offset(max(p.offset-p.limit, 0))))
+	}
+	if (p.total < 0 && len(p.items) >= p.limit) || (p.total >= 0 && p.offset+p.limit < p.total) {
+		links = append(links, link("next", offset(p.offset+p.limit)))
+	}
+	if p.total >= 0 {
+		links = append(links, link("last", offset(max(p.total-1, 0)/p.limit*p.limit)))
+	}
+	return links
+}
+
+// paginate implements the paginated interface for a Page.
+func (p *Page[T]) paginate(u *url.URL, h headers, fields []string) any {
+	if u != nil {
+		for _, link := range p.links(u) {
+			h.add("Link", link)
+		}
+	}
+
+	items := project(p.items, fields)
+	if !p.envelope {
+		if p.total >= 0 {
+			h.set("X-Total-Count", p.total)
+		}
+		return items
+	}
+
+	env := pageEnvelope{
+		Items: items,
+		Limit: p.limit,
+		Next:  p.next,
+		Prev:  p.prev,
+	}
+	if !p.cursor {
+		env.Offset = &p.offset
+		if p.total >= 0 {
+			env.Total = &p.total
+		}
+	}
+	return env
+}
@@ -0,0 +1,229 @@
+package restapi
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/blugnu/test"
+)
+
+func TestParsePageRequest(t *testing.T) {
+	// ARRANGE
+	testcases := []struct {
+		query  string
+		result PageRequest
+		params []ParameterError
+	}{
+		{query: "", result: PageRequest{Limit: 20}},
+		{query: "limit=50&offset=100", result: PageRequest{Limit: 50, Offset: 100}},
+		{query: "limit=500", result: PageRequest{Limit: 100}},
+		{query: "cursor=abc", result: PageRequest{Limit: 20, Cursor: "abc"}},
+		{query: "limit=0", params: []ParameterError{{In: "query", Name: "limit", Reason: "must be a positive integer"}}},
+		{query: "limit=x&offset=-1", params: []ParameterError{
+			{In: "query", Name: "limit", Reason: "must be a positive integer"},
+			{In: "query", Name: "offset", Reason: "must be an integer of zero or more"},
+		}},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.query, func(t *testing.T) {
+			// ARRANGE
+			rq := httptest.NewRequest(http.MethodGet, "/items?"+tc.query, nil)
+
+			// ACT
+			result, err := ParsePageRequest(rq, 20, 100)
+
+			// ASSERT
+			if tc.params == nil {
+				test.Error(t, err).IsNil()
+				test.That(t, result).Equals(tc.result)
+				return
+			}
+			test.Error(t, err).Is(ErrInvalidParameter)
+
+			var apierr *Error
+			test.IsTrue(t, errors.As(err, &apierr))
+			test.That(t, apierr.statusCode).Equals(http.StatusBadRequest)
+			test.That(t, apierr.properties["parameters"]).Equals(any(tc.params))
+		})
+	}
+
+	t.Run("invalid limits", func(t *testing.T) {
+		// ARRANGE
+		defer test.ExpectPanic(ErrInvalidArgument).Assert(t)
+
+		// ACT
+		_, _ = ParsePageRequest(httptest.NewRequest(http.MethodGet, "/", nil), 50, 10)
+	})
+}
+
+func TestPage(t *testing.T) {
+	// ARRANGE
+	serve := func(target string, accept string, result any) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		rq := httptest.NewRequest(http.MethodGet, target, nil)
+		rq.Header.Set("Accept", accept)
+		HandlerFunc(func(context.Context, *http.Request) any { return result })(rec, rq)
+		return rec
+	}
+	items := []int{1, 2}
+
+	testcases := []struct {
+		scenario string
+		exec     func(t *testing.T)
+	}{
+		{scenario: "offset/links",
+			exec: func(t *testing.T) {
+				// ARRANGE
+				testcases := []struct {
+					name   string
+					page   *Page[int]
+					result []string
+				}{
+					{name: "first page", page: OffsetPage(items, 0, 2, 5), result: []string{
+						`</items?limit=2&q=x>; rel="first"`,
+						`</items?limit=2&offset=2&q=x>; rel="next"`,
+						`</items?limit=2&offset=4&q=x>; rel="last"`,
+					}},
+					{name: "middle page", page: OffsetPage(items, 3, 2, 6), result: []string{
+						`</items?limit=2&q=x>; rel="first"`,
+						`</items?limit=2&offset=1&q=x>; rel="prev"`,
+						`</items?limit=2&offset=5&q=x>; rel="next"`,
+						`</items?limit=2&offset=4&q=x>; rel="last"`,
+					}},
+					{name: "last page", page: OffsetPage(items, 4, 2, 6), result: []string{
+						`</items?limit=2&q=x>; rel="first"`,
+						`</items?limit=2&offset=2&q=x>; rel="prev"`,
+						`</items?limit=2&offset=4&q=x>; rel="last"`,
+					}},
+					{name: "unknown total/full page", page: OffsetPage(items, 2, 2, -1), result: []string{
+						`</items?limit=2&q=x>; rel="first"`,
+						`</items?limit=2&q=x>; rel="prev"`,
+						`</items?limit=2&offset=4&q=x>; rel="next"`,
+					}},
+					{name: "unknown total/partial page", page: OffsetPage(items, 0, 10, -1), result: []string{
+						`</items?limit=10&q=x>; rel="first"`,
+					}},
+					{name: "empty collection", page: OffsetPage([]int{}, 0, 10, 0), result: []string{
+						`</items?limit=10&q=x>; rel="first"`,
+						`</items?limit=10&q=x>; rel="last"`,
+					}},
+				}
+				for _, tc := range testcases {
+					t.Run(tc.name, func(t *testing.T) {
+						// ACT
+						rec := serve("/items?q=x&offset=99", "application/json", tc.page)
+
+						// ASSERT
+						test.That(t, rec.Header()["Link"]).Equals(tc.result)
+					})
+				}
+			},
+		},
+		{scenario: "offset/envelope",
+			exec: func(t *testing.T) {
+				// ACT
+				rec := serve("/items", "application/json", OffsetPage(items, 0, 2, 5))
+
+				// ASSERT
+				test.That(t, rec.Body.String()).Equals(`{"items":[1,2],"limit":2,"offset":0,"total":5}`)
+			},
+		},
+		{scenario: "offset/envelope/unknown total",
+			exec: func(t *testing.T) {
+				// ACT
+				rec := serve("/items", "application/json", OffsetPage([]int(nil), 0, 2, -1))
+
+				// ASSERT
+				test.That(t, rec.Body.String()).Equals(`{"items":[],"limit":2,"offset":0}`)
+			},
+		},
+		{scenario: "offset/envelope/xml",
+			exec: func(t *testing.T) {
+				// ACT
+				rec := serve("/items", "application/xml", OffsetPage(items, 0, 2, 5))
+
+				// ASSERT
+				test.That(t, rec.Body.String()).Equals(`<page><items><item>1</item><item>2</item></items><limit>2</limit><offset>0</offset><total>5</total></page>`)
+			},
+		},
+		{scenario: "offset/without envelope",
+			exec: func(t *testing.T) {
+				// ACT
+				rec := serve("/items", "application/json", OffsetPage(items, 0, 2, 5).WithoutEnvelope())
+
+				// ASSERT
+				test.That(t, rec.Body.String()).Equals(`[1,2]`)
+				test.That(t, rec.Header().Get("X-Total-Count")).Equals("5")
+			},
+		},
+		{scenario: "cursor/links",
+			exec: func(t *testing.T) {
+				// ACT
+				rec := serve("/items?cursor=c2", "application/json", CursorPage(items, 2, "c3", "c1"))
+
+				// ASSERT
+				test.That(t, rec.Header()["Link"]).Equals([]string{
+					`</items?limit=2>; rel="first"`,
+					`</items?cursor=c1&limit=2>; rel="prev"`,
+					`</items?cursor=c3&limit=2>; rel="next"`,
+				})
+				test.That(t, rec.Body.String()).Equals(`{"items":[1,2],"limit":2,"next":"c3","prev":"c1"}`)
+			},
+		},
+		{scenario: "cursor/without envelope",
+			exec: func(t *testing.T) {
+				// ACT
+				rec := serve("/items", "application/json", CursorPage(items, 2, "", "").WithoutEnvelope())
+
+				// ASSERT
+				test.That(t, rec.Header()["Link"]).Equals([]string{`</items?limit=2>; rel="first"`})
+				test.That(t, rec.Header().Get("X-Total-Count")).Equals("")
+				test.That(t, rec.Body.String()).Equals(`[1,2]`)
+			},
+		},
+		{scenario: "result value/with link header",
+			exec: func(t *testing.T) {
+				// ARRANGE
+				result := OK().
+					WithHeader("Link", `</docs>; rel="help"`).
+					WithValue(CursorPage(items, 2, "", ""))
+
+				// ACT
+				rec := serve("/items", "application/json", result)
+
+				// ASSERT
+				test.That(t, rec.Header()["Link"]).Equals([]string{
+					`</docs>; rel="help"`,
+					`</items?limit=2>; rel="first"`,
+				})
+				test.That(t, result.headers["Link"]).Equals(any(`</docs>; rel="help"`))
+			},
+		},
+		{scenario: "items",
+			exec: func(t *testing.T) {
+				// ACT
+				result := OffsetPage(items, 0, 2, 2).Items()
+
+				// ASSERT
+				test.That(t, result).Equals(items)
+			},
+		},
+		{scenario: "invalid limit",
+			exec: func(t *testing.T) {
+				// ARRANGE
+				defer test.ExpectPanic(ErrInvalidArgument).Assert(t)
+
+				// ACT
+				_ = CursorPage(items, 0, "", "")
+			},
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.scenario, func(t *testing.T) {
+			tc.exec(t)
+		})
+	}
+}
@@ -1,12 +1,28 @@
 package restapi
 
 import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
 	"fmt"
 	"net/http"
 	"net/url"
+	"slices"
+	"sort"
 	"strings"
+	"unicode"
 )
 
+// content types of a Problem response
+const (
+	problemJSON = "application/problem+json"
+	problemXML  = "application/problem+xml"
+)
+
+// problemNamespace is the xml namespace of a Problem response (RFC 9457
+// retains the namespace defined by RFC 7807)
+const problemNamespace = "urn:ietf:rfc:7807"
+
 var (
 	makeProblemResponse = func(p *Problem, rq *Request) *Response {
 		response := map[string]any{}
@@ -33,9 +49,23 @@ var (
 			panic(fmt.Errorf("%w: an uninitialised restapi.Problem was returned", ErrInvalidOperation))
 		}
 
-		result, err := rq.MarshalContent(response)
+		contentType := problemContentType(rq)
+
+		var (
+			result []byte
+			err    error
+		)
+		switch {
+		case contentType == problemXML:
+			result, err = marshalProblemXML(response)
+		case sequenceFormat(rq.Accept) == "json" && !isHypermedia(rq.Accept):
+			// the negotiated json marshaller is used (e.g. indented for text/json)
+			result, err = rq.MarshalContent(response)
+		default:
+			result, err = json.Marshal(response)
+		}
 		if err != nil {
-			LogError(InternalError{
+			rq.logError(InternalError{
 				Err:     err,
 				Message: "error marshalling Problem response",
 				Help:    fmt.Sprintf("Problem: %v", p),
@@ -47,16 +77,162 @@ var (
 
 		return &Response{
 			StatusCode:  coalesce(p.Status, http.StatusInternalServerError),
-			ContentType: "application/problem+json",
+			ContentType: contentType,
 			Content:     result,
+			negotiated:  true,
 		}
 	}
 )
 
-// FUTURE: implementation of rfc7807; https://datatracker.ietf.org/doc/html/rfc7807
+// problemContentType returns the content type of a Problem response for a
+// request: application/problem+xml or application/problem+json.
+//
+// The problem content types are negotiated using the request Accept header,
+// preferring the type corresponding to the content type negotiated for the
+// request (application/problem+xml if an xml content type was negotiated).  If
+// neither problem content type is acceptable, the type corresponding to the
+// negotiated content type is returned.
+func problemContentType(rq *Request) string {
+	preferred := []string{problemJSON, problemXML}
+	if sequenceFormat(rq.Accept) == "xml" {
+		preferred = []string{problemXML, problemJSON}
+	}
+	if rq.Request == nil {
+		return preferred[0]
+	}
+	if ct, ok := negotiateContentType(rq.Header.Get("Accept"), preferred); ok {
+		return ct
+	}
+	return preferred[0]
+}
+
+// marshalProblemXML marshals the members of a Problem response as xml, as
+// described in RFC 9457, Appendix B: a <problem> element in the
+// urn:ietf:rfc:7807 namespace with an element for each member.  Extension
+// members are represented by their json form; arrays are represented by <i>
+// elements and objects by an element for each member.
+//
+// An error is returned if the name of any member (or of a member of an object)
+// is not a valid xml element name (e.g. "not valid" or "1st").
+func marshalProblemXML(members map[string]any) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	enc := xml.NewEncoder(buf)
+
+	start := xml.StartElement{
+		Name: xml.Name{Local: "problem"},
+		Attr: []xml.Attr{{Name: xml.Name{Local: "xmlns"}, Value: problemNamespace}},
+	}
+	if err := enc.EncodeToken(start); err != nil {
+		return nil, err
+	}
+
+	// the standard members are written first, followed by any extension
+	// members in key order
+	keys := []string{}
+	for k := range members {
+		if !slices.Contains([]string{"type", "title", "status", "detail", "instance"}, k) {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	keys = append([]string{"type", "title", "status", "detail", "instance"}, keys...)
+
+	for _, k := range keys {
+		v, ok := members[k]
+		if !ok {
+			continue
+		}
+		// extension members are converted to their json form
+		b, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		dec := json.NewDecoder(bytes.NewReader(b))
+		dec.UseNumber()
+		if err := dec.Decode(&v); err != nil {
+			return nil, err
+		}
+		if err := encodeProblemXML(enc, k, v); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := enc.EncodeToken(start.End()); err != nil {
+		return nil, err
+	}
+	if err := enc.Flush(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// isXMLName returns true if a name is a valid xml element name with no
+// namespace prefix (an NCName).  Letters and digits are those identified as
+// such by the unicode package.
+func isXMLName(name string) bool {
+	for i, r := range name {
+		switch {
+		case unicode.IsLetter(r) || r == '_':
+			continue
+		case i > 0 && (unicode.IsDigit(r) || r == '-' || r == '.' || unicode.In(r, unicode.Mn, unicode.Mc)):
+			continue
+		default:
+			return false
+		}
+	}
+	return name != ""
+}
+
+// encodeProblemXML encodes an element with a value in json form (as decoded
+// into an any).  An error is returned if the name of the element, or of any
+// member of an object value, is not a valid xml element name.
+func encodeProblemXML(enc *xml.Encoder, name string, v any) error {
+	if !isXMLName(name) {
+		return fmt.Errorf("xml: invalid element name: %q", name)
+	}
+	start := xml.StartElement{Name: xml.Name{Local: name}}
+	if err := enc.EncodeToken(start); err != nil {
+		return err
+	}
+
+	switch v := v.(type) {
+	case nil:
+		// an empty element
 
-// Implements an RFC7807 Problem Details response
-// https://www.rfc-editor.org/rfc/rfc7807
+	case []any:
+		for _, item := range v {
+			if err := encodeProblemXML(enc, "i", item); err != nil {
+				return err
+			}
+		}
+
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			if err := encodeProblemXML(enc, k, v[k]); err != nil {
+				return err
+			}
+		}
+
+	default:
+		if err := enc.EncodeToken(xml.CharData(fmt.Sprint(v))); err != nil {
+			return err
+		}
+	}
+
+	return enc.EncodeToken(start.End())
+}
+
+// Implements an RFC 9457 Problem Details response (RFC 9457 obsoletes RFC 7807)
+// https://www.rfc-editor.org/rfc/rfc9457
+//
+// A Problem response is application/problem+json or, if xml is negotiated for
+// the request (or application/problem+xml is preferred by the request Accept
+// header), application/problem+xml.
 type Problem struct {
 	Type     *url.URL
 	Status   int
@@ -149,7 +325,7 @@ func NewProblem(args ...any) *Problem {
 }
 
 // makeResponse generates a response for the Problem instance.  The response will be a JSON
-// encoded RFC7807 Problem Details response.
+// (or XML) encoded RFC 9457 Problem Details response.
 //
 // If the Problem instance has a Type, Title, Status, Detail or Instance set, these will be
 // included in the response.  Any additional properties set on the Problem instance will also
@@ -1,9 +1,12 @@
 package restapi
 
 import (
+	"context"
 	"encoding/json"
+	"encoding/xml"
 	"errors"
 	"net/http"
+	"net/http/httptest"
 	"net/url"
 	"testing"
 	"time"
@@ -371,7 +374,7 @@ func TestProblem(t *testing.T) {
 				// ARRANGE
 				errm := errors.New("marshalling error")
 				rq := &Request{
-					Accept:  "request/Content-Type",
+					Accept:  "application/json",
 					Request: &http.Request{URL: &url.URL{}},
 					MarshalContent: func(v any) ([]byte, error) {
 						switch v.(type) {
@@ -394,6 +397,136 @@ func TestProblem(t *testing.T) {
 				test.That(t, response, "response").Equals(&Response{ContentType: "Error-Content"})
 			},
 		},
+		{scenario: "makeResponse/content negotiation",
+			exec: func(t *testing.T) {
+				// ARRANGE
+				testcases := []struct {
+					accept      string
+					contentType string
+					content     string
+				}{
+					{accept: "", contentType: "application/problem+json", content: `{"status":404}`},
+					{accept: "application/json", contentType: "application/problem+json", content: `{"status":404}`},
+					{accept: "application/x-ndjson", contentType: "application/problem+json", content: `{"status":404}`},
+					{accept: "text/json", contentType: "application/problem+json", content: "{\n  \"status\": 404\n}"},
+					{accept: "application/xml", contentType: "application/problem+xml",
+						content: `<problem xmlns="urn:ietf:rfc:7807"><status>404</status></problem>`},
+					{accept: "application/xml, */*;q=0.1", contentType: "application/problem+xml",
+						content: `<problem xmlns="urn:ietf:rfc:7807"><status>404</status></problem>`},
+					{accept: "application/problem+xml, application/json;q=0.5", contentType: "application/problem+xml",
+						content: `<problem xmlns="urn:ietf:rfc:7807"><status>404</status></problem>`},
+					{accept: "application/problem+json, application/xml", contentType: "application/problem+json", content: `{"status":404}`},
+				}
+				for _, tc := range testcases {
+					t.Run(tc.accept, func(t *testing.T) {
+						// ARRANGE
+						rec := httptest.NewRecorder()
+						rq := httptest.NewRequest(http.MethodGet, "/", nil)
+						if tc.accept != "" {
+							rq.Header.Set("Accept", tc.accept)
+						}
+
+						// ACT
+						HandlerFunc(func(context.Context, *http.Request) any {
+							return &Problem{Status: http.StatusNotFound}
+						})(rec, rq)
+
+						// ASSERT
+						test.That(t, rec.Code).Equals(http.StatusNotFound)
+						test.That(t, rec.Header().Get("Content-Type")).Equals(tc.contentType)
+						test.That(t, rec.Header().Get("Vary")).Equals("Accept")
+						test.That(t, rec.Body.String()).Equals(tc.content)
+					})
+				}
+			},
+		},
+		{scenario: "makeResponse/xml",
+			exec: func(t *testing.T) {
+				// ARRANGE
+				rq := &Request{Accept: "application/xml", MarshalContent: xml.Marshal}
+				p := NewProblem(http.StatusForbidden, url.URL{Scheme: "https", Host: "example.com", Path: "/probs/out-of-credit"}).
+					WithDetail("Your current balance is 30, but that costs 50.").
+					WithInstance(url.URL{Path: "/account/12345/msgs/abc"}).
+					WithProperty("balance", 30).
+					WithProperty("accounts", []string{"/account/12345", "/account/67890"}).
+					WithProperty("limits", map[string]any{"daily": 100, "note": "a < b"}).
+					WithProperty("expires", nil)
+				p.Title = "You do not have enough credit."
+
+				// ACT
+				response := p.makeResponse(rq)
+
+				// ASSERT
+				test.That(t, response.ContentType).Equals("application/problem+xml")
+				test.String(t, response.Content).Equals(`<problem xmlns="urn:ietf:rfc:7807">` +
+					`<type>https://example.com/probs/out-of-credit</type>` +
+					`<title>You do not have enough credit.</title>` +
+					`<status>403</status>` +
+					`<detail>Your current balance is 30, but that costs 50.</detail>` +
+					`<instance>/account/12345/msgs/abc</instance>` +
+					`<accounts><i>/account/12345</i><i>/account/67890</i></accounts>` +
+					`<balance>30</balance>` +
+					`<expires></expires>` +
+					`<limits><daily>100</daily><note>a &lt; b</note></limits>` +
+					`</problem>`)
+			},
+		},
+		{scenario: "makeResponse/xml/marshalling error",
+			exec: func(t *testing.T) {
+				// ARRANGE
+				rq := &Request{
+					Accept:         "application/xml",
+					MarshalContent: xml.Marshal,
+					Request:        &http.Request{URL: &url.URL{}},
+				}
+				defer test.Using(&makeErrorResponse, func(*Error, *Request) *Response {
+					return &Response{ContentType: "Error-Content"}
+				})()
+
+				// ACT
+				response := NewProblem(http.StatusBadRequest).
+					WithProperty("invalid", func() {}).
+					makeResponse(rq)
+
+				// ASSERT
+				test.That(t, response).Equals(&Response{ContentType: "Error-Content"})
+			},
+		},
+		{scenario: "makeResponse/xml/invalid element name",
+			exec: func(t *testing.T) {
+				testcases := []struct {
+					scenario string
+					problem  *Problem
+				}{
+					{scenario: "member", problem: NewProblem(http.StatusBadRequest).WithProperty("not valid", 1)},
+					{scenario: "member of object", problem: NewProblem(http.StatusBadRequest).WithProperty("limits", map[string]int{"1st": 1})},
+					{scenario: "prefixed name", problem: NewProblem(http.StatusBadRequest).WithProperty("x:limit", 1)},
+				}
+				for _, tc := range testcases {
+					t.Run(tc.scenario, func(t *testing.T) {
+						// ARRANGE
+						rq := &Request{
+							Accept:         "application/xml",
+							MarshalContent: xml.Marshal,
+							Request:        &http.Request{URL: &url.URL{}},
+						}
+						var logged *InternalError
+						defer test.Using(&LogError, func(e InternalError) { logged = &e })()
+						defer test.Using(&makeErrorResponse, func(*Error, *Request) *Response {
+							return &Response{ContentType: "Error-Content"}
+						})()
+
+						// ACT
+						response := tc.problem.makeResponse(rq)
+
+						// ASSERT
+						test.That(t, response).Equals(&Response{ContentType: "Error-Content"})
+						test.IsTrue(t, logged != nil)
+						test.String(t, logged.Err.Error()).Contains("xml: invalid element name")
+					})
+				}
+			},
+		},
 	}
 	for _, tc := range testcases {
 		t.Run(tc.scenario, func(t *testing.T) {
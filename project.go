@@ -0,0 +1,233 @@
+package restapi
+
+import (
+	"encoding"
+	"encoding/json"
+	"encoding/xml"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// marshaler types are marshalled by their own methods; their fields are not
+// projected
+var (
+	jsonMarshalerType = reflect.TypeFor[json.Marshaler]()
+	textMarshalerType = reflect.TypeFor[encoding.TextMarshaler]()
+	xmlMarshalerType  = reflect.TypeFor[xml.Marshaler]()
+	xmlNameType       = reflect.TypeFor[xml.Name]()
+)
+
+// project returns a value with only the specified fields of a struct value
+// (or a pointer to a struct), of each struct in a slice or array of structs, or
+// of a map with string keys.  Any other value is returned unchanged.
+//
+// The fields of a struct are identified by name, as marshalled: the name in a
+// json tag, or an xml tag, or the name of the field.  The fields of embedded
+// structs are projected as for the fields of the struct embedding them (the
+// fields of an embedded struct referenced by a nil pointer have zero values in
+// the projection).  An XMLName field is always retained.
+//
+// A projected struct is a value of a new struct type, holding the specified
+// fields (with their tags) from the original, so is marshalled by any
+// marshaller in the same way as the original (excluding fields not
+// specified).  Only the top-level fields are projected; the values of those
+// fields are unchanged.
+func project(v any, fields []string) any {
+	if v == nil || len(fields) == 0 {
+		return v
+	}
+	rv := reflect.ValueOf(v)
+	result, ok := projectValue(rv, fields)
+	if !ok {
+		return v
+	}
+	return result.Interface()
+}
+
+// projectable returns true if the fields of values of a specified type may be
+// projected.
+func projectable(t reflect.Type) bool {
+	for t.Kind() == reflect.Pointer {
+		if t.Implements(jsonMarshalerType) || t.Implements(textMarshalerType) || t.Implements(xmlMarshalerType) {
+			return false
+		}
+		t = t.Elem()
+	}
+	if t.Implements(jsonMarshalerType) || t.Implements(textMarshalerType) || t.Implements(xmlMarshalerType) {
+		return false
+	}
+	pt := reflect.PointerTo(t)
+	if pt.Implements(jsonMarshalerType) || pt.Implements(textMarshalerType) || pt.Implements(xmlMarshalerType) {
+		return false
+	}
+	return t.Kind() == reflect.Struct
+}
+
+// projectValue returns a projection of a value.  The returned bool is false
+// if the value cannot be projected.
+func projectValue(v reflect.Value, fields []string) (reflect.Value, bool) {
+	switch v.Kind() {
+	case reflect.Interface:
+		if v.IsNil() {
+			return v, false
+		}
+		return projectValue(v.Elem(), fields)
+
+	case reflect.Pointer:
+		if v.IsNil() || !projectable(v.Type()) {
+			return v, false
+		}
+		return projectValue(v.Elem(), fields)
+
+	case reflect.Struct:
+		if !projectable(v.Type()) {
+			return v, false
+		}
+		p := projectedType(v.Type(), fields)
+		result := reflect.New(p.Type).Elem()
+		copyFields(p, result, v)
+		return result, true
+
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			return v, false
+		}
+		et := v.Type().Elem()
+		if !projectable(et) {
+			return v, false
+		}
+		p := projectedType(indirectType(et), fields)
+		pt := p.Type
+		if et.Kind() == reflect.Pointer {
+			pt = reflect.PointerTo(pt)
+		}
+		result := reflect.MakeSlice(reflect.SliceOf(pt), v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			ev := v.Index(i)
+			if ev.Kind() == reflect.Pointer {
+				if ev.IsNil() {
+					continue
+				}
+				pv := reflect.New(p.Type)
+				copyFields(p, pv.Elem(), ev.Elem())
+				result.Index(i).Set(pv)
+				continue
+			}
+			copyFields(p, result.Index(i), ev)
+		}
+		return result, true
+
+	case reflect.Map:
+		if v.IsNil() || v.Type().Key().Kind() != reflect.String {
+			return v, false
+		}
+		result := reflect.MakeMap(v.Type())
+		for _, f := range fields {
+			k := reflect.ValueOf(f).Convert(v.Type().Key())
+			if ev := v.MapIndex(k); ev.IsValid() {
+				result.SetMapIndex(k, ev)
+			}
+		}
+		return result, true
+	}
+	return v, false
+}
+
+// indirectType returns the type referenced by a (pointer) type.
+func indirectType(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t
+}
+
+// fieldName returns the name of a struct field as marshalled: the name in
+// a json tag, or an xml tag, or the name of the field.  An empty string is
+// returned for a field that is not marshalled.
+func fieldName(f reflect.StructField) string {
+	for _, key := range []string{"json", "xml"} {
+		if tag, ok := f.Tag.Lookup(key); ok {
+			name, _, _ := strings.Cut(tag, ",")
+			if name == "-" {
+				return ""
+			}
+			if key == "xml" {
+				if i := strings.LastIndex(name, ">"); i >= 0 {
+					name = name[i+1:]
+				}
+			}
+			if name != "" {
+				return name
+			}
+		}
+	}
+	return f.Name
+}
+
+// projection is a struct type holding selected fields of a struct type, with
+// the index (in the original type) of each field of the projection.
+type projection struct {
+	reflect.Type
+	index [][]int
+}
+
+// projectedType returns the projection of the specified fields of a struct
+// type.  The fields of embedded structs are promoted to fields of the
+// projection (as they are when marshalled).
+//
+// A projection is an unnamed struct type, which cannot be marshalled as xml
+// without an XMLName field; if the struct type has no XMLName field, the
+// projection is given one naming the element as for the struct type (i.e.
+// with the name of the type).
+func projectedType(t reflect.Type, fields []string) projection {
+	p := projection{}
+	sfs := []reflect.StructField{}
+	hasXMLName := false
+	for _, f := range reflect.VisibleFields(t) {
+		if f.Anonymous {
+			if _, tagged := f.Tag.Lookup("json"); !tagged && indirectType(f.Type).Kind() == reflect.Struct {
+				// the fields of an embedded struct are visited in turn
+				continue
+			}
+		}
+		if !f.IsExported() || !isPromoted(t, f) || (f.Type == xmlNameType && len(f.Index) > 1) {
+			continue
+		}
+		if f.Type == xmlNameType || slices.Contains(fields, fieldName(f)) {
+			sfs = append(sfs, reflect.StructField{Name: f.Name, Type: f.Type, Tag: f.Tag})
+			p.index = append(p.index, f.Index)
+			hasXMLName = hasXMLName || f.Name == "XMLName"
+		}
+	}
+	if !hasXMLName && t.Name() != "" {
+		// the field is not copied from the original (it has no index in the
+		// projection) and is ignored by json
+		sfs = append(sfs, reflect.StructField{
+			Name: "XMLName",
+			Type: xmlNameType,
+			Tag:  reflect.StructTag(`json:"-" xml:"` + t.Name() + `"`),
+		})
+	}
+	p.Type = reflect.StructOf(sfs)
+	return p
+}
+
+// isPromoted returns true if a field is the field with that name in a struct
+// type (i.e. is not a field of an embedded struct hidden by a field at a
+// shallower depth or a field with an ambiguous name).
+func isPromoted(t reflect.Type, f reflect.StructField) bool {
+	sf, ok := t.FieldByName(f.Name)
+	return ok && slices.Equal(sf.Index, f.Index)
+}
+
+// copyFields copies the value of each field of a projection from an original
+// struct.  A field of an embedded struct referenced by a nil pointer is not
+// copied.
+func copyFields(p projection, dst, src reflect.Value) {
+	for i, index := range p.index {
+		if sv, err := src.FieldByIndexErr(index); err == nil {
+			dst.Field(i).Set(sv)
+		}
+	}
+}
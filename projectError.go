@@ -89,6 +89,9 @@ func (m errorProps) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
 // Applications may customise the body of error responses by replacing the implementation
 // of this function and returning a custom struct or other type with marshalling support
 // appropriate to the needs of the application.
+//
+// ProjectError is not called for requests handled by a Server that has its own
+// ProjectError function configured.
 var ProjectError = func(err ErrorInfo) any {
 	// FUTURE: handling of []error, if present in the error (i.e. if implements Unwrap() []error)
 	pe := errorResponse{
@@ -0,0 +1,181 @@
+package restapi
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/blugnu/test"
+)
+
+type projectBase struct {
+	ID      string    `json:"id" xml:"id"`
+	Created time.Time `json:"created" xml:"created"`
+}
+
+type projectItem struct {
+	XMLName xml.Name `json:"-" xml:"item"`
+	projectBase
+	Name   string            `json:"name" xml:"name"`
+	Secret string            `json:"-" xml:"-"`
+	Tags   []string          `json:"tags,omitempty" xml:"tags>tag"`
+	Attrs  map[string]string `json:"attrs"`
+	Plain  int
+	hidden int
+}
+
+func TestProject(t *testing.T) {
+	// ARRANGE
+	created := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	item := projectItem{
+		projectBase: projectBase{ID: "1", Created: created},
+		Name:        "a",
+		Secret:      "s",
+		Tags:        []string{"x"},
+		Plain:       42,
+		hidden:      1,
+	}
+	marshal := func(t *testing.T, v any) string {
+		t.Helper()
+		b, err := json.Marshal(v)
+		test.Error(t, err).IsNil()
+		return string(b)
+	}
+
+	testcases := []struct {
+		scenario string
+		value    any
+		fields   []string
+		result   string
+	}{
+		{scenario: "no fields", value: item, fields: nil, result: `{"id":"1","created":"2024-01-02T03:04:05Z","name":"a","tags":["x"],"attrs":null,"Plain":42}`},
+		{scenario: "struct", value: item, fields: []string{"name", "Plain"}, result: `{"name":"a","Plain":42}`},
+		{scenario: "embedded", value: item, fields: []string{"id", "name"}, result: `{"id":"1","name":"a"}`},
+		{scenario: "pointer", value: &item, fields: []string{"created"}, result: `{"created":"2024-01-02T03:04:05Z"}`},
+		{scenario: "excluded field", value: item, fields: []string{"Secret", "hidden"}, result: `{}`},
+		{scenario: "slice", value: []projectItem{item, item}, fields: []string{"id"}, result: `[{"id":"1"},{"id":"1"}]`},
+		{scenario: "slice of pointers", value: []*projectItem{&item, nil}, fields: []string{"name"}, result: `[{"name":"a"},null]`},
+		{scenario: "array", value: [1]projectItem{item}, fields: []string{"name"}, result: `[{"name":"a"}]`},
+		{scenario: "map", value: map[string]any{"id": 1, "name": "a", "secret": "s"}, fields: []string{"id", "name"}, result: `{"id":1,"name":"a"}`},
+		{scenario: "marshaler", value: created, fields: []string{"id"}, result: `"2024-01-02T03:04:05Z"`},
+		{scenario: "scalar", value: 42, fields: []string{"id"}, result: `42`},
+		{scenario: "slice of scalars", value: []int{1, 2}, fields: []string{"id"}, result: `[1,2]`},
+		{scenario: "nil pointer", value: (*projectItem)(nil), fields: []string{"id"}, result: `null`},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.scenario, func(t *testing.T) {
+			// ACT
+			result := project(tc.value, tc.fields)
+
+			// ASSERT
+			test.That(t, marshal(t, result)).Equals(tc.result)
+		})
+	}
+
+	t.Run("xml", func(t *testing.T) {
+		// ACT
+		result, err := xml.Marshal(project(item, []string{"name", "tags"}))
+
+		// ASSERT
+		test.Error(t, err).IsNil()
+		test.That(t, string(result)).Equals(`<item><name>a</name><tags><tag>x</tag></tags></item>`)
+	})
+}
+
+func TestResultWithFields(t *testing.T) {
+	// ARRANGE
+	serve := func(accept string, result any) string {
+		rec := httptest.NewRecorder()
+		rq := httptest.NewRequest(http.MethodGet, "/items", nil)
+		rq.Header.Set("Accept", accept)
+		HandlerFunc(func(context.Context, *http.Request) any { return result })(rec, rq)
+		return rec.Body.String()
+	}
+	items := []projectItem{{projectBase: projectBase{ID: "1"}, Name: "a"}}
+	type plainItem struct {
+		ID   int    `json:"id" xml:"id"`
+		Name string `json:"name" xml:"name"`
+		Note string `json:"note" xml:"note"`
+	}
+	plain := plainItem{ID: 1, Name: "a", Note: "n"}
+
+	testcases := []struct {
+		scenario string
+		accept   string
+		result   any
+		body     string
+	}{
+		{scenario: "value/json", accept: "application/json",
+			result: OK().WithValue(items[0]).WithFields("id"),
+			body:   `{"id":"1"}`,
+		},
+		{scenario: "value/xml", accept: "application/xml",
+			result: OK().WithValue(items[0]).WithFields("name"),
+			body:   `<item><name>a</name></item>`,
+		},
+		{scenario: "value/xml/no XMLName", accept: "application/xml",
+			result: OK().WithValue(plain).WithFields("id", "name"),
+			body:   `<plainItem><id>1</id><name>a</name></plainItem>`,
+		},
+		{scenario: "value/json/no XMLName", accept: "application/json",
+			result: OK().WithValue(&plain).WithFields("id", "name"),
+			body:   `{"id":1,"name":"a"}`,
+		},
+		{scenario: "slice/xml/no XMLName", accept: "application/xml",
+			result: OK().WithValue([]*plainItem{&plain, &plain}).WithFields("name"),
+			body:   `<plainItem><name>a</name></plainItem><plainItem><name>a</name></plainItem>`,
+		},
+		{scenario: "page", accept: "application/json",
+			result: OK().WithValue(OffsetPage(items, 0, 10, 1)).WithFields("name"),
+			body:   `{"items":[{"name":"a"}],"limit":10,"offset":0,"total":1}`,
+		},
+		{scenario: "page/xml", accept: "application/xml",
+			result: OK().WithValue(OffsetPage(items, 0, 10, 1)).WithFields("id"),
+			body:   `<page><items><item><id>1</id></item></items><limit>10</limit><offset>0</offset><total>1</total></page>`,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.scenario, func(t *testing.T) {
+			// ACT
+			body := serve(tc.accept, tc.result)
+
+			// ASSERT
+			test.That(t, body).Equals(tc.body)
+		})
+	}
+}
+
+func TestProjectHiddenFields(t *testing.T) {
+	// ARRANGE
+	type inner struct {
+		ID   string `json:"id"`
+		Name string `json:"innerName"`
+	}
+	type outer struct {
+		*inner
+		ID string `json:"id"`
+	}
+
+	testcases := []struct {
+		scenario string
+		value    outer
+		result   string
+	}{
+		{scenario: "shallower field", value: outer{inner: &inner{ID: "inner", Name: "n"}, ID: "outer"}, result: `{"innerName":"n","id":"outer"}`},
+		{scenario: "nil embedded pointer", value: outer{ID: "outer"}, result: `{"innerName":"","id":"outer"}`},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.scenario, func(t *testing.T) {
+			// ACT
+			result, err := json.Marshal(project(tc.value, []string{"id", "innerName"}))
+
+			// ASSERT
+			test.Error(t, err).IsNil()
+			test.That(t, string(result)).Equals(tc.result)
+		})
+	}
+}
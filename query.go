@@ -0,0 +1,178 @@
+package restapi
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// FilterOperator identifies the comparison applied by a FilterTerm.
+type FilterOperator string
+
+// operators supported in filter query parameters
+const (
+	FilterEq FilterOperator = "eq"
+	FilterNe FilterOperator = "ne"
+	FilterLt FilterOperator = "lt"
+	FilterLe FilterOperator = "le"
+	FilterGt FilterOperator = "gt"
+	FilterGe FilterOperator = "ge"
+)
+
+// SortTerm is a field by which the items in a collection are to be sorted.
+type SortTerm struct {
+	Field      string
+	Descending bool
+}
+
+// FilterTerm is a condition that the items in a collection are to satisfy.
+// Where more than one value is specified, an item satisfies the condition if
+// the comparison is true for any of the values.
+type FilterTerm struct {
+	Field    string
+	Operator FilterOperator
+	Values   []string
+}
+
+// Query holds the sort, filter and fields query parameters of a request for a
+// collection (see: ParseQuery).
+type Query struct {
+	Sort   []SortTerm
+	Filter []FilterTerm
+	Fields []string
+}
+
+// QueryAllow specifies the fields that may be used in the sort, filter and
+// fields query parameters of a request.  A query parameter that is not
+// allowed any fields is not supported.
+type QueryAllow struct {
+	Sort   []string
+	Filter []string
+	Fields []string
+}
+
+// ParseQuery returns the sort, filter and fields query parameters of a request,
+// validated against the fields allowed by an endpoint.  The parameters are:
+//
+//	sort=-created,name           // sort by created (descending) then name
+//	filter[status]=open,closed   // status equals "open" or "closed"
+//	filter[created][gt]=2024-01  // created is greater than "2024-01"
+//	fields=id,name               // the fields to be included in the response
+//
+// The operators supported in a filter are eq (the default), ne, lt, le, gt and
+// ge.  Filter values are not interpreted; they are provided as strings in the
+// Values of each FilterTerm, with filters sorted by field and operator.
+//
+// If any parameter identifies a field that is not allowed, has an unsupported
+// operator or is malformed, an Error with a status of 400 Bad Request is
+// returned, wrapping ErrInvalidParameter, with a "parameters" property listing
+// each invalid parameter (as ParameterErrors).
+//
+// The Fields of the Query may be passed to Result.WithFields to include only
+// those fields in the response.  A Server with SparseFieldsets enabled does
+// not prune a Result with fields specified, so the fields in the response are
+// those validated against the QueryAllow.Fields of the endpoint.
+//
+// # example
+//
+//	func GetItems(ctx context.Context, rq *http.Request) any {
+//	    q, err := restapi.ParseQuery(rq, restapi.QueryAllow{
+//	        Sort:   []string{"created", "name"},
+//	        Filter: []string{"status"},
+//	        Fields: []string{"id", "name", "status", "created"},
+//	    })
+//	    if err != nil {
+//	        return err
+//	    }
+//	    items, err := store.List(ctx, q.Sort, q.Filter)
+//	    if err != nil {
+//	        return err
+//	    }
+//	    return restapi.OK().WithValue(items).WithFields(q.Fields...)
+//	}
+func ParseQuery(rq *http.Request, allow QueryAllow) (*Query, error) {
+	query := rq.URL.Query()
+	result := &Query{}
+
+	var errs []ParameterError
+	invalid := func(name, reason string, args ...any) {
+		errs = append(errs, ParameterError{In: "query", Name: name, Reason: fmt.Sprintf(reason, args...)})
+	}
+	list := func(name string) []string {
+		var items []string
+		for _, v := range query[name] {
+			for _, s := range strings.Split(v, ",") {
+				if s = strings.TrimSpace(s); s != "" {
+					items = append(items, s)
+				}
+			}
+		}
+		return items
+	}
+
+	for _, s := range list("sort") {
+		term := SortTerm{Field: strings.TrimPrefix(s, "-"), Descending: strings.HasPrefix(s, "-")}
+		if !slices.Contains(allow.Sort, term.Field) {
+			invalid("sort", "unknown field: %s", term.Field)
+			continue
+		}
+		result.Sort = append(result.Sort, term)
+	}
+
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		if strings.HasPrefix(k, "filter[") {
+			keys = append(keys, k)
+		}
+	}
+	slices.Sort(keys)
+	for _, k := range keys {
+		field, op, ok := parseFilterKey(k)
+		switch {
+		case !ok:
+			invalid(k, "malformed filter: expected filter[field] or filter[field][operator]")
+			continue
+		case !slices.Contains(allow.Filter, field):
+			invalid(k, "unknown field: %s", field)
+			continue
+		case !slices.Contains([]FilterOperator{FilterEq, FilterNe, FilterLt, FilterLe, FilterGt, FilterGe}, op):
+			invalid(k, "unknown operator: %s", op)
+			continue
+		}
+		result.Filter = append(result.Filter, FilterTerm{Field: field, Operator: op, Values: list(k)})
+	}
+
+	for _, f := range list("fields") {
+		if !slices.Contains(allow.Fields, f) {
+			invalid("fields", "unknown field: %s", f)
+			continue
+		}
+		result.Fields = append(result.Fields, f)
+	}
+
+	if len(errs) > 0 {
+		return nil, BadRequest(ErrInvalidParameter, rq).
+			WithProperty("parameters", errs)
+	}
+	return result, nil
+}
+
+// parseFilterKey parses a filter query parameter key of the form
+// filter[field] or filter[field][operator], returning the field and operator
+// (FilterEq if not specified).  The returned bool is false if the key is
+// malformed.
+func parseFilterKey(key string) (string, FilterOperator, bool) {
+	s := strings.TrimPrefix(key, "filter[")
+	field, rest, ok := strings.Cut(s, "]")
+	if !ok || field == "" {
+		return "", "", false
+	}
+	if rest == "" {
+		return field, FilterEq, true
+	}
+	if !strings.HasPrefix(rest, "[") || !strings.HasSuffix(rest, "]") || len(rest) < 3 {
+		return "", "", false
+	}
+	return field, FilterOperator(rest[1 : len(rest)-1]), true
+}
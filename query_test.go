@@ -0,0 +1,101 @@
+package restapi
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/blugnu/test"
+)
+
+func TestParseQuery(t *testing.T) {
+	// ARRANGE
+	allow := QueryAllow{
+		Sort:   []string{"created", "name"},
+		Filter: []string{"status", "created"},
+		Fields: []string{"id", "name"},
+	}
+	testcases := []struct {
+		query  string
+		result *Query
+		params []ParameterError
+	}{
+		{query: "", result: &Query{}},
+		{query: "sort=-created,name", result: &Query{
+			Sort: []SortTerm{{Field: "created", Descending: true}, {Field: "name"}},
+		}},
+		{query: "sort=name&sort=-created", result: &Query{
+			Sort: []SortTerm{{Field: "name"}, {Field: "created", Descending: true}},
+		}},
+		{query: "filter[status]=open,closed&filter[created][gt]=2024-01", result: &Query{
+			Filter: []FilterTerm{
+				{Field: "created", Operator: FilterGt, Values: []string{"2024-01"}},
+				{Field: "status", Operator: FilterEq, Values: []string{"open", "closed"}},
+			},
+		}},
+		{query: "fields=id,%20name", result: &Query{Fields: []string{"id", "name"}}},
+		{query: "sort=size&fields=id,secret", params: []ParameterError{
+			{In: "query", Name: "sort", Reason: "unknown field: size"},
+			{In: "query", Name: "fields", Reason: "unknown field: secret"},
+		}},
+		{query: "filter[owner]=me&filter[status][like]=o&filter[status", params: []ParameterError{
+			{In: "query", Name: "filter[owner]", Reason: "unknown field: owner"},
+			{In: "query", Name: "filter[status", Reason: "malformed filter: expected filter[field] or filter[field][operator]"},
+			{In: "query", Name: "filter[status][like]", Reason: "unknown operator: like"},
+		}},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.query, func(t *testing.T) {
+			// ARRANGE
+			rq := httptest.NewRequest(http.MethodGet, "/items", nil)
+			rq.URL.RawQuery = tc.query
+
+			// ACT
+			result, err := ParseQuery(rq, allow)
+
+			// ASSERT
+			if tc.params == nil {
+				test.Error(t, err).IsNil()
+				test.That(t, result).Equals(tc.result)
+				return
+			}
+			test.That(t, result).IsNil()
+			test.Error(t, err).Is(ErrInvalidParameter)
+
+			var apierr *Error
+			test.IsTrue(t, errors.As(err, &apierr))
+			test.That(t, apierr.statusCode).Equals(http.StatusBadRequest)
+			test.That(t, apierr.properties["parameters"]).Equals(any(tc.params))
+		})
+	}
+}
+
+func TestParseFilterKey(t *testing.T) {
+	// ARRANGE
+	testcases := []struct {
+		key      string
+		field    string
+		operator FilterOperator
+		ok       bool
+	}{
+		{key: "filter[a]", field: "a", operator: FilterEq, ok: true},
+		{key: "filter[a][ne]", field: "a", operator: FilterNe, ok: true},
+		{key: "filter[]", ok: false},
+		{key: "filter[a", ok: false},
+		{key: "filter[a]x", ok: false},
+		{key: "filter[a][]", ok: false},
+	}
+	for _, tc := range testcases {
+		t.Run(url.QueryEscape(tc.key), func(t *testing.T) {
+			// ACT
+			field, op, ok := parseFilterKey(tc.key)
+
+			// ASSERT
+			test.That(t, field).Equals(tc.field)
+			test.That(t, op).Equals(tc.operator)
+			test.That(t, ok).Equals(tc.ok)
+		})
+	}
+}
@@ -0,0 +1,124 @@
+package restapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"path"
+	"strconv"
+	"time"
+)
+
+// function variables to facilitate testing
+var (
+	ioCopy = io.Copy
+)
+
+// readerContent is Result content holding a reader from which the response
+// body is streamed.
+type readerContent struct {
+	r       io.Reader
+	name    string
+	modTime time.Time
+	size    int64 // -1 if not known
+}
+
+// newReaderContent returns readerContent for a reader.  If the reader is an
+// fs.File the name, modification time and size of the file are obtained from
+// the file.
+func newReaderContent(r io.Reader) *readerContent {
+	rc := &readerContent{r: r, size: -1}
+	if f, ok := r.(fs.File); ok {
+		if fi, err := f.Stat(); err == nil && fi.Mode().IsRegular() {
+			rc.name = fi.Name()
+			rc.modTime = fi.ModTime()
+			rc.size = fi.Size()
+		}
+	}
+	return rc
+}
+
+// makeStreamResponse implements the streamer interface for readerContent.
+//
+// If the reader is an io.ReadSeeker and the response status is 200 OK, the
+// response is served using http.ServeContent, supporting Range requests.
+// Otherwise the content is copied from the reader to the response.
+func (rc *readerContent) makeStreamResponse(rq *Request, response *Response) *Response {
+	if rs, ok := rc.r.(io.ReadSeeker); ok && response.StatusCode == http.StatusOK {
+		response.identity = true
+		response.serve = func(rw http.ResponseWriter, rq *Request) {
+			http.ServeContent(rw, rq.Request, rc.name, coalesce(response.lastModified, rc.modTime), rs)
+		}
+		return response
+	}
+
+	response.serve = func(rw http.ResponseWriter, rq *Request) {
+		if response.ContentType == "" {
+			rw.Header().Set("Content-Type", "application/octet-stream")
+		}
+		if rc.size >= 0 {
+			rw.Header().Set("Content-Length", strconv.FormatInt(rc.size, 10))
+		}
+		rw.WriteHeader(response.StatusCode)
+		if rq.Method == http.MethodHead {
+			return
+		}
+
+		if _, err := ioCopy(rw, rc.r); err != nil &&
+			!errors.Is(err, context.Canceled) && !errors.Is(rq.Context().Err(), context.Canceled) {
+			rq.logError(InternalError{
+				Err:     fmt.Errorf("%w: %w", ErrErrorReadingContent, err),
+				Message: "error writing response",
+				Help:    "the response was incomplete",
+				Request: rq.Request,
+			})
+		}
+	}
+	return response
+}
+
+// closeResult closes the reader of a result (or a Result) if the reader
+// implements io.Closer.
+func closeResult(result any) {
+	if r, ok := result.(*Result); ok {
+		if rc, ok := r.content.(*readerContent); ok {
+			result = rc.r
+		}
+	}
+	if c, ok := result.(io.Closer); ok {
+		_ = c.Close()
+	}
+}
+
+// WithReader sets the content of the Result to be streamed from a reader,
+// with a specified content type.  The reader will replace any content and
+// content type that may have been set on the Result previously.
+//
+// If the content type is empty, the content type is determined from the name
+// of the file (if the reader is an fs.File) or, for an io.ReadSeeker, from the
+// content itself; otherwise "application/octet-stream" is used.
+//
+// If the reader is an io.ReadSeeker and the status of the Result is 200 OK,
+// the response is served using http.ServeContent, supporting Range and
+// If-Range requests with 206 Partial Content responses (including
+// multipart/byteranges responses for multiple ranges).  The Content-Length of
+// the response is set from the size of the content.
+//
+// If the reader is an fs.File, the Content-Length and Last-Modified time of
+// the response are obtained from the file (unless set using WithLastModified).
+//
+// If the reader implements io.Closer it is closed after the response has been
+// written.
+func (r *Result) WithReader(contentType string, rd io.Reader) *Result {
+	rc := newReaderContent(rd)
+	if contentType == "" && rc.name != "" {
+		contentType = mime.TypeByExtension(path.Ext(rc.name))
+	}
+	r.content = rc
+	r.contentType = &contentType
+	return r
+}
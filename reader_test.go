@@ -0,0 +1,234 @@
+package restapi
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/blugnu/test"
+)
+
+// closeTracker wraps a reader (hiding any other interfaces it implements) and
+// records whether it is closed.
+type closeTracker struct {
+	io.Reader
+	isClosed bool
+}
+
+func (c *closeTracker) Close() error {
+	c.isClosed = true
+	return nil
+}
+
+// unseekableFile wraps an fs.File, hiding any io.Seeker implementation.
+type unseekableFile struct {
+	f fs.File
+}
+
+func (u unseekableFile) Read(b []byte) (int, error) { return u.f.Read(b) }
+func (u unseekableFile) Stat() (fs.FileInfo, error) { return u.f.Stat() }
+func (u unseekableFile) Close() error               { return u.f.Close() }
+
+func TestReader(t *testing.T) {
+	// ARRANGE
+	modified := time.Date(2010, 9, 8, 7, 6, 5, 0, time.UTC)
+	fsys := fstest.MapFS{
+		"report.csv": {Data: []byte("a,b,c\n1,2,3\n"), ModTime: modified},
+	}
+	serve := func(rq *http.Request, result any) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		HandlerFunc(func(context.Context, *http.Request) any { return result })(rec, rq)
+		return rec
+	}
+
+	testcases := []struct {
+		scenario string
+		exec     func(t *testing.T)
+	}{
+		{scenario: "io.Reader",
+			exec: func(t *testing.T) {
+				// ARRANGE
+				rq := httptest.NewRequest(http.MethodGet, "/", nil)
+				r := &closeTracker{Reader: strings.NewReader("content")}
+
+				// ACT
+				rec := serve(rq, r)
+
+				// ASSERT
+				test.That(t, rec.Code).Equals(http.StatusOK)
+				test.That(t, rec.Header().Get("Content-Type")).Equals("application/octet-stream")
+				test.That(t, rec.Header().Get("Content-Length")).Equals("")
+				test.That(t, rec.Body.String()).Equals("content")
+				test.IsTrue(t, r.isClosed)
+			},
+		},
+		{scenario: "io.Reader/HEAD",
+			exec: func(t *testing.T) {
+				// ARRANGE
+				rq := httptest.NewRequest(http.MethodHead, "/", nil)
+
+				// ACT
+				rec := serve(rq, &closeTracker{Reader: strings.NewReader("content")})
+
+				// ASSERT
+				test.That(t, rec.Code).Equals(http.StatusOK)
+				test.That(t, rec.Body.Len()).Equals(0)
+			},
+		},
+		{scenario: "io.Reader/error",
+			exec: func(t *testing.T) {
+				// ARRANGE
+				rq := httptest.NewRequest(http.MethodGet, "/", nil)
+				rderr := errors.New("read error")
+				defer test.Using(&ioCopy, func(io.Writer, io.Reader) (int64, error) { return 0, rderr })()
+				var logged *InternalError
+				defer test.Using(&LogError, func(e InternalError) { logged = &e })()
+
+				// ACT
+				_ = serve(rq, &closeTracker{Reader: strings.NewReader("content")})
+
+				// ASSERT
+				test.IsTrue(t, logged != nil)
+				test.Error(t, logged.Err).Is(ErrErrorReadingContent)
+				test.Error(t, logged.Err).Is(rderr)
+			},
+		},
+		{scenario: "io.ReadSeeker",
+			exec: func(t *testing.T) {
+				// ARRANGE
+				rq := httptest.NewRequest(http.MethodGet, "/", nil)
+
+				// ACT
+				rec := serve(rq, OK().WithReader("text/plain", strings.NewReader("0123456789")))
+
+				// ASSERT
+				test.That(t, rec.Code).Equals(http.StatusOK)
+				test.That(t, rec.Header().Get("Content-Type")).Equals("text/plain")
+				test.That(t, rec.Header().Get("Content-Length")).Equals("10")
+				test.That(t, rec.Header().Get("Accept-Ranges")).Equals("bytes")
+				test.That(t, rec.Body.String()).Equals("0123456789")
+			},
+		},
+		{scenario: "io.ReadSeeker/Range",
+			exec: func(t *testing.T) {
+				// ARRANGE
+				rq := httptest.NewRequest(http.MethodGet, "/", nil)
+				rq.Header.Set("Range", "bytes=2-4")
+
+				// ACT
+				rec := serve(rq, OK().WithReader("text/plain", strings.NewReader("0123456789")))
+
+				// ASSERT
+				test.That(t, rec.Code).Equals(http.StatusPartialContent)
+				test.That(t, rec.Header().Get("Content-Range")).Equals("bytes 2-4/10")
+				test.That(t, rec.Body.String()).Equals("234")
+			},
+		},
+		{scenario: "io.ReadSeeker/multiple ranges",
+			exec: func(t *testing.T) {
+				// ARRANGE
+				rq := httptest.NewRequest(http.MethodGet, "/", nil)
+				rq.Header.Set("Range", "bytes=0-1,8-9")
+
+				// ACT
+				rec := serve(rq, OK().WithReader("text/plain", strings.NewReader("0123456789")))
+
+				// ASSERT
+				test.That(t, rec.Code).Equals(http.StatusPartialContent)
+				test.IsTrue(t, strings.HasPrefix(rec.Header().Get("Content-Type"), "multipart/byteranges; boundary="))
+				test.String(t, rec.Body.String()).Contains("Content-Range: bytes 0-1/10")
+				test.String(t, rec.Body.String()).Contains("Content-Range: bytes 8-9/10")
+			},
+		},
+		{scenario: "io.ReadSeeker/If-Range/matched",
+			exec: func(t *testing.T) {
+				// ARRANGE
+				rq := httptest.NewRequest(http.MethodGet, "/", nil)
+				rq.Header.Set("Range", "bytes=2-4")
+				rq.Header.Set("If-Range", `"v1"`)
+
+				// ACT
+				rec := serve(rq, OK().WithReader("text/plain", strings.NewReader("0123456789")).WithETag("v1"))
+
+				// ASSERT
+				test.That(t, rec.Code).Equals(http.StatusPartialContent)
+				test.That(t, rec.Body.String()).Equals("234")
+			},
+		},
+		{scenario: "io.ReadSeeker/If-Range/not matched",
+			exec: func(t *testing.T) {
+				// ARRANGE
+				rq := httptest.NewRequest(http.MethodGet, "/", nil)
+				rq.Header.Set("Range", "bytes=2-4")
+				rq.Header.Set("If-Range", `"v0"`)
+
+				// ACT
+				rec := serve(rq, OK().WithReader("text/plain", strings.NewReader("0123456789")).WithETag("v1"))
+
+				// ASSERT
+				test.That(t, rec.Code).Equals(http.StatusOK)
+				test.That(t, rec.Body.String()).Equals("0123456789")
+			},
+		},
+		{scenario: "io.ReadSeeker/not 200 OK",
+			exec: func(t *testing.T) {
+				// ARRANGE
+				rq := httptest.NewRequest(http.MethodGet, "/", nil)
+				rq.Header.Set("Range", "bytes=2-4")
+
+				// ACT
+				rec := serve(rq, Created().WithReader("text/plain", strings.NewReader("0123456789")))
+
+				// ASSERT
+				test.That(t, rec.Code).Equals(http.StatusCreated)
+				test.That(t, rec.Header().Get("Content-Type")).Equals("text/plain")
+				test.That(t, rec.Body.String()).Equals("0123456789")
+			},
+		},
+		{scenario: "fs.File",
+			exec: func(t *testing.T) {
+				// ARRANGE
+				rq := httptest.NewRequest(http.MethodGet, "/", nil)
+				f, _ := fsys.Open("report.csv")
+
+				// ACT
+				rec := serve(rq, f)
+
+				// ASSERT
+				test.That(t, rec.Code).Equals(http.StatusOK)
+				test.That(t, rec.Header().Get("Content-Type")).Equals("text/csv; charset=utf-8")
+				test.That(t, rec.Header().Get("Content-Length")).Equals("12")
+				test.That(t, rec.Header().Get("Last-Modified")).Equals("Wed, 08 Sep 2010 07:06:05 GMT")
+				test.That(t, rec.Body.String()).Equals("a,b,c\n1,2,3\n")
+			},
+		},
+		{scenario: "fs.File/not seekable",
+			exec: func(t *testing.T) {
+				// ARRANGE
+				rq := httptest.NewRequest(http.MethodGet, "/", nil)
+				f, _ := fsys.Open("report.csv")
+
+				// ACT
+				rec := serve(rq, unseekableFile{f})
+
+				// ASSERT
+				test.That(t, rec.Code).Equals(http.StatusOK)
+				test.That(t, rec.Header().Get("Content-Type")).Equals("text/csv; charset=utf-8")
+				test.That(t, rec.Header().Get("Content-Length")).Equals("12")
+				test.That(t, rec.Body.String()).Equals("a,b,c\n1,2,3\n")
+			},
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.scenario, func(t *testing.T) {
+			tc.exec(t)
+		})
+	}
+}
@@ -1,36 +1,50 @@
 package restapi
 
 import (
+	"encoding/json"
+	"errors"
+	"io"
 	"net/http"
 )
 
 var (
-	// newRequest creates a new Request from an http.Request.  The Accept
-	// header is used to determine the content type of the response and the
-	// appropriate content marshalling function.
+	// newRequest creates a new Request for a Server from an http.Request.
+	// The Accept header is used to determine the content type of the
+	// response and the appropriate content marshalling function.
 	//
-	// If the Accept header is empty or "*/*", the default content type is
-	// "application/json".
+	// The Accept header is negotiated against the content types registered
+	// with the Server in accordance with RFC 9110, section 12.5.1, respecting
+	// q-values, wildcards and media type parameters.  If the Accept header
+	// is empty (or "*/*"), the default content type is the first registered
+	// content type ("application/json", unless the registry has been modified).
 	//
-	// If the Accept header is not recognized or not supported by any
-	// content marshalling function, an ErrInvalidAcceptHeader error is
-	// returned.
+	// If none of the supported content types is acceptable, an
+	// ErrInvalidAcceptHeader error is returned unless the Accept header
+	// accepts a server-sent event stream (text/event-stream), in which case
+	// the default content type is used (to marshal event data) and the
+	// request is marked as accepting only an event stream; a 406 Not
+	// Acceptable response is returned if the endpoint does not return an
+	// EventStream (or EventChannel).
 	//
 	// newRequest is a function variable to facilitate testing.
-	newRequest = func(rq *http.Request) (*Request, error) {
-		acc := rq.Header.Get("Accept")
-		if acc == "" || acc == "*/*" {
-			acc = "application/json"
+	newRequest = func(s *Server, rq *http.Request) (*Request, error) {
+		accept := rq.Header.Get("Accept")
+		acc, mc, ok := s.marshallers().negotiate(accept)
+		eventStreamOnly := false
+		if !ok && acceptsEventStream(accept) {
+			acc, mc, ok = s.marshallers().negotiate("")
+			eventStreamOnly = true
 		}
-		if mc, ok := marshal[acc]; ok {
-			nrq := &Request{
-				Request:        rq,
-				Accept:         acc,
-				MarshalContent: mc,
-			}
-			return nrq, nil
+		if !ok {
+			return nil, ErrInvalidAcceptHeader
 		}
-		return nil, ErrInvalidAcceptHeader
+		return &Request{
+			Request:         rq,
+			Accept:          acc,
+			MarshalContent:  mc,
+			server:          s,
+			eventStreamOnly: eventStreamOnly,
+		}, nil
 	}
 )
 
@@ -38,16 +52,49 @@ type Request struct {
 	*http.Request
 	Accept         string
 	MarshalContent func(any) ([]byte, error)
+	server         *Server
+
+	// eventStreamOnly indicates that the request accepts a server-sent event
+	// stream but none of the content types supported by the server
+	eventStreamOnly bool
+}
+
+// logError logs an error using the configuration of the Server handling the
+// request.
+func (rq *Request) logError(err InternalError) {
+	rq.server.logError(err)
+}
+
+// projectError projects an error using the configuration of the Server handling
+// the request.
+func (rq *Request) projectError(err ErrorInfo) any {
+	return rq.server.projectError(err)
+}
+
+// marshalContent marshals a value using the marshalling function negotiated
+// for the request, returning the content type of the marshalled content.
+//
+// A value that is not a hypermedia document (the marshalling function returns
+// ErrNotHypermedia, e.g. if a hypermedia content type is negotiated for a value
+// other than a Resource) is marshalled as application/json.
+func (rq *Request) marshalContent(v any) (string, []byte, error) {
+	content, err := rq.MarshalContent(v)
+	if errors.Is(err, ErrNotHypermedia) {
+		content, err = json.Marshal(v)
+		return "application/json", content, err
+	}
+	return rq.Accept, content, err
 }
 
 // makeResponse derives an apppropriate response for a result based on the
 // result type as follows:
 //
 //   - *restapi.Error        // an error response as defined by the Error struct
-//   - *restapi.Problem      // an error response as defined by RFC 7807
+//   - *restapi.Problem      // an error response as defined by RFC 9457
 //   - *restapi.Result       // a successful response as defined by the Result struct
 //   - error                 // an internal server error response
 //   - []byte                // a byte slice response (Content-Type: application/octet-stream)
+//   - io.Reader             // a response streamed from the reader (see: Result.WithReader)
 //   - int                   // a status code response
 //   - <any other type>	     // a successful response with the value marshalled
 //     // according to the request Accept header
@@ -87,6 +134,11 @@ func (rq *Request) makeResponse(result any) *Response {
 			Content:     result,
 		}
 
+	case io.Reader:
+		return OK().
+			WithReader("", result).
+			makeResponse(rq)
+
 	case int:
 		return &Response{StatusCode: result}
 
@@ -21,7 +21,7 @@ func TestRequest(t *testing.T) {
 				rq := &http.Request{Header: http.Header{}}
 
 				// ACT
-				result, err := newRequest(rq)
+				result, err := newRequest(Default, rq)
 
 				// ASSERT
 				test.Error(t, err).IsNil()
@@ -30,13 +30,41 @@ func TestRequest(t *testing.T) {
 				test.That(t, result.MarshalContent).IsNotNil()
 			},
 		},
+		{scenario: "newRequest/negotiated Accept header",
+			exec: func(t *testing.T) {
+				// ARRANGE
+				rq := &http.Request{Header: http.Header{"Accept": []string{"text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8"}}}
+
+				// ACT
+				result, err := newRequest(Default, rq)
+
+				// ASSERT
+				test.Error(t, err).IsNil()
+				test.That(t, result.Accept).Equals("application/xml")
+				test.That(t, result.MarshalContent).IsNotNil()
+			},
+		},
+		{scenario: "newRequest/event stream",
+			exec: func(t *testing.T) {
+				// ARRANGE
+				rq := &http.Request{Header: http.Header{"Accept": []string{"text/event-stream"}}}
+
+				// ACT
+				result, err := newRequest(Default, rq)
+
+				// ASSERT
+				test.Error(t, err).IsNil()
+				test.That(t, result.Accept).Equals("application/json")
+				test.IsTrue(t, result.eventStreamOnly)
+			},
+		},
 		{scenario: "newRequest/unsupported Accept header",
 			exec: func(t *testing.T) {
 				// ARRANGE
 				rq := &http.Request{Header: http.Header{"Accept": []string{"text/plain"}}}
 
 				// ACT
-				result, err := newRequest(rq)
+				result, err := newRequest(Default, rq)
 
 				// ASSERT
 				test.That(t, result).IsNil()
@@ -2,7 +2,12 @@ package restapi
 
 import (
 	"fmt"
+	"mime"
 	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 )
 
 type Response struct {
@@ -10,21 +15,145 @@ type Response struct {
 	ContentType string
 	Content     []byte
 	headers
+	etag         string
+	lastModified time.Time
+
+	// serve, if set, writes the status and body of the response in place of
+	// the StatusCode and Content (e.g. for a streamed response)
+	serve func(http.ResponseWriter, *Request)
+
+	// identity indicates that the content must not be encoded (compressed)
+	identity bool
+
+	// negotiated indicates that the content of the response was determined
+	// by the request Accept header
+	negotiated bool
+}
+
+// contentTypeWithCharset returns a content type with a charset=utf-8 parameter
+// for a text type that does not specify a charset; all marshalled content is
+// utf-8 encoded.  Any other content type is returned unchanged.
+func contentTypeWithCharset(contentType string) string {
+	mt, params, err := mime.ParseMediaType(contentType)
+	if err != nil || !strings.HasPrefix(mt, "text/") {
+		return contentType
+	}
+	if _, ok := params["charset"]; ok {
+		return contentType
+	}
+	return contentType + "; charset=utf-8"
+}
+
+// addVary adds fields to the Vary header, merging them with any fields
+// already present (e.g. set using WithHeader) to form a single header value.
+// A Vary header of "*" is left unchanged.
+func addVary(h http.Header, fields ...string) {
+	vary := []string{}
+	for _, v := range h.Values("Vary") {
+		for _, f := range strings.Split(v, ",") {
+			if f = strings.TrimSpace(f); f == "*" {
+				return
+			} else if f != "" {
+				vary = append(vary, f)
+			}
+		}
+	}
+
+	for _, f := range fields {
+		if !slices.ContainsFunc(vary, func(v string) bool { return strings.EqualFold(v, f) }) {
+			vary = append(vary, f)
+		}
+	}
+	if len(vary) > 0 {
+		h.Set("Vary", strings.Join(vary, ", "))
+	}
+}
+
+// bodyAllowed returns true if a response with a specified status code may
+// have content.
+func bodyAllowed(statusCode int) bool {
+	return statusCode >= 200 && statusCode != http.StatusNoContent && statusCode != http.StatusNotModified
 }
 
 // writeResponse writes a response to the http.ResponseWriter.
-func (r Response) write(rw http.ResponseWriter, rq *http.Request) {
-	rw.Header().Add("Content-Type", r.ContentType) //NOSONAR: Content-Type const
+//
+// A Vary header is added identifying the request headers that determine the
+// response (Accept, for negotiated content, and Accept-Encoding, for content
+// that may be compressed), merged with any Vary header set on the response.
+// Buffered content is written with a Content-Length header.  The text content
+// type of negotiated (marshalled) content is given a charset=utf-8 parameter
+// if no charset is specified; the content type of any other content (e.g. from
+// a reader or file, of unknown encoding) is written as specified.
+func (r Response) write(rw http.ResponseWriter, rq *Request) {
+	switch {
+	case r.ContentType == "":
+		// NO-OP
+	case r.negotiated:
+		rw.Header().Add("Content-Type", contentTypeWithCharset(r.ContentType)) //NOSONAR: Content-Type const
+	default:
+		rw.Header().Add("Content-Type", r.ContentType) //NOSONAR: Content-Type const
+	}
+	if r.etag != "" {
+		rw.Header().Set("ETag", r.etag)
+	}
+	if !r.lastModified.IsZero() {
+		rw.Header().Set("Last-Modified", r.lastModified.Format(http.TimeFormat))
+	}
 	for k, v := range r.headers {
-		rw.Header()[k] = []string{fmt.Sprintf("%v", v)}
+		if values := headerValues(v); len(values) > 0 {
+			rw.Header()[k] = values
+		}
+	}
+
+	vary := []string{}
+	if r.negotiated {
+		vary = append(vary, "Accept")
+	}
+
+	content := r.Content
+	if rq != nil && rq.Request != nil {
+		enc, varies := rq.server.compression().encoding(rq.Request, &r, rw.Header())
+		if varies {
+			vary = append(vary, "Accept-Encoding")
+		}
+		if enc != nil {
+			if r.serve != nil {
+				setContentEncoding(rw.Header(), enc.Name)
+				ew := &encodingWriter{ResponseWriter: rw, enc: enc}
+				defer ew.Close()
+				rw = ew
+			} else if encoded, err := enc.encode(content); err == nil {
+				setContentEncoding(rw.Header(), enc.Name)
+				content = encoded
+			} else {
+				rq.logError(InternalError{
+					Err:     err,
+					Message: "error encoding response",
+					Help:    fmt.Sprintf("the response was sent without %s encoding", enc.Name),
+					Request: rq.Request,
+				})
+			}
+		}
+	}
+
+	addVary(rw.Header(), vary...)
+
+	if r.serve != nil {
+		r.serve(rw, rq)
+		return
+	}
+	if !bodyAllowed(r.StatusCode) {
+		rw.WriteHeader(r.StatusCode)
+		return
 	}
+	rw.Header().Set("Content-Length", strconv.Itoa(len(content)))
 	rw.WriteHeader(r.StatusCode)
-	if err := responseWriterWrite(rw, r.Content); err != nil {
-		LogError(InternalError{
+	if err := responseWriterWrite(rw, content); err != nil {
+		rq.logError(InternalError{
 			Err:     err,
 			Message: "error writing response",
 			Help:    fmt.Sprintf("(response: %d %s): rw.Write() error: %s", r.StatusCode, http.StatusText(r.StatusCode), err),
-			Request: rq,
+			Request: rq.Request,
 		})
 	}
 }
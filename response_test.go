@@ -69,7 +69,7 @@ func TestResponse(t *testing.T) {
 				rq := &http.Request{URL: &url.URL{Path: "/path"}}
 
 				// ACT
-				sut.write(rec, rq)
+				sut.write(rec, &Request{Request: rq})
 
 				// ASSERT
 				test.That(t, rec.statusCode).Equals(200)
@@ -79,6 +79,133 @@ func TestResponse(t *testing.T) {
 				test.That(t, loggedErr.Request).Equals(rq)
 			},
 		},
+		{scenario: "write/multiple header values",
+			exec: func(t *testing.T) {
+				// ARRANGE
+				rec := httptest.NewRecorder()
+				sut := Response{
+					StatusCode: 200,
+					headers: headers{
+						"Link":       []string{`</items?page=2>; rel="next"`, `</items?page=9>; rel="last"`},
+						"Set-Cookie": []any{&http.Cookie{Name: "a", Value: "1"}, &http.Cookie{Name: "b", Value: "2"}},
+						"Empty":      nil,
+					},
+				}
+
+				// ACT
+				sut.write(rec, nil)
+
+				// ASSERT
+				result := rec.Result()
+				test.That(t, result.Header["Link"]).Equals([]string{`</items?page=2>; rel="next"`, `</items?page=9>; rel="last"`})
+				test.That(t, result.Header["Set-Cookie"]).Equals([]string{"a=1", "b=2"})
+				_, hasEmpty := result.Header["Empty"]
+				test.IsFalse(t, hasEmpty)
+			},
+		},
+		{scenario: "write/content length",
+			exec: func(t *testing.T) {
+				// ARRANGE
+				rec := httptest.NewRecorder()
+				sut := Response{
+					StatusCode:  200,
+					ContentType: "application/json",
+					Content:     []byte("\"content\""),
+				}
+
+				// ACT
+				sut.write(rec, nil)
+
+				// ASSERT
+				test.That(t, rec.Header().Get("Content-Length")).Equals("9")
+			},
+		},
+		{scenario: "write/content length/no content",
+			exec: func(t *testing.T) {
+				// ARRANGE
+				testcases := []struct {
+					statusCode int
+					result     []string
+				}{
+					{statusCode: http.StatusOK, result: []string{"0"}},
+					{statusCode: http.StatusNoContent, result: nil},
+					{statusCode: http.StatusNotModified, result: nil},
+				}
+				for _, tc := range testcases {
+					t.Run(http.StatusText(tc.statusCode), func(t *testing.T) {
+						// ARRANGE
+						rec := httptest.NewRecorder()
+						sut := Response{StatusCode: tc.statusCode}
+
+						// ACT
+						sut.write(rec, nil)
+
+						// ASSERT
+						test.That(t, rec.Header()["Content-Length"]).Equals(tc.result)
+					})
+				}
+			},
+		},
+		{scenario: "write/charset",
+			exec: func(t *testing.T) {
+				// ARRANGE
+				testcases := []struct {
+					scenario    string
+					contentType string
+					negotiated  bool
+					result      string
+				}{
+					{scenario: "json", contentType: "application/json", negotiated: true, result: "application/json"},
+					{scenario: "text", contentType: "text/xml", negotiated: true, result: "text/xml; charset=utf-8"},
+					{scenario: "text/charset", contentType: "text/plain; charset=iso-8859-1", negotiated: true, result: "text/plain; charset=iso-8859-1"},
+					{scenario: "text/parameters", contentType: "text/csv; header=present", negotiated: true, result: "text/csv; header=present; charset=utf-8"},
+					{scenario: "text/not negotiated", contentType: "text/plain", result: "text/plain"},
+				}
+				for _, tc := range testcases {
+					t.Run(tc.scenario, func(t *testing.T) {
+						// ARRANGE
+						rec := httptest.NewRecorder()
+						sut := Response{StatusCode: 200, ContentType: tc.contentType, negotiated: tc.negotiated}
+
+						// ACT
+						sut.write(rec, nil)
+
+						// ASSERT
+						test.That(t, rec.Header().Get("Content-Type")).Equals(tc.result)
+					})
+				}
+			},
+		},
+		{scenario: "write/vary",
+			exec: func(t *testing.T) {
+				// ARRANGE
+				testcases := []struct {
+					scenario   string
+					negotiated bool
+					headers    headers
+					result     []string
+				}{
+					{scenario: "not negotiated", result: nil},
+					{scenario: "negotiated", negotiated: true, result: []string{"Accept"}},
+					{scenario: "merged", negotiated: true, headers: headers{"Vary": "Origin"}, result: []string{"Origin, Accept"}},
+					{scenario: "not duplicated", negotiated: true, headers: headers{"Vary": "origin, accept"}, result: []string{"origin, accept"}},
+					{scenario: "wildcard", negotiated: true, headers: headers{"Vary": "*"}, result: []string{"*"}},
+				}
+				for _, tc := range testcases {
+					t.Run(tc.scenario, func(t *testing.T) {
+						// ARRANGE
+						rec := httptest.NewRecorder()
+						sut := Response{StatusCode: 200, headers: tc.headers, negotiated: tc.negotiated}
+
+						// ACT
+						sut.write(rec, nil)
+
+						// ASSERT
+						test.That(t, rec.Header()["Vary"]).Equals(tc.result)
+					})
+				}
+			},
+		},
 	}
 	for _, tc := range testcases {
 		t.Run(tc.scenario, func(t *testing.T) {
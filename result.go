@@ -3,47 +3,84 @@ package restapi
 import (
 	"bytes"
 	"fmt"
+	"maps"
 	"net/http"
+	"net/url"
 	"strings"
+	"time"
 )
 
 var (
 	makeResultResponse = func(result *Result, rq *Request) *Response {
 		response := &Response{
-			StatusCode: coalesce(result.statusCode, http.StatusOK),
-			headers:    result.headers,
+			StatusCode:   coalesce(result.statusCode, http.StatusOK),
+			headers:      result.headers,
+			etag:         result.etag,
+			lastModified: result.lastModified,
 		}
 
-		// if the result content type is non-nil then the corresponding response
-		// body is specified in the result content as a []byte
-		if result.contentType != nil && result.content != nil {
-			response.Content = result.content.([]byte)
-			response.ContentType = *result.contentType
-			return response
+		// content that is streamed is written by the response itself
+		if s, ok := result.content.(streamer); ok {
+			response.ContentType = ifNil(result.contentType, "")
+			return s.makeStreamResponse(rq, response)
+		}
+
+		// a page of a collection adds headers describing the page to the
+		// response and provides the value to be marshalled
+		value := result.content
+		if p, ok := value.(paginated); ok {
+			h := make(headers, len(response.headers)+2)
+			maps.Copy(h, response.headers)
+			var u *url.URL
+			if rq.Request != nil {
+				u = rq.URL
+			}
+			value = p.paginate(u, h, result.fields)
+			response.headers = h
+		} else {
+			value = project(value, result.fields)
 		}
 
+		switch {
 		// a nil content means no further response (no body)
-		if result.content == nil {
+		case value == nil:
 			return response
-		}
+
+		// if the result content type is non-nil then the corresponding response
+		// body is specified in the result content as a []byte
+		case result.contentType != nil:
+			response.Content = result.content.([]byte)
+			response.ContentType = *result.contentType
 
 		// otherwise the result content holds some value which must be
 		// presented in the response according to the request Accept header
-		contentType := rq.Accept
-		content, err := rq.MarshalContent(result.content)
-		if err != nil {
-			LogError(InternalError{
-				Err:     err,
-				Message: "error marshalling Result response",
-				Help:    fmt.Sprintf("Result:%v", result),
-				Request: rq.Request,
-			})
-			return InternalServerError(fmt.Errorf("%w: %w", ErrMarshalResultFailed, err)).
-				makeResponse(rq)
+		default:
+			contentType, content, err := rq.marshalContent(value)
+			if err != nil {
+				rq.logError(InternalError{
+					Err:     err,
+					Message: "error marshalling Result response",
+					Help:    fmt.Sprintf("Result:%v", result),
+					Request: rq.Request,
+				})
+				return InternalServerError(fmt.Errorf("%w: %w", ErrMarshalResultFailed, err)).
+					makeResponse(rq)
+			}
+
+			response.ContentType = contentType
+			response.Content = content
+			response.negotiated = true
+
+			// fields selected by the endpoint (e.g. validated using ParseQuery)
+			// take precedence over a sparse fieldset
+			if len(result.fields) == 0 {
+				response.applySparseFieldset(rq, value)
+			}
 		}
 
-		response.ContentType = contentType
-		response.Content = content
+		if result.autoETag && response.etag == "" {
+			response.etag = contentETag(response.Content)
+		}
 		return response
 	}
 )
@@ -85,6 +122,21 @@ type Result struct {
 
 	// statusCode holds the HTTP status code for the response
 	statusCode int
+
+	// etag holds the entity tag of the content (if any), formatted as a
+	// quoted string with an optional weak indicator (W/)
+	etag string
+
+	// autoETag indicates that a strong entity tag is to be computed from the
+	// response content if no etag is set
+	autoETag bool
+
+	// lastModified holds the time the content was last modified (if known)
+	lastModified time.Time
+
+	// fields holds the names of the fields of the content to be included in
+	// the response (if empty, all fields are included)
+	fields []string
 }
 
 func (h headers) String() string {
@@ -136,6 +188,46 @@ func (r *Result) ContentType() string {
 	return *r.contentType
 }
 
+// hasHeaders ensures that the Result headers member is an initialised map,
+// making a new one if necessary.
+func (r *Result) hasHeaders() headers {
+	if r.headers == nil {
+		r.headers = make(headers)
+	}
+	return r.headers
+}
+
+// AddHeader adds a value to a canonical header on the Result, retaining any
+// values already set for the header (e.g. to add multiple Link or Set-Cookie
+// headers).  Values are formatted as described for WithHeader.
+//
+// The header key is canonicalised using http.CanonicalHeaderKey.
+func (r *Result) AddHeader(k string, v any) *Result {
+	r.hasHeaders().add(k, v)
+	return r
+}
+
+// ClearCookie adds a Set-Cookie header to the Result which instructs the client
+// to delete a cookie with the specified name and a Path of "/" (e.g. to end a
+// session).  To clear a cookie set with a different Path or Domain, use
+// WithCookie with a cookie having the same Path and Domain and a MaxAge of -1.
+func (r *Result) ClearCookie(name string) *Result {
+	r.hasHeaders().setCookie(expiredCookie(name))
+	return r
+}
+
+// WithAutoETag specifies that a strong entity tag is to be computed from the
+// content of the response if no entity tag is set using WithETag.  The entity
+// tag is computed from the response content after marshalling, so differs for
+// each representation (content type) of a value.
+//
+// See WithETag for details of the conditional requests supported using the
+// entity tag.
+func (r *Result) WithAutoETag() *Result {
+	r.autoETag = true
+	return r
+}
+
 // WithContent sets the content and content type of the Result.  The
 // specified content and content type will replace any content or
 // content type that may have been set on the Result previously.
@@ -145,16 +237,75 @@ func (r *Result) WithContent(contentType string, content []byte) *Result {
 	return r
 }
 
+// WithCookie adds a Set-Cookie header to the Result for the specified cookie.
+// A cookie with the same Name, Path and Domain as a cookie already set on the
+// Result replaces that cookie.
+//
+// The function panics with ErrInvalidArgument if the cookie is nil or is
+// not valid (see: http.Cookie.Valid).
+func (r *Result) WithCookie(c *http.Cookie) *Result {
+	r.hasHeaders().setCookie(c)
+	return r
+}
+
+// WithETag sets the entity tag of the Result content, returned in an ETag
+// response header.  The entity tag may be specified with or without quotes;
+// a weak entity tag is specified with a W/ prefix:
+//
+//	r.WithETag("v42")       // ETag: "v42"
+//	r.WithETag(`W/"v42"`)   // ETag: W/"v42"
+//
+// For GET and HEAD requests, an entity tag is used to evaluate any If-Match
+// and If-None-Match request headers, responding with 412 Precondition Failed
+// or 304 Not Modified (respectively) if the condition is not met.
+//
+// Conditional headers are not evaluated for other request methods since the
+// handler has already performed the request; to reject requests that would
+// modify a resource that has changed, see CheckIfMatch and RequireIfMatch.
+func (r *Result) WithETag(etag string) *Result {
+	r.etag = formatETag(etag)
+	return r
+}
+
+// WithFields specifies the fields of the Result value to be included in the
+// response; other fields are omitted.  Fields are identified by name, as
+// marshalled (the name in a json tag, or an xml tag, or the name of the field).
+// If no fields are specified, all fields are included.
+//
+// Fields are selected from a struct value (or a pointer to a struct), each
+// struct in a slice or array of structs (including the items of a Page) or a
+// map with string keys; only top-level fields are selected.  The fields of a
+// value of any other type, or of a type which implements json.Marshaler,
+// xml.Marshaler or encoding.TextMarshaler, are not selected.
+//
+// The fields requested in the fields query parameter of a request are obtained
+// using ParseQuery.  A Result with fields specified is not pruned to a sparse
+// fieldset requested by the client (see: Server.SparseFieldsets).
+func (r *Result) WithFields(fields ...string) *Result {
+	r.fields = fields
+	return r
+}
+
 // WithHeader sets a canonical header on the Result.
 //
 // The specified header will be added to any headers already set on the
 // Result.  If the specified header is already set on the Result
 // the existing header will be replaced with the new value.
 //
+// A slice value (e.g. []string) sets multiple values for the header.  Values
+// are formatted according to their type:
+//
+//   - time.Time             // an HTTP-date (e.g. "Mon, 02 Jan 2006 15:04:05 GMT")
+//   - time.Duration         // a number of whole seconds (e.g. for Retry-After)
+//   - *url.URL, url.URL     // the string form of the URL
+//   - *http.Cookie          // a Set-Cookie value (an invalid cookie is omitted)
+//   - <any other type>      // formatted using %v
+//
 // The header key is canonicalised using http.CanonicalHeaderKey.  To set
-// a header with a non-canonical key use WithNonCanonicalHeader.
+// a header with a non-canonical key use WithNonCanonicalHeader.  To add a
+// value to a header without replacing any existing values use AddHeader.
 func (r *Result) WithHeader(k string, v any) *Result {
-	r.headers[http.CanonicalHeaderKey(k)] = v
+	r.hasHeaders().set(k, v)
 	return r
 }
 
@@ -167,9 +318,21 @@ func (r *Result) WithHeader(k string, v any) *Result {
 // The header keys are canonicalised using http.CanonicalHeaderKey.
 // To set a header with a non-canonical key use WithNonCanonicalHeader.
 func (r *Result) WithHeaders(headers map[string]any) *Result {
-	for k, v := range headers {
-		r.headers[http.CanonicalHeaderKey(k)] = v
-	}
+	r.hasHeaders().setAll(headers)
+	return r
+}
+
+// WithLastModified sets the time that the Result content was last modified,
+// returned in a Last-Modified response header.  The time is truncated to
+// whole seconds.
+//
+// For GET and HEAD requests, the modification time is used to evaluate any
+// If-Unmodified-Since and If-Modified-Since request headers, responding with
+// 412 Precondition Failed or 304 Not Modified (respectively) if the condition
+// is not met.  If-Unmodified-Since and If-Modified-Since are ignored if the
+// request also has an If-Match or If-None-Match header (respectively).
+func (r *Result) WithLastModified(t time.Time) *Result {
+	r.lastModified = t.UTC().Truncate(time.Second)
 	return r
 }
 
@@ -186,13 +349,14 @@ func (r *Result) WithHeaders(headers map[string]any) *Result {
 // header key is specifically required (which is rare).  Ordinarily
 // WithHeader should be used.
 func (r *Result) WithNonCanonicalHeader(k string, v any) *Result {
-	r.headers[k] = v
+	r.hasHeaders().setNonCanonical(k, v)
 	return r
 }
 
 // WithValue sets the content of the Result to a value that will be
 // marshalled in the response to the content type indicated in the request
-// Accept header (or restapi.Default.ResponseContentType).
+// Accept header (or the default content type if the request does not express
+// a preference).
 //
 // The specified value will replace any content and content type that may
 // have been set on the Result previously.
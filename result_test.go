@@ -195,6 +195,54 @@ func TestResult(t *testing.T) {
 				})
 			},
 		},
+		{scenario: "WithHeader/no headers",
+			exec: func(t *testing.T) {
+				// ARRANGE
+				sut := &Result{}
+
+				// ACT
+				_ = sut.WithHeader("header", "value")
+
+				// ASSERT
+				test.That(t, sut.headers).Equals(headers{"Header": "value"})
+			},
+		},
+		{scenario: "WithETag",
+			exec: func(t *testing.T) {
+				// ARRANGE
+				testcases := []struct {
+					etag   string
+					result string
+				}{
+					{etag: "", result: ""},
+					{etag: "v1", result: `"v1"`},
+					{etag: `"v1"`, result: `"v1"`},
+					{etag: `W/"v1"`, result: `W/"v1"`},
+				}
+				for _, tc := range testcases {
+					sut := &Result{}
+
+					// ACT
+					_ = sut.WithETag(tc.etag)
+
+					// ASSERT
+					test.That(t, sut.etag, tc.etag).Equals(tc.result)
+				}
+			},
+		},
+		{scenario: "WithLastModified",
+			exec: func(t *testing.T) {
+				// ARRANGE
+				sut := &Result{}
+				tm := time.Date(2010, 9, 8, 9, 6, 5, 4, time.FixedZone("CEST", 2*60*60))
+
+				// ACT
+				_ = sut.WithLastModified(tm)
+
+				// ASSERT
+				test.That(t, sut.lastModified).Equals(time.Date(2010, 9, 8, 7, 6, 5, 0, time.UTC))
+			},
+		},
 		{scenario: "WithHeaders/already set",
 			exec: func(t *testing.T) {
 				// ARRANGE
@@ -328,9 +376,45 @@ func TestResult(t *testing.T) {
 					StatusCode:  http.StatusOK,
 					ContentType: "application/json",
 					Content:     []byte("\"value\""),
+					negotiated:  true,
 				})
 			},
 		},
+		{scenario: "makeResponse/with validators",
+			exec: func(t *testing.T) {
+				// ARRANGE
+				tm := time.Date(2010, 9, 8, 7, 6, 5, 0, time.UTC)
+				sut := OK().
+					WithValue("value").
+					WithETag("v1").
+					WithLastModified(tm)
+
+				// ACT
+				response := sut.makeResponse(&Request{Accept: "application/json", MarshalContent: json.Marshal})
+
+				// ASSERT
+				test.That(t, response.etag).Equals(`"v1"`)
+				test.That(t, response.lastModified).Equals(tm)
+			},
+		},
+		{scenario: "makeResponse/auto etag",
+			exec: func(t *testing.T) {
+				// ARRANGE
+				rq := &Request{Accept: "application/json", MarshalContent: json.Marshal}
+
+				// ACT
+				r1 := OK().WithValue("value").WithAutoETag().makeResponse(rq)
+				r2 := OK().WithValue("value").WithAutoETag().makeResponse(rq)
+				r3 := OK().WithValue("other").WithAutoETag().makeResponse(rq)
+				r4 := OK().WithValue("value").WithAutoETag().WithETag("v1").makeResponse(rq)
+
+				// ASSERT
+				test.That(t, r1.etag).Equals(`"a0b7821a11db531982044ca5ca2e788e"`)
+				test.That(t, r2.etag).Equals(r1.etag)
+				test.IsFalse(t, r3.etag == r1.etag)
+				test.That(t, r4.etag).Equals(`"v1"`)
+			},
+		},
 		{scenario: "makeResponse/marshal error",
 			exec: func(t *testing.T) {
 				// ARRANGE
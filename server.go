@@ -0,0 +1,143 @@
+package restapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+// Server holds the configuration applied to REST API endpoints.  A Server
+// provides HandlerFunc and Handler methods returning http handlers bound to
+// that configuration, allowing APIs with different requirements (e.g. a public
+// API and an admin API) to be served from the same application.
+//
+// Any configuration that is not set on a Server is provided by the equivalent
+// package-level default:
+//
+//	LogError       // restapi.LogError
+//	ProjectError   // restapi.ProjectError
+//	Marshallers    // the registry maintained by restapi.RegisterMarshaller
+//	               // and restapi.UnregisterMarshaller
+//	NowUTC         // the current time in UTC (time.Now().UTC())
+//
+// Compression and SparseFieldsets are not applied unless configured on a
+// Server.
+//
+// The zero value of a Server is ready to use, applying the package-level
+// defaults.  The package-level HandlerFunc and Handler functions use the
+// Default Server.
+//
+// # example
+//
+//	admin := &restapi.Server{
+//	    LogError:     logAdminError,
+//	    ProjectError: projectAdminError,
+//	}
+//	http.Handle("/admin/users", admin.HandlerFunc(GetUsers))
+//	http.Handle("/users", restapi.HandlerFunc(GetPublicUsers))
+type Server struct {
+	// LogError is called when an error is returned from a handler or if an
+	// error occurs in an aspect of the restapi implementation itself.
+	LogError func(InternalError)
+
+	// ProjectError is called when writing an error response to obtain a
+	// representation of a REST API Error to be used as the response body.
+	ProjectError func(ErrorInfo) any
+
+	// Marshallers is the registry of content types supported by the Server.
+	Marshallers *Marshallers
+
+	// NowUTC returns the current time in UTC, used to timestamp error
+	// responses (e.g. to provide a consistent time when testing).
+	NowUTC func() time.Time
+
+	// Compression specifies the compression applied to response content.  If
+	// not set, responses are not compressed (see: DefaultCompression).
+	Compression *Compression
+
+	// SparseFieldsets enables the pruning of marshalled Result content to the
+	// fields requested by a client, identified by a fields query parameter or
+	// a fields preference in a Prefer header (e.g. ?fields=id,owner.name or
+	// Prefer: fields="id,owner.name").  Nested fields are identified by a
+	// path of names separated by '.'.
+	//
+	// Fields are pruned from json, ndjson and xml content (identified by
+	// the names of elements in xml content).  Streamed content and hypermedia
+	// content (application/hal+json and application/vnd.api+json) are not
+	// pruned.
+	//
+	// Requested fields are not validated; pruning only omits fields from the
+	// marshalled content, so a client cannot obtain fields that would not
+	// otherwise be present.  An endpoint that validates the fields query
+	// parameter (using ParseQuery with QueryAllow.Fields) rejects a request
+	// for fields that are not allowed; the validated fields should be applied
+	// using Result.WithFields, in which case the Result is not pruned.
+	SparseFieldsets bool
+}
+
+// Default is the Server used by the package-level HandlerFunc and Handler
+// functions.
+var Default = &Server{}
+
+// compression returns the Compression configured on the Server; nil if no
+// Compression is configured.
+func (s *Server) compression() *Compression {
+	if s != nil {
+		return s.Compression
+	}
+	return nil
+}
+
+// fallbackRequest returns a Request to be used to respond to a request for
+// which no acceptable content type could be negotiated.  The Request will
+// use the default content type of the Server or, if the Server has no
+// registered content types, "application/json".
+func (s *Server) fallbackRequest(rq *http.Request) *Request {
+	ct, mc, ok := s.marshallers().negotiate("")
+	if !ok {
+		ct, mc = "application/json", json.Marshal
+	}
+	return &Request{
+		Request:        rq,
+		Accept:         ct,
+		MarshalContent: mc,
+		server:         s,
+	}
+}
+
+// logError calls the LogError function configured on the Server or, if no
+// function is configured, the package-level LogError function.
+func (s *Server) logError(err InternalError) {
+	if s != nil && s.LogError != nil {
+		s.LogError(err)
+		return
+	}
+	LogError(err)
+}
+
+// marshallers returns the Marshallers registry configured on the Server or,
+// if no registry is configured, the default registry.
+func (s *Server) marshallers() *Marshallers {
+	if s != nil && s.Marshallers != nil {
+		return s.Marshallers
+	}
+	return marshal
+}
+
+// nowUTC returns the current time using the NowUTC function configured on the
+// Server or, if no function is configured, the package-level clock.
+func (s *Server) nowUTC() time.Time {
+	if s != nil && s.NowUTC != nil {
+		return s.NowUTC()
+	}
+	return nowUTC()
+}
+
+// projectError calls the ProjectError function configured on the Server or, if
+// no function is configured, the package-level ProjectError function.
+func (s *Server) projectError(err ErrorInfo) any {
+	if s != nil && s.ProjectError != nil {
+		return s.ProjectError(err)
+	}
+	return ProjectError(err)
+}
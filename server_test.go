@@ -0,0 +1,146 @@
+package restapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/blugnu/test"
+)
+
+func TestServer(t *testing.T) {
+	// ARRANGE
+	testcases := []struct {
+		scenario string
+		exec     func(t *testing.T)
+	}{
+		{scenario: "nil/uses package defaults",
+			exec: func(t *testing.T) {
+				// ARRANGE
+				var sut *Server
+				isLogged := false
+				defer test.Using(&LogError, func(InternalError) { isLogged = true })()
+				defer test.Using(&ProjectError, func(ErrorInfo) any { return "projected" })()
+
+				// ACT
+				sut.logError(InternalError{})
+				projection := sut.projectError(ErrorInfo{})
+				marshallers := sut.marshallers()
+				now := sut.nowUTC()
+
+				// ASSERT
+				test.IsTrue(t, isLogged)
+				test.That(t, projection).Equals("projected")
+				test.IsTrue(t, marshallers == marshal)
+				test.That(t, now.Location()).Equals(time.UTC)
+			},
+		},
+		{scenario: "configured",
+			exec: func(t *testing.T) {
+				// ARRANGE
+				isLogged := false
+				defer test.Using(&LogError, func(InternalError) { t.Error("package LogError was called") })()
+				defer test.Using(&ProjectError, func(ErrorInfo) any { return "package projection" })()
+
+				defer test.Using(&nowUTC, func() time.Time { t.Error("package nowUTC was called"); return time.Time{} })()
+
+				m := NewMarshallers()
+				ts := time.Date(2010, 9, 8, 7, 6, 5, 0, time.UTC)
+				sut := &Server{
+					LogError:     func(InternalError) { isLogged = true },
+					ProjectError: func(ErrorInfo) any { return "server projection" },
+					Marshallers:  m,
+					NowUTC:       func() time.Time { return ts },
+				}
+
+				// ACT
+				sut.logError(InternalError{})
+				projection := sut.projectError(ErrorInfo{})
+				marshallers := sut.marshallers()
+				now := sut.nowUTC()
+
+				// ASSERT
+				test.IsTrue(t, isLogged)
+				test.That(t, projection).Equals("server projection")
+				test.IsTrue(t, marshallers == m)
+				test.That(t, now).Equals(ts)
+			},
+		},
+		{scenario: "Handler/bound to configuration",
+			exec: func(t *testing.T) {
+				// ARRANGE
+				m := NewMarshallers()
+				m.Register("application/vnd.admin+json", json.Marshal)
+
+				logged := []InternalError{}
+				sut := &Server{
+					LogError:     func(e InternalError) { logged = append(logged, e) },
+					ProjectError: func(e ErrorInfo) any { return map[string]any{"admin": e.StatusCode} },
+					Marshallers:  m,
+				}
+				rq := &http.Request{
+					Header: http.Header{"Accept": []string{"application/vnd.admin+json"}},
+					URL:    &url.URL{Path: "/admin"},
+				}
+				rec := httptest.NewRecorder()
+
+				// ACT
+				sut.Handler(EndpointFunc(func(context.Context, *http.Request) any {
+					return BadRequest(errors.New("bad"))
+				}))(rec, rq)
+
+				// ASSERT
+				test.That(t, rec.Code).Equals(http.StatusBadRequest)
+				test.That(t, rec.Header().Get("Content-Type")).Equals("application/vnd.admin+json")
+				test.That(t, rec.Body.String()).Equals(`{"admin":400}`)
+			},
+		},
+		{scenario: "Handler/error timestamp",
+			exec: func(t *testing.T) {
+				// ARRANGE
+				sut := &Server{NowUTC: func() time.Time { return time.Date(2010, 9, 8, 7, 6, 5, 0, time.UTC) }}
+				rq := &http.Request{URL: &url.URL{Path: "/"}}
+				rec := httptest.NewRecorder()
+
+				// ACT
+				sut.HandlerFunc(func(context.Context, *http.Request) any {
+					return NotFound()
+				})(rec, rq)
+
+				// ASSERT
+				test.String(t, rec.Body.String()).Contains(`"timestamp":"2010-09-08T07:06:05Z"`)
+			},
+		},
+		{scenario: "Handler/other server not affected",
+			exec: func(t *testing.T) {
+				// ARRANGE
+				admin := &Server{Marshallers: NewMarshallers()}
+				admin.Marshallers.Register("application/vnd.admin+json", json.Marshal)
+
+				rq := &http.Request{
+					Header: http.Header{"Accept": []string{"application/vnd.admin+json"}},
+					URL:    &url.URL{Path: "/"},
+				}
+				rec := httptest.NewRecorder()
+
+				// ACT
+				HandlerFunc(func(context.Context, *http.Request) any {
+					return http.StatusOK
+				})(rec, rq)
+
+				// ASSERT
+				test.That(t, rec.Code).Equals(http.StatusNotAcceptable)
+			},
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.scenario, func(t *testing.T) {
+			tc.exec(t)
+		})
+	}
+}
@@ -0,0 +1,352 @@
+package restapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"strings"
+)
+
+// fieldMask is a tree of the names of the fields to be retained in response
+// content.  A field with a nil mask is retained in full.
+type fieldMask map[string]fieldMask
+
+// sparseEnvelope is implemented by values marshalled as an envelope holding
+// other content (e.g. a page of items), to which a field mask is applied.
+type sparseEnvelope interface {
+	// envelopeMask returns the mask to be applied to the marshalled envelope
+	// for a mask to be applied to the content it holds, in a specified format
+	// ("json", "ndjson" or "xml")
+	envelopeMask(m fieldMask, format string) fieldMask
+}
+
+// parseFieldMask parses a comma-separated list of field paths, in which the
+// names of nested fields are separated by '.' (e.g. "id,owner.name").  A nil
+// mask is returned if no fields are specified.
+func parseFieldMask(s string) fieldMask {
+	var m fieldMask
+	for _, path := range strings.Split(s, ",") {
+		path = strings.TrimSpace(path)
+		if path == "" {
+			continue
+		}
+		if m == nil {
+			m = fieldMask{}
+		}
+
+		node := m
+		names := strings.Split(path, ".")
+		for i, name := range names {
+			if i == len(names)-1 {
+				node[name] = nil
+				break
+			}
+			sub, ok := node[name]
+			if ok && sub == nil {
+				// the field is already retained in full
+				break
+			}
+			if !ok {
+				sub = fieldMask{}
+				node[name] = sub
+			}
+			node = sub
+		}
+	}
+	return m
+}
+
+// splitUnquoted splits a string at each occurrence of a separator that is
+// not enclosed in double-quotes.
+func splitUnquoted(s string, sep byte) []string {
+	var (
+		result []string
+		quoted bool
+		from   int
+	)
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '"':
+			quoted = !quoted
+		case s[i] == sep && !quoted:
+			result = append(result, s[from:i])
+			from = i + 1
+		}
+	}
+	return append(result, s[from:])
+}
+
+// preferredFields returns the value of any fields preference in the Prefer
+// headers of a request (e.g. Prefer: fields="id,name").
+func preferredFields(rq *http.Request) string {
+	for _, h := range rq.Header.Values("Prefer") {
+		for _, pref := range splitUnquoted(h, ',') {
+			// any parameters of the preference are ignored
+			k, v, ok := strings.Cut(splitUnquoted(pref, ';')[0], "=")
+			if ok && strings.EqualFold(strings.TrimSpace(k), "fields") {
+				return strings.Trim(strings.TrimSpace(v), `"`)
+			}
+		}
+	}
+	return ""
+}
+
+// pruneJSON returns json content retaining only the fields identified by a
+// mask.  The mask is applied to an object, or to each element of an array;
+// any other value is returned unchanged.  The order of retained fields is
+// preserved.
+func pruneJSON(data []byte, m fieldMask) ([]byte, error) {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 || (data[0] != '{' && data[0] != '[') {
+		return data, nil
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	open, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	buf := &bytes.Buffer{}
+	buf.WriteRune(rune(open.(json.Delim)))
+	for n := 0; dec.More(); {
+		var key json.Token
+		if open == json.Delim('{') {
+			if key, err = dec.Token(); err != nil {
+				return nil, err
+			}
+		}
+
+		var value json.RawMessage
+		if err := dec.Decode(&value); err != nil {
+			return nil, err
+		}
+
+		mask := m
+		if key != nil {
+			sub, ok := m[key.(string)]
+			if !ok {
+				continue
+			}
+			mask = sub
+		}
+		if mask != nil {
+			if value, err = pruneJSON(value, mask); err != nil {
+				return nil, err
+			}
+		}
+
+		if n > 0 {
+			buf.WriteByte(',')
+		}
+		if key != nil {
+			k, _ := json.Marshal(key)
+			buf.Write(k)
+			buf.WriteByte(':')
+		}
+		buf.Write(value)
+		n++
+	}
+	if _, err := dec.Token(); err != nil {
+		return nil, err
+	}
+	buf.WriteRune(rune(open.(json.Delim)) + 2) // '{' + 2 = '}', '[' + 2 = ']'
+	return buf.Bytes(), nil
+}
+
+// skipXML reads the tokens of an xml element (or of the remainder of the
+// element if the start element has already been read), to its end element.
+func skipXML(dec *xml.Decoder) error {
+	for depth := 1; depth > 0; {
+		tok, err := dec.RawToken()
+		if err != nil {
+			return err
+		}
+		switch tok.(type) {
+		case xml.StartElement:
+			depth++
+		case xml.EndElement:
+			depth--
+		}
+	}
+	return nil
+}
+
+// pruneXMLContent copies xml content from a decoder to a buffer, up to the end
+// of the current element (or of the document), retaining only those elements
+// for which the keep function returns true (with the mask to be applied to the
+// content of the element).  Whitespace preceding an element is retained only if
+// the element is retained.
+func pruneXMLContent(dec *xml.Decoder, data []byte, buf *bytes.Buffer, keep func(name string) (fieldMask, bool)) error {
+	var whitespace []byte
+	for {
+		start := dec.InputOffset()
+		tok, err := dec.RawToken()
+		if errors.Is(err, io.EOF) {
+			buf.Write(whitespace)
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		raw := data[start:dec.InputOffset()]
+
+		switch tok := tok.(type) {
+		case xml.StartElement:
+			mask, ok := keep(tok.Name.Local)
+			if !ok {
+				whitespace = nil
+				if err := skipXML(dec); err != nil {
+					return err
+				}
+				continue
+			}
+
+			buf.Write(whitespace)
+			whitespace = nil
+			if mask == nil {
+				if err := skipXML(dec); err != nil {
+					return err
+				}
+				buf.Write(data[start:dec.InputOffset()])
+				continue
+			}
+
+			buf.Write(raw)
+			if err := pruneXMLContent(dec, data, buf, func(name string) (fieldMask, bool) {
+				sub, ok := mask[name]
+				return sub, ok
+			}); err != nil {
+				return err
+			}
+
+		case xml.EndElement:
+			buf.Write(whitespace)
+			buf.Write(raw)
+			return nil
+
+		case xml.CharData:
+			if len(bytes.TrimSpace(tok)) == 0 {
+				whitespace = append(whitespace, raw...)
+				continue
+			}
+			buf.Write(whitespace)
+			whitespace = nil
+			buf.Write(raw)
+
+		default:
+			buf.Write(raw)
+		}
+	}
+}
+
+// pruneXML returns xml content retaining only the elements identified by a
+// mask.  Top-level elements are retained, with the mask applied to the content
+// of each (an xml document holding a single value has a single top-level
+// element; a marshalled slice has a top-level element for each item).
+//
+// Elements are identified by local name; the path to an element includes any
+// elements wrapping a collection (e.g. "owners.owner.name").
+func pruneXML(data []byte, m fieldMask) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	if err := pruneXMLContent(dec, data, buf, func(string) (fieldMask, bool) { return m, true }); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// pruneContent returns content of a specified format ("json", "ndjson" or
+// "xml") retaining only the fields identified by a mask.  Content of any other
+// format is returned unchanged.
+//
+// Indented json is re-indented (using two spaces) after pruning.
+func pruneContent(content []byte, format string, m fieldMask) ([]byte, error) {
+	switch format {
+	case "json":
+		result, err := pruneJSON(content, m)
+		if err != nil || !bytes.ContainsRune(content, '\n') {
+			return result, err
+		}
+		buf := &bytes.Buffer{}
+		if err := json.Indent(buf, result, "", "  "); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+
+	case "ndjson":
+		buf := &bytes.Buffer{}
+		for _, line := range bytes.Split(content, []byte{'\n'}) {
+			if len(bytes.TrimSpace(line)) == 0 {
+				continue
+			}
+			result, err := pruneJSON(line, m)
+			if err != nil {
+				return nil, err
+			}
+			buf.Write(result)
+			buf.WriteByte('\n')
+		}
+		return buf.Bytes(), nil
+
+	case "xml":
+		return pruneXML(content, m)
+	}
+	return content, nil
+}
+
+// applySparseFieldset prunes the content of a response to the fields requested
+// by a request, if sparse fieldsets are enabled on the Server handling the
+// request.  The requested fields are identified by a fields query parameter or,
+// if not specified, a fields preference in a Prefer header (in which case a
+// Preference-Applied header is added to the response).
+//
+// Hypermedia content is not pruned; the structure of a hypermedia document is
+// defined by its media type (and JSON:API defines sparse fieldsets of its own).
+//
+// value is the value marshalled as the content of the response.
+func (r *Response) applySparseFieldset(rq *Request, value any) {
+	if rq.Request == nil || rq.server == nil || !rq.server.SparseFieldsets ||
+		isHypermedia(r.ContentType) {
+		return
+	}
+
+	h := make(headers, len(r.headers)+2)
+	maps.Copy(h, r.headers)
+	h.add("Vary", "Prefer")
+	r.headers = h
+
+	fields, preferred := rq.URL.Query().Get("fields"), false
+	if fields == "" {
+		fields, preferred = preferredFields(rq.Request), true
+	}
+	m := parseFieldMask(fields)
+	if m == nil {
+		return
+	}
+
+	format := sequenceFormat(r.ContentType)
+	if env, ok := value.(sparseEnvelope); ok {
+		m = env.envelopeMask(m, format)
+	}
+
+	content, err := pruneContent(r.Content, format, m)
+	if err != nil {
+		rq.logError(InternalError{
+			Err:     err,
+			Message: "error applying sparse fieldset",
+			Help:    fmt.Sprintf("the response was sent with all fields (fields: %s)", fields),
+			Request: rq.Request,
+		})
+		return
+	}
+	r.Content = content
+	if preferred {
+		h.set("Preference-Applied", fmt.Sprintf("fields=%q", fields))
+	}
+}
@@ -0,0 +1,296 @@
+package restapi
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/blugnu/test"
+)
+
+func TestParseFieldMask(t *testing.T) {
+	// ARRANGE
+	testcases := []struct {
+		fields string
+		result fieldMask
+	}{
+		{fields: "", result: nil},
+		{fields: " , ", result: nil},
+		{fields: "id,name", result: fieldMask{"id": nil, "name": nil}},
+		{fields: "id, owner.name,owner.email", result: fieldMask{"id": nil, "owner": fieldMask{"name": nil, "email": nil}}},
+		{fields: "owner,owner.name", result: fieldMask{"owner": nil}},
+		{fields: "owner.name,owner", result: fieldMask{"owner": nil}},
+		{fields: "a.b.c", result: fieldMask{"a": fieldMask{"b": fieldMask{"c": nil}}}},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.fields, func(t *testing.T) {
+			// ACT
+			result := parseFieldMask(tc.fields)
+
+			// ASSERT
+			test.That(t, result).Equals(tc.result)
+		})
+	}
+}
+
+func TestPreferredFields(t *testing.T) {
+	// ARRANGE
+	testcases := []struct {
+		prefer []string
+		result string
+	}{
+		{prefer: nil, result: ""},
+		{prefer: []string{"return=minimal"}, result: ""},
+		{prefer: []string{`fields="id,name"`}, result: "id,name"},
+		{prefer: []string{`return=minimal, Fields="id,owner.name"; x=1`}, result: "id,owner.name"},
+		{prefer: []string{"respond-async", "fields=id"}, result: "id"},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.result, func(t *testing.T) {
+			// ARRANGE
+			rq := httptest.NewRequest(http.MethodGet, "/", nil)
+			for _, v := range tc.prefer {
+				rq.Header.Add("Prefer", v)
+			}
+
+			// ACT
+			result := preferredFields(rq)
+
+			// ASSERT
+			test.That(t, result).Equals(tc.result)
+		})
+	}
+}
+
+func TestPruneContent(t *testing.T) {
+	// ARRANGE
+	mask := parseFieldMask("id,owner.name,tags")
+	testcases := []struct {
+		scenario string
+		format   string
+		content  string
+		result   string
+		err      bool
+	}{
+		{scenario: "json/object", format: "json",
+			content: `{"name":"x","id":1,"owner":{"name":"o","email":"e"},"tags":["a",{"b":1}]}`,
+			result:  `{"id":1,"owner":{"name":"o"},"tags":["a",{"b":1}]}`,
+		},
+		{scenario: "json/array", format: "json",
+			content: `[{"id":1,"name":"a"},{"id":2,"owner":null}]`,
+			result:  `[{"id":1},{"id":2,"owner":null}]`,
+		},
+		{scenario: "json/scalar", format: "json", content: `42`, result: `42`},
+		{scenario: "json/escaped key", format: "json", content: `{"id":"A","x\"y":1}`, result: `{"id":"A"}`},
+		{scenario: "json/indented", format: "json",
+			content: "{\n  \"id\": 1,\n  \"name\": \"x\"\n}",
+			result:  "{\n  \"id\": 1\n}",
+		},
+		{scenario: "json/invalid", format: "json", content: `{"id":`, err: true},
+		{scenario: "ndjson", format: "ndjson",
+			content: "{\"id\":1,\"name\":\"a\"}\n{\"id\":2,\"name\":\"b\"}\n",
+			result:  "{\"id\":1}\n{\"id\":2}\n",
+		},
+		{scenario: "xml/element", format: "xml",
+			content: `<thing kind="k"><id>1</id><name>x</name><owner><name>o</name><email>e</email></owner><tags><tag>a</tag></tags></thing>`,
+			result:  `<thing kind="k"><id>1</id><owner><name>o</name></owner><tags><tag>a</tag></tags></thing>`,
+		},
+		{scenario: "xml/sequence", format: "xml",
+			content: `<thing><id>1</id><name>a</name></thing><thing><id>2</id><name/></thing>`,
+			result:  `<thing><id>1</id></thing><thing><id>2</id></thing>`,
+		},
+		{scenario: "xml/indented", format: "xml",
+			content: "<thing>\n    <id>1</id>\n    <name>x</name>\n    <owner>\n        <email>e</email>\n        <name>o</name>\n    </owner>\n</thing>",
+			result:  "<thing>\n    <id>1</id>\n    <owner>\n        <name>o</name>\n    </owner>\n</thing>",
+		},
+		{scenario: "xml/invalid", format: "xml", content: `<thing><id>`, err: true},
+		{scenario: "other format", format: "", content: `id,name`, result: `id,name`},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.scenario, func(t *testing.T) {
+			// ACT
+			result, err := pruneContent([]byte(tc.content), tc.format, mask)
+
+			// ASSERT
+			if tc.err {
+				test.That(t, err != nil).Equals(true)
+				return
+			}
+			test.Error(t, err).IsNil()
+			test.That(t, string(result)).Equals(tc.result)
+		})
+	}
+}
+
+func TestSparseFieldsets(t *testing.T) {
+	// ARRANGE
+	type owner struct {
+		Name  string `json:"name" xml:"name"`
+		Email string `json:"email" xml:"email"`
+	}
+	type thing struct {
+		ID    int    `json:"id" xml:"id"`
+		Name  string `json:"name" xml:"name"`
+		Owner owner  `json:"owner" xml:"owner"`
+	}
+	value := thing{ID: 1, Name: "a", Owner: owner{Name: "o", Email: "e"}}
+
+	serve := func(s *Server, rq *http.Request, result any) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		s.HandlerFunc(func(context.Context, *http.Request) any { return result })(rec, rq)
+		return rec
+	}
+
+	testcases := []struct {
+		scenario string
+		exec     func(t *testing.T)
+	}{
+		{scenario: "disabled",
+			exec: func(t *testing.T) {
+				// ARRANGE
+				rq := httptest.NewRequest(http.MethodGet, "/?fields=id", nil)
+
+				// ACT
+				rec := serve(&Server{}, rq, value)
+
+				// ASSERT
+				test.That(t, rec.Body.String()).Equals(`{"id":1,"name":"a","owner":{"name":"o","email":"e"}}`)
+				test.That(t, rec.Header().Get("Vary")).Equals("Accept")
+			},
+		},
+		{scenario: "no fields requested",
+			exec: func(t *testing.T) {
+				// ARRANGE
+				rq := httptest.NewRequest(http.MethodGet, "/", nil)
+
+				// ACT
+				rec := serve(&Server{SparseFieldsets: true}, rq, value)
+
+				// ASSERT
+				test.That(t, rec.Body.String()).Equals(`{"id":1,"name":"a","owner":{"name":"o","email":"e"}}`)
+				test.That(t, rec.Header().Get("Vary")).Equals("Prefer, Accept")
+			},
+		},
+		{scenario: "query parameter",
+			exec: func(t *testing.T) {
+				// ARRANGE
+				rq := httptest.NewRequest(http.MethodGet, "/?fields=id,owner.email", nil)
+				rq.Header.Set("Prefer", `fields="name"`)
+
+				// ACT
+				rec := serve(&Server{SparseFieldsets: true}, rq, OK().WithValue(value).WithHeader("Vary", "Origin"))
+
+				// ASSERT
+				test.That(t, rec.Body.String()).Equals(`{"id":1,"owner":{"email":"e"}}`)
+				test.That(t, rec.Header().Get("Content-Length")).Equals("30")
+				test.That(t, rec.Header().Get("Preference-Applied")).Equals("")
+				test.That(t, rec.Header().Get("Vary")).Equals("Origin, Prefer, Accept")
+			},
+		},
+		{scenario: "prefer header",
+			exec: func(t *testing.T) {
+				// ARRANGE
+				rq := httptest.NewRequest(http.MethodGet, "/", nil)
+				rq.Header.Set("Accept", "application/xml")
+				rq.Header.Set("Prefer", `fields="name,owner.name"`)
+
+				// ACT
+				rec := serve(&Server{SparseFieldsets: true}, rq, value)
+
+				// ASSERT
+				test.That(t, rec.Body.String()).Equals(`<thing><name>a</name><owner><name>o</name></owner></thing>`)
+				test.That(t, rec.Header().Get("Preference-Applied")).Equals(`fields="name,owner.name"`)
+			},
+		},
+		{scenario: "page",
+			exec: func(t *testing.T) {
+				// ARRANGE
+				rq := httptest.NewRequest(http.MethodGet, "/?fields=owner.name", nil)
+
+				// ACT
+				rec := serve(&Server{SparseFieldsets: true}, rq, OffsetPage([]thing{value}, 0, 10, 1))
+
+				// ASSERT
+				test.That(t, rec.Body.String()).Equals(`{"items":[{"owner":{"name":"o"}}],"limit":10,"offset":0,"total":1}`)
+			},
+		},
+		{scenario: "page/xml",
+			exec: func(t *testing.T) {
+				// ARRANGE
+				rq := httptest.NewRequest(http.MethodGet, "/?fields=id", nil)
+				rq.Header.Set("Accept", "application/xml")
+
+				// ACT
+				rec := serve(&Server{SparseFieldsets: true}, rq, OffsetPage([]thing{value}, 0, 10, 1))
+
+				// ASSERT
+				test.That(t, rec.Body.String()).Equals(`<page><items><item><id>1</id></item></items><limit>10</limit><offset>0</offset><total>1</total></page>`)
+			},
+		},
+		{scenario: "fields selected by result",
+			exec: func(t *testing.T) {
+				// ARRANGE
+				rq := httptest.NewRequest(http.MethodGet, "/?fields=id,owner.email", nil)
+
+				// ACT
+				rec := serve(&Server{SparseFieldsets: true}, rq, OK().WithValue(value).WithFields("id", "name"))
+
+				// ASSERT
+				test.That(t, rec.Body.String()).Equals(`{"id":1,"name":"a"}`)
+				test.That(t, rec.Header().Get("Vary")).Equals("Accept")
+			},
+		},
+		{scenario: "hypermedia",
+			exec: func(t *testing.T) {
+				// ARRANGE
+				s := &Server{SparseFieldsets: true}
+				resource := NewResource("things", "1", value).WithLink("self", "/things/1")
+				rq := httptest.NewRequest(http.MethodGet, "/?fields=name", nil)
+				rq.Header.Set("Accept", halContentType)
+
+				// ACT
+				rec := serve(s, rq, resource)
+
+				// ASSERT
+				test.That(t, rec.Body.String()).Equals(`{"id":1,"name":"a","owner":{"name":"o","email":"e"},"_links":{"self":{"href":"/things/1"}}}`)
+			},
+		},
+		{scenario: "hypermedia/plain content",
+			exec: func(t *testing.T) {
+				// ARRANGE
+				resource := NewResource("things", "1", value).WithLink("self", "/things/1")
+				rq := httptest.NewRequest(http.MethodGet, "/?fields=name", nil)
+
+				// ACT
+				rec := serve(&Server{SparseFieldsets: true}, rq, resource)
+
+				// ASSERT
+				test.That(t, rec.Body.String()).Equals(`{"name":"a"}`)
+			},
+		},
+		{scenario: "error pruning content",
+			exec: func(t *testing.T) {
+				// ARRANGE
+				s := &Server{SparseFieldsets: true, Marshallers: NewMarshallers()}
+				s.Marshallers.Register("application/json", func(any) ([]byte, error) { return []byte(`{"id":`), nil })
+				rq := httptest.NewRequest(http.MethodGet, "/?fields=id", nil)
+				var logged *InternalError
+				defer test.Using(&LogError, func(e InternalError) { logged = &e })()
+
+				// ACT
+				rec := serve(s, rq, value)
+
+				// ASSERT
+				test.That(t, rec.Body.String()).Equals(`{"id":`)
+				test.IsTrue(t, logged != nil)
+				test.That(t, logged.Message).Equals("error applying sparse fieldset")
+			},
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.scenario, func(t *testing.T) {
+			tc.exec(t)
+		})
+	}
+}
@@ -0,0 +1,257 @@
+package restapi
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// eventStreamContentType is the content type of a server-sent event stream
+const eventStreamContentType = "text/event-stream"
+
+// Event is a server-sent event, written to an event stream (see: EventStream).
+type Event struct {
+	// ID is the id of the event; if set, a client reconnecting to the stream
+	// will identify the id of the last event received in a Last-Event-ID header
+	// (see: LastEventID).  An ID must not contain newline characters.
+	ID string
+
+	// Event is the type of the event; if not set, clients receive the event
+	// as a "message" event.  An Event must not contain newline characters.
+	Event string
+
+	// Data is the data of the event.  A string or []byte is written as-is;
+	// any other value is marshalled using the marshalling function negotiated
+	// for the request.  If Data is nil no data is written.
+	Data any
+
+	// Retry, if non-zero, is the time a client should wait before attempting
+	// to reconnect if the connection is lost.
+	Retry time.Duration
+}
+
+// lineBreaks normalises the line breaks in event data (CRLF or CR) to LF
+var lineBreaks = strings.NewReplacer("\r\n", "\n", "\r", "\n")
+
+// write writes an event to a response, marshalling the event data using a
+// specified function.
+func (e Event) write(w *bufio.Writer, marshal MarshalFunc) error {
+	if strings.ContainsAny(e.ID, "\r\n\x00") {
+		return fmt.Errorf("%w: event id: %q: must not contain newlines or NUL", ErrInvalidArgument, e.ID)
+	}
+	if strings.ContainsAny(e.Event, "\r\n") {
+		return fmt.Errorf("%w: event type: %q: must not contain newlines", ErrInvalidArgument, e.Event)
+	}
+
+	var data []byte
+	switch d := e.Data.(type) {
+	case nil:
+		// NO-OP
+	case string:
+		data = []byte(d)
+	case []byte:
+		data = d
+	default:
+		var err error
+		if data, err = marshal(d); err != nil {
+			return fmt.Errorf("%w: event data: %w", ErrMarshalResultFailed, err)
+		}
+	}
+
+	if e.ID != "" {
+		fmt.Fprintf(w, "id: %s\n", e.ID)
+	}
+	if e.Event != "" {
+		fmt.Fprintf(w, "event: %s\n", e.Event)
+	}
+	if e.Retry > 0 {
+		fmt.Fprintf(w, "retry: %s\n", strconv.FormatInt(e.Retry.Milliseconds(), 10))
+	}
+	if e.Data != nil {
+		// CRLF, CR and LF are each a line break in an event stream; data is
+		// written as a data field for each line
+		lines := strings.Split(lineBreaks.Replace(string(data)), "\n")
+		for _, line := range lines {
+			fmt.Fprintf(w, "data: %s\n", line)
+		}
+	}
+	_, err := w.WriteString("\n")
+	return err
+}
+
+// eventStream is Result content holding a sequence of events to be written
+// to a server-sent event stream.
+type eventStream struct {
+	each func(ctx context.Context, yield func(Event) bool)
+}
+
+// acceptsEventStream returns true if an Accept header accepts a server-sent
+// event stream.
+func acceptsEventStream(accept string) bool {
+	_, ok := negotiateContentType(accept, []string{eventStreamContentType})
+	return accept != "" && ok
+}
+
+// isEventStream returns true if a result is an event stream Result (see:
+// EventStream).
+func isEventStream(result any) bool {
+	r, ok := result.(*Result)
+	if !ok {
+		return false
+	}
+	_, ok = r.content.(*eventStream)
+	return ok
+}
+
+// isSuccessful returns true if a result is not an error and has (or implies)
+// a 2xx status.
+func isSuccessful(result any) bool {
+	switch r := result.(type) {
+	case *Error, *Problem, error:
+		return false
+	case *Result:
+		return r.statusCode < 300
+	case int:
+		return r < 300
+	}
+	return true
+}
+
+// makeStreamResponse implements the streamer interface for an eventStream.
+// A request with an Accept header that does not accept text/event-stream
+// receives a 406 Not Acceptable response.
+func (es *eventStream) makeStreamResponse(rq *Request, response *Response) *Response {
+	if rq.Request != nil {
+		if accept := strings.Join(rq.Header.Values("Accept"), ","); accept != "" && !acceptsEventStream(accept) {
+			return notAcceptable(rq).makeResponse(rq)
+		}
+	}
+	response.ContentType = eventStreamContentType
+	response.negotiated = true
+	if _, ok := response.headers["Cache-Control"]; !ok {
+		h := make(headers, len(response.headers)+1)
+		maps.Copy(h, response.headers)
+		h.set("Cache-Control", "no-cache")
+		response.headers = h
+	}
+	response.serve = func(rw http.ResponseWriter, rq *Request) {
+		rw.WriteHeader(response.StatusCode)
+		if rq.Method == http.MethodHead {
+			return
+		}
+
+		if err := es.write(rq, rw); err != nil {
+			rq.logError(InternalError{
+				Err:     err,
+				Message: "error writing event stream",
+				Request: rq.Request,
+			})
+		}
+	}
+	return response
+}
+
+// write writes the events in the stream to a response, flushing the response
+// after each event.  Writing stops if the request context is cancelled (e.g. if
+// the client disconnects) or an error occurs writing an event; any error other
+// than the cancellation of the context is returned.
+func (es *eventStream) write(rq *Request, rw http.ResponseWriter) error {
+	ctx := rq.Context()
+	rc := http.NewResponseController(rw)
+	bw := bufio.NewWriter(rw)
+
+	var err error
+	es.each(ctx, func(e Event) bool {
+		if ctx.Err() != nil {
+			return false
+		}
+		if err = e.write(bw, func(v any) ([]byte, error) {
+			_, content, err := rq.marshalContent(v)
+			return content, err
+		}); err != nil {
+			return false
+		}
+		if err = bw.Flush(); err != nil {
+			return false
+		}
+		if err = rc.Flush(); err != nil && !errors.Is(err, http.ErrNotSupported) {
+			return false
+		}
+		err = nil
+		return true
+	})
+	return err
+}
+
+// EventStream returns a 200 OK Result that writes a sequence of events to the
+// response as a server-sent event stream (Content-Type: text/event-stream).  The
+// sequence is a function with the signature of an iter.Seq[Event] (a
+// range-over-func iterator).
+//
+// Event data is marshalled using the content type negotiated for the request.
+// A request that accepts only text/event-stream is accepted, with event data
+// marshalled using the default content type (a 406 Not Acceptable response is
+// returned to such a request by an endpoint that returns a successful result
+// other than an event stream; errors are presented using the default content
+// type).  A request with an Accept header that does not accept
+// text/event-stream receives a 406 Not Acceptable response.
+//
+// The response is flushed after each event.  When the request context is
+// cancelled (e.g. the client disconnects) the sequence is stopped.  A client
+// reconnecting to a stream identifies the last event it received in a
+// Last-Event-ID header; use LastEventID to resume the stream from that event.
+//
+// If no Cache-Control header is set on the Result, "Cache-Control: no-cache" is
+// added to the response.
+//
+// # example
+//
+//	func Progress(ctx context.Context, rq *http.Request) any {
+//	    job := jobs.Get(rq.PathValue("id"))
+//	    return restapi.EventStream(func(yield func(restapi.Event) bool) {
+//	        for p := range job.ProgressSince(restapi.LastEventID(rq)) {
+//	            if !yield(restapi.Event{ID: p.Seq, Event: "progress", Data: p}) {
+//	                return
+//	            }
+//	        }
+//	    })
+//	}
+func EventStream(events func(yield func(Event) bool)) *Result {
+	return &Result{
+		statusCode: http.StatusOK,
+		content: &eventStream{each: func(_ context.Context, yield func(Event) bool) {
+			events(yield)
+		}},
+	}
+}
+
+// EventChannel returns a 200 OK Result that writes the events received from a
+// channel to the response as a server-sent event stream, until the channel is
+// closed.  See EventStream for details.
+//
+// If the request context is cancelled (e.g. the client disconnects) the stream
+// is stopped, even while waiting for an event, and no further events are
+// received from the channel; a producer sending events to the channel should
+// also observe the request context to avoid blocking indefinitely.
+func EventChannel(ch <-chan Event) *Result {
+	return &Result{
+		statusCode: http.StatusOK,
+		content: &eventStream{each: func(ctx context.Context, yield func(Event) bool) {
+			receive(ctx, ch, yield)
+		}},
+	}
+}
+
+// LastEventID returns the id of the last event received by a client
+// reconnecting to an event stream, identified by the Last-Event-ID request
+// header.  If the request has no Last-Event-ID header an empty string is
+// returned.
+func LastEventID(rq *http.Request) string {
+	return rq.Header.Get("Last-Event-ID")
+}
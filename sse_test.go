@@ -0,0 +1,301 @@
+package restapi
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/blugnu/test"
+)
+
+func TestEventStream(t *testing.T) {
+	// ARRANGE
+	serve := func(rq *http.Request, result any) *flushRecorder {
+		rec := &flushRecorder{ResponseRecorder: httptest.NewRecorder()}
+		HandlerFunc(func(context.Context, *http.Request) any { return result })(rec, rq)
+		return rec
+	}
+
+	testcases := []struct {
+		scenario string
+		exec     func(t *testing.T)
+	}{
+		{scenario: "events",
+			exec: func(t *testing.T) {
+				// ARRANGE
+				rq := httptest.NewRequest(http.MethodGet, "/", nil)
+				rq.Header.Set("Accept", "text/event-stream")
+
+				// ACT
+				rec := serve(rq, EventStream(func(yield func(Event) bool) {
+					_ = yield(Event{ID: "1", Event: "progress", Data: map[string]int{"pct": 50}, Retry: 5 * time.Second}) &&
+						yield(Event{Data: "line 1\nline 2"}) &&
+						yield(Event{ID: "3"})
+				}))
+
+				// ASSERT
+				test.That(t, rec.Code).Equals(http.StatusOK)
+				test.That(t, rec.Header().Get("Content-Type")).Equals("text/event-stream; charset=utf-8")
+				test.That(t, rec.Header().Get("Cache-Control")).Equals("no-cache")
+				test.That(t, rec.Body.String()).Equals("id: 1\nevent: progress\nretry: 5000\ndata: {\"pct\":50}\n\n" +
+					"data: line 1\ndata: line 2\n\n" +
+					"id: 3\n\n")
+				test.That(t, rec.flushes).Equals(3)
+			},
+		},
+		{scenario: "line breaks in data",
+			exec: func(t *testing.T) {
+				// ARRANGE
+				testcases := []struct {
+					name string
+					data any
+				}{
+					{name: "string", data: "a\rid: 666\r\nevent: evil\nb"},
+					{name: "[]byte", data: []byte("a\rid: 666\r\nevent: evil\nb")},
+					{name: "marshalled", data: struct{}{}},
+				}
+				marshal := func(any) ([]byte, error) { return []byte("a\rid: 666\r\nevent: evil\nb"), nil }
+				for _, tc := range testcases {
+					t.Run(tc.name, func(t *testing.T) {
+						// ARRANGE
+						buf := &bytes.Buffer{}
+						w := bufio.NewWriter(buf)
+
+						// ACT
+						err := Event{Data: tc.data}.write(w, marshal)
+						_ = w.Flush()
+
+						// ASSERT
+						test.Error(t, err).IsNil()
+						test.That(t, buf.String()).Equals("data: a\ndata: id: 666\ndata: event: evil\ndata: b\n\n")
+					})
+				}
+			},
+		},
+		{scenario: "negotiated data content type",
+			exec: func(t *testing.T) {
+				// ARRANGE
+				rq := httptest.NewRequest(http.MethodGet, "/", nil)
+				rq.Header.Set("Accept", "text/event-stream, text/json")
+
+				// ACT
+				rec := serve(rq, EventStream(func(yield func(Event) bool) {
+					yield(Event{Data: map[string]int{"pct": 50}})
+				}))
+
+				// ASSERT
+				test.That(t, rec.Header().Get("Content-Type")).Equals("text/event-stream; charset=utf-8")
+				test.That(t, rec.Body.String()).Equals("data: {\ndata:   \"pct\": 50\ndata: }\n\n")
+			},
+		},
+		{scenario: "Cache-Control set on Result",
+			exec: func(t *testing.T) {
+				// ARRANGE
+				rq := httptest.NewRequest(http.MethodGet, "/", nil)
+
+				// ACT
+				rec := serve(rq, EventStream(func(yield func(Event) bool) {}).
+					WithHeader("Cache-Control", "no-store"))
+
+				// ASSERT
+				test.That(t, rec.Header().Get("Cache-Control")).Equals("no-store")
+			},
+		},
+		{scenario: "channel",
+			exec: func(t *testing.T) {
+				// ARRANGE
+				rq := httptest.NewRequest(http.MethodGet, "/", nil)
+				ch := make(chan Event, 2)
+				ch <- Event{Data: "a"}
+				ch <- Event{Data: "b"}
+				close(ch)
+
+				// ACT
+				rec := serve(rq, EventChannel(ch))
+
+				// ASSERT
+				test.That(t, rec.Body.String()).Equals("data: a\n\ndata: b\n\n")
+			},
+		},
+		{scenario: "context cancelled",
+			exec: func(t *testing.T) {
+				// ARRANGE
+				ctx, cancel := context.WithCancel(context.Background())
+				rq := httptest.NewRequest(http.MethodGet, "/", nil).WithContext(ctx)
+				defer test.Using(&LogError, func(InternalError) { t.Error("error was logged") })()
+
+				// ACT
+				rec := serve(rq, EventStream(func(yield func(Event) bool) {
+					for i := 0; yield(Event{Data: []byte("tick")}); i++ {
+						if i == 1 {
+							cancel()
+						}
+					}
+				}))
+
+				// ASSERT
+				test.That(t, rec.Body.String()).Equals("data: tick\n\ndata: tick\n\n")
+			},
+		},
+		{scenario: "channel/idle/context cancelled",
+			exec: func(t *testing.T) {
+				// ARRANGE
+				ctx, cancel := context.WithCancel(context.Background())
+				rq := httptest.NewRequest(http.MethodGet, "/", nil).WithContext(ctx)
+				defer test.Using(&LogError, func(InternalError) { t.Error("error was logged") })()
+				ch := make(chan Event, 1)
+				ch <- Event{Data: "a"}
+				time.AfterFunc(10*time.Millisecond, cancel)
+
+				// ACT
+				done := make(chan *flushRecorder)
+				go func() { done <- serve(rq, EventChannel(ch)) }()
+
+				// ASSERT
+				select {
+				case rec := <-done:
+					test.That(t, rec.Body.String()).Equals("data: a\n\n")
+				case <-time.After(time.Second):
+					t.Error("handler blocked on an idle channel after the context was cancelled")
+				}
+			},
+		},
+		{scenario: "invalid event",
+			exec: func(t *testing.T) {
+				// ARRANGE
+				rq := httptest.NewRequest(http.MethodGet, "/", nil)
+				var logged *InternalError
+				defer test.Using(&LogError, func(e InternalError) { logged = &e })()
+
+				// ACT
+				rec := serve(rq, EventStream(func(yield func(Event) bool) {
+					_ = yield(Event{ID: "1\n2"}) && yield(Event{Data: "not written"})
+				}))
+
+				// ASSERT
+				test.That(t, rec.Body.String()).Equals("")
+				test.IsTrue(t, logged != nil)
+				test.Error(t, logged.Err).Is(ErrInvalidArgument)
+			},
+		},
+		{scenario: "marshalling error",
+			exec: func(t *testing.T) {
+				// ARRANGE
+				rq := httptest.NewRequest(http.MethodGet, "/", nil)
+				var logged *InternalError
+				defer test.Using(&LogError, func(e InternalError) { logged = &e })()
+
+				// ACT
+				_ = serve(rq, EventStream(func(yield func(Event) bool) {
+					yield(Event{Data: func() {}})
+				}))
+
+				// ASSERT
+				test.IsTrue(t, logged != nil)
+				test.Error(t, logged.Err).Is(ErrMarshalResultFailed)
+			},
+		},
+		{scenario: "event stream only/not an event stream result",
+			exec: func(t *testing.T) {
+				// ARRANGE
+				rq := httptest.NewRequest(http.MethodGet, "/", nil)
+				rq.Header.Set("Accept", "text/event-stream")
+				defer test.Using(&LogError, func(InternalError) {})()
+
+				// ACT
+				rec := serve(rq, OK().WithValue(map[string]int{"pct": 50}))
+
+				// ASSERT
+				test.That(t, rec.Code).Equals(http.StatusNotAcceptable)
+				test.That(t, rec.Header().Get("Content-Type")).Equals("application/json")
+			},
+		},
+		{scenario: "event stream only/not an event stream result/logged",
+			exec: func(t *testing.T) {
+				// ARRANGE
+				rq := httptest.NewRequest(http.MethodGet, "/", nil)
+				rq.Header.Set("Accept", "text/event-stream")
+				var logged *InternalError
+				defer test.Using(&LogError, func(e InternalError) { logged = &e })()
+
+				// ACT
+				_ = serve(rq, map[string]int{"pct": 50})
+
+				// ASSERT
+				test.IsTrue(t, logged != nil)
+				test.Error(t, logged.Err).Is(ErrInvalidAcceptHeader)
+				test.That(t, logged.Message).Equals("content negotiation failed")
+			},
+		},
+		{scenario: "event stream only/error results",
+			exec: func(t *testing.T) {
+				testcases := []struct {
+					scenario    string
+					result      any
+					statusCode  int
+					contentType string
+				}{
+					{scenario: "Error", result: NewError(http.StatusUnauthorized), statusCode: http.StatusUnauthorized, contentType: "application/json"},
+					{scenario: "error", result: errors.New("failed"), statusCode: http.StatusInternalServerError, contentType: "application/json"},
+					{scenario: "Problem", result: NewProblem(http.StatusForbidden), statusCode: http.StatusForbidden, contentType: "application/problem+json"},
+					{scenario: "status", result: http.StatusNotFound, statusCode: http.StatusNotFound},
+				}
+				for _, tc := range testcases {
+					t.Run(tc.scenario, func(t *testing.T) {
+						// ARRANGE
+						rq := httptest.NewRequest(http.MethodGet, "/", nil)
+						rq.Header.Set("Accept", "text/event-stream")
+						defer test.Using(&LogError, func(InternalError) {})()
+
+						// ACT
+						rec := serve(rq, tc.result)
+
+						// ASSERT
+						test.That(t, rec.Code).Equals(tc.statusCode)
+						test.That(t, rec.Header().Get("Content-Type")).Equals(tc.contentType)
+					})
+				}
+			},
+		},
+		{scenario: "event stream not accepted",
+			exec: func(t *testing.T) {
+				// ARRANGE
+				rq := httptest.NewRequest(http.MethodGet, "/", nil)
+				rq.Header.Set("Accept", "application/json")
+
+				// ACT
+				rec := serve(rq, EventStream(func(yield func(Event) bool) {
+					yield(Event{Data: "not written"})
+				}))
+
+				// ASSERT
+				test.That(t, rec.Code).Equals(http.StatusNotAcceptable)
+				test.That(t, rec.Header().Get("Content-Type")).Equals("application/json")
+				test.String(t, rec.Body.String()).Contains(`"acceptable"`)
+			},
+		},
+		{scenario: "LastEventID",
+			exec: func(t *testing.T) {
+				// ARRANGE
+				rq := httptest.NewRequest(http.MethodGet, "/", nil)
+				rq.Header.Set("Last-Event-ID", "42")
+
+				// ACT
+				result := LastEventID(rq)
+
+				// ASSERT
+				test.That(t, result).Equals("42")
+			},
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.scenario, func(t *testing.T) {
+			tc.exec(t)
+		})
+	}
+}
@@ -0,0 +1,267 @@
+package restapi
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// function variables to facilitate testing
+var (
+	// streamFlushInterval is the maximum time between flushes of a streamed
+	// response
+	streamFlushInterval = time.Second
+
+	// streamFlushItems is the maximum number of items written to a streamed
+	// response between flushes
+	streamFlushItems = 100
+)
+
+// streamer is implemented by Result content that is written directly to the
+// response rather than marshalled in full before the response is written.
+type streamer interface {
+	// makeStreamResponse completes a response initialised with the status,
+	// headers and validators of a Result, or returns an alternative (error)
+	// response if the content cannot be streamed to the request.
+	makeStreamResponse(rq *Request, response *Response) *Response
+}
+
+// sequence is Result content holding a sequence of items to be streamed to
+// the response.  The sequence is provided with the request context, to stop
+// (e.g. when the client disconnects) while waiting for an item.
+type sequence struct {
+	each func(ctx context.Context, yield func(any) bool)
+}
+
+// delimiters specifies the strings written before the first item of a streamed
+// sequence (open), between items (sep), after each item (term) and after the
+// last item (close).
+type delimiters struct {
+	open, sep, term, close string
+}
+
+// sequenceFormat identifies the format of a streamed sequence according to
+// the content type of the response: "json" (a json array), "ndjson" (newline
+// delimited json) or "xml" (elements in an <items> root element).  An empty
+// string is returned if the content type does not support sequences.
+func sequenceFormat(contentType string) string {
+	mt, _, _ := mime.ParseMediaType(contentType)
+	switch {
+	case mt == "application/x-ndjson":
+		return "ndjson"
+	case mt == "application/json" || mt == "text/json" || strings.HasSuffix(mt, "+json"):
+		return "json"
+	case mt == "application/xml" || mt == "text/xml" || strings.HasSuffix(mt, "+xml"):
+		return "xml"
+	default:
+		return ""
+	}
+}
+
+// makeStreamResponse implements the streamer interface for a sequence.
+func (seq *sequence) makeStreamResponse(rq *Request, response *Response) *Response {
+	format := sequenceFormat(rq.Accept)
+	if format == "" {
+		return NewError(http.StatusNotAcceptable, ErrInvalidAcceptHeader, rq.Request).
+			WithHelp("streamed results are supported for json, newline delimited json and xml content types").
+			makeResponse(rq)
+	}
+
+	d := map[string]delimiters{
+		"json":   {open: "[", sep: ",", close: "]"},
+		"ndjson": {term: "\n"},
+		"xml":    {open: "<items>", close: "</items>"},
+	}[format]
+
+	// each item in a newline delimited json sequence is marshalled as json
+	// (the negotiated marshaller would marshal the elements of a slice item
+	// as separate lines); a sequence is not a hypermedia document, so is
+	// presented as application/json if a hypermedia content type is negotiated
+	marshal := rq.MarshalContent
+	response.ContentType = rq.Accept
+	switch {
+	case format == "ndjson":
+		marshal = json.Marshal
+	case isHypermedia(rq.Accept):
+		marshal = json.Marshal
+		response.ContentType = "application/json"
+	}
+
+	response.negotiated = true
+	response.serve = func(rw http.ResponseWriter, rq *Request) {
+		rw.WriteHeader(response.StatusCode)
+		if rq.Method == http.MethodHead {
+			return
+		}
+
+		if err := seq.write(rq.Context(), rw, marshal, d); err != nil &&
+			!errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded) {
+			rq.logError(InternalError{
+				Err:     err,
+				Message: "error writing streamed response",
+				Help:    "the response was incomplete",
+				Request: rq.Request,
+			})
+		}
+	}
+	return response
+}
+
+// write writes the items in the sequence to a response, each marshalled using
+// a specified function and delimited as specified.  The response is flushed
+// periodically.
+//
+// Writing stops if the context is cancelled (e.g. if the client disconnects) or
+// an error occurs writing to the response or marshalling an item; the error
+// is returned and the response is incomplete.
+func (seq *sequence) write(
+	ctx context.Context,
+	rw http.ResponseWriter,
+	marshal MarshalFunc,
+	d delimiters,
+) error {
+	rc := http.NewResponseController(rw)
+	bw := bufio.NewWriter(rw)
+
+	lastFlush := time.Now()
+	flush := func() error {
+		if err := bw.Flush(); err != nil {
+			return err
+		}
+		lastFlush = time.Now()
+		if err := rc.Flush(); err != nil && !errors.Is(err, http.ErrNotSupported) {
+			return err
+		}
+		return nil
+	}
+
+	if _, err := bw.WriteString(d.open); err != nil {
+		return err
+	}
+
+	var err error
+	n := 0
+	seq.each(ctx, func(item any) bool {
+		if err = ctx.Err(); err != nil {
+			return false
+		}
+
+		var b []byte
+		if b, err = marshal(item); err != nil {
+			err = fmt.Errorf("%w: item %d: %w", ErrMarshalResultFailed, n, err)
+			return false
+		}
+		if n > 0 {
+			if _, err = bw.WriteString(d.sep); err != nil {
+				return false
+			}
+		}
+		if _, err = bw.Write(b); err != nil {
+			return false
+		}
+		if _, err = bw.WriteString(d.term); err != nil {
+			return false
+		}
+
+		n++
+		if n%streamFlushItems == 0 || time.Since(lastFlush) >= streamFlushInterval {
+			err = flush()
+		}
+		return err == nil
+	})
+	if err == nil {
+		// the sequence may have stopped because the context was cancelled
+		err = ctx.Err()
+	}
+	if err != nil {
+		return err
+	}
+
+	if _, err := bw.WriteString(d.close); err != nil {
+		return err
+	}
+	return flush()
+}
+
+// Stream returns a 200 OK Result that streams a sequence of items to the
+// response, with each item marshalled as it is obtained from the sequence.  The
+// sequence is a function with the signature of an iter.Seq[T] (a range-over-func
+// iterator).
+//
+// The format of the response depends on the content type negotiated for the
+// request:
+//
+//	json (application/json, text/json, +json)    // a json array
+//	application/x-ndjson                          // one json value per line
+//	xml (application/xml, text/xml, +xml)         // elements in an <items> root element
+//
+// If the negotiated content type does not support sequences, a 406 Not
+// Acceptable Error is returned instead.
+//
+// The response is flushed periodically as items are written.  If the request
+// context is cancelled (e.g. the client disconnects) the sequence is stopped
+// and the response is abandoned.  Since the status and headers of the response
+// have been written before any items are marshalled, an error marshalling an
+// item also stops the sequence, leaving the response incomplete; the error is
+// logged.
+//
+// The status and headers of the Result may be set using the usual methods.
+//
+// # example
+//
+//	func ExportOrders(ctx context.Context, rq *http.Request) any {
+//	    return restapi.Stream(func(yield func(Order) bool) {
+//	        rows := db.QueryOrders(ctx)
+//	        defer rows.Close()
+//	        for rows.Next() {
+//	            if !yield(rows.Order()) {
+//	                return
+//	            }
+//	        }
+//	    })
+//	}
+func Stream[T any](seq func(yield func(T) bool)) *Result {
+	return &Result{
+		statusCode: http.StatusOK,
+		content: &sequence{each: func(_ context.Context, yield func(any) bool) {
+			seq(func(item T) bool { return yield(item) })
+		}},
+	}
+}
+
+// StreamChannel returns a 200 OK Result that streams the items received from a
+// channel to the response, until the channel is closed.  See Stream for details.
+//
+// If the request context is cancelled (e.g. the client disconnects) the
+// response is abandoned, even while waiting for an item, and no further items
+// are received from the channel; a producer sending items to the channel should
+// also observe the request context to avoid blocking indefinitely.
+func StreamChannel[T any](ch <-chan T) *Result {
+	return &Result{
+		statusCode: http.StatusOK,
+		content: &sequence{each: func(ctx context.Context, yield func(any) bool) {
+			receive(ctx, ch, func(item T) bool { return yield(item) })
+		}},
+	}
+}
+
+// receive yields the items received from a channel until the channel is
+// closed, the yield function returns false or the context is cancelled.
+func receive[T any](ctx context.Context, ch <-chan T, yield func(T) bool) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case item, ok := <-ch:
+			if !ok || !yield(item) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,279 @@
+package restapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/blugnu/test"
+)
+
+// flushRecorder is a ResponseRecorder that counts the number of times the
+// response is flushed.
+type flushRecorder struct {
+	*httptest.ResponseRecorder
+	flushes int
+}
+
+func (r *flushRecorder) Flush() {
+	r.flushes++
+	r.ResponseRecorder.Flush()
+}
+
+func TestStream(t *testing.T) {
+	// ARRANGE
+	type item struct {
+		ID int `json:"id" xml:"id"`
+	}
+	items := func(n int) func(func(item) bool) {
+		return func(yield func(item) bool) {
+			for i := 1; i <= n; i++ {
+				if !yield(item{ID: i}) {
+					return
+				}
+			}
+		}
+	}
+	serve := func(rq *http.Request, result any) *flushRecorder {
+		rec := &flushRecorder{ResponseRecorder: httptest.NewRecorder()}
+		HandlerFunc(func(context.Context, *http.Request) any { return result })(rec, rq)
+		return rec
+	}
+
+	testcases := []struct {
+		scenario string
+		exec     func(t *testing.T)
+	}{
+		{scenario: "json",
+			exec: func(t *testing.T) {
+				// ARRANGE
+				rq := httptest.NewRequest(http.MethodGet, "/", nil)
+
+				// ACT
+				rec := serve(rq, Stream(items(3)).WithHeader("X-Export", "orders"))
+
+				// ASSERT
+				test.That(t, rec.Code).Equals(http.StatusOK)
+				test.That(t, rec.Header().Get("Content-Type")).Equals("application/json")
+				test.That(t, rec.Header().Get("X-Export")).Equals("orders")
+				test.That(t, rec.Body.String()).Equals(`[{"id":1},{"id":2},{"id":3}]`)
+			},
+		},
+		{scenario: "json/empty",
+			exec: func(t *testing.T) {
+				// ARRANGE
+				rq := httptest.NewRequest(http.MethodGet, "/", nil)
+
+				// ACT
+				rec := serve(rq, Stream(items(0)))
+
+				// ASSERT
+				test.That(t, rec.Body.String()).Equals(`[]`)
+			},
+		},
+		{scenario: "ndjson",
+			exec: func(t *testing.T) {
+				// ARRANGE
+				rq := httptest.NewRequest(http.MethodGet, "/", nil)
+				rq.Header.Set("Accept", "application/x-ndjson")
+
+				// ACT
+				rec := serve(rq, Stream(func(yield func([]int) bool) {
+					_ = yield([]int{1, 2}) && yield([]int{3})
+				}))
+
+				// ASSERT
+				test.That(t, rec.Header().Get("Content-Type")).Equals("application/x-ndjson")
+				test.That(t, rec.Body.String()).Equals("[1,2]\n[3]\n")
+			},
+		},
+		{scenario: "xml",
+			exec: func(t *testing.T) {
+				// ARRANGE
+				rq := httptest.NewRequest(http.MethodGet, "/", nil)
+				rq.Header.Set("Accept", "application/xml")
+
+				// ACT
+				rec := serve(rq, Stream(items(2)))
+
+				// ASSERT
+				test.That(t, rec.Header().Get("Content-Type")).Equals("application/xml")
+				test.That(t, rec.Body.String()).Equals(`<items><item><id>1</id></item><item><id>2</id></item></items>`)
+			},
+		},
+		{scenario: "channel",
+			exec: func(t *testing.T) {
+				// ARRANGE
+				rq := httptest.NewRequest(http.MethodGet, "/", nil)
+				ch := make(chan int, 3)
+				ch <- 1
+				ch <- 2
+				close(ch)
+
+				// ACT
+				rec := serve(rq, StreamChannel(ch))
+
+				// ASSERT
+				test.That(t, rec.Body.String()).Equals(`[1,2]`)
+			},
+		},
+		{scenario: "HEAD",
+			exec: func(t *testing.T) {
+				// ARRANGE
+				rq := httptest.NewRequest(http.MethodHead, "/", nil)
+				isCalled := false
+
+				// ACT
+				rec := serve(rq, Stream(func(yield func(int) bool) { isCalled = true }))
+
+				// ASSERT
+				test.That(t, rec.Code).Equals(http.StatusOK)
+				test.IsFalse(t, isCalled)
+			},
+		},
+		{scenario: "unsupported content type",
+			exec: func(t *testing.T) {
+				// ARRANGE
+				m := NewMarshallers()
+				m.Register("application/cbor", json.Marshal)
+				s := &Server{Marshallers: m}
+				rq := httptest.NewRequest(http.MethodGet, "/", nil)
+				rq.Header.Set("Accept", "application/cbor")
+				rec := httptest.NewRecorder()
+
+				// ACT
+				s.HandlerFunc(func(context.Context, *http.Request) any { return Stream(items(1)) })(rec, rq)
+
+				// ASSERT
+				test.That(t, rec.Code).Equals(http.StatusNotAcceptable)
+			},
+		},
+		{scenario: "flushes periodically",
+			exec: func(t *testing.T) {
+				// ARRANGE
+				defer test.Using(&streamFlushItems, 2)()
+				rq := httptest.NewRequest(http.MethodGet, "/", nil)
+
+				// ACT
+				rec := serve(rq, Stream(items(5)))
+
+				// ASSERT
+				test.That(t, rec.flushes).Equals(3) // after items 2 and 4, and at the end
+			},
+		},
+		{scenario: "flushes after interval",
+			exec: func(t *testing.T) {
+				// ARRANGE
+				defer test.Using(&streamFlushInterval, time.Duration(0))()
+				rq := httptest.NewRequest(http.MethodGet, "/", nil)
+
+				// ACT
+				rec := serve(rq, Stream(items(3)))
+
+				// ASSERT
+				test.That(t, rec.flushes).Equals(4)
+			},
+		},
+		{scenario: "context cancelled",
+			exec: func(t *testing.T) {
+				// ARRANGE
+				ctx, cancel := context.WithCancel(context.Background())
+				rq := httptest.NewRequest(http.MethodGet, "/", nil).WithContext(ctx)
+				defer test.Using(&LogError, func(InternalError) { t.Error("error was logged") })()
+				yielded := 0
+
+				// ACT
+				rec := serve(rq, Stream(func(yield func(int) bool) {
+					for i := 1; yield(i); i++ {
+						yielded++
+						if i == 2 {
+							cancel()
+						}
+					}
+				}))
+
+				// ASSERT
+				test.That(t, yielded).Equals(2)
+				test.IsFalse(t, strings.HasSuffix(rec.Body.String(), "]"))
+			},
+		},
+		{scenario: "channel/idle/context cancelled",
+			exec: func(t *testing.T) {
+				// ARRANGE
+				ctx, cancel := context.WithCancel(context.Background())
+				rq := httptest.NewRequest(http.MethodGet, "/", nil).WithContext(ctx)
+				defer test.Using(&LogError, func(InternalError) { t.Error("error was logged") })()
+				ch := make(chan int)
+				time.AfterFunc(10*time.Millisecond, cancel)
+
+				// ACT
+				done := make(chan *flushRecorder)
+				go func() { done <- serve(rq, StreamChannel(ch)) }()
+
+				// ASSERT
+				select {
+				case rec := <-done:
+					test.That(t, rec.Body.String()).Equals("")
+				case <-time.After(time.Second):
+					t.Error("handler blocked on an idle channel after the context was cancelled")
+				}
+			},
+		},
+		{scenario: "marshalling error",
+			exec: func(t *testing.T) {
+				// ARRANGE
+				rq := httptest.NewRequest(http.MethodGet, "/", nil)
+				var logged *InternalError
+				defer test.Using(&LogError, func(e InternalError) { logged = &e })()
+
+				// ACT
+				rec := serve(rq, Stream(func(yield func(any) bool) {
+					_ = yield(1) && yield(func() {}) && yield(3)
+				}))
+
+				// ASSERT
+				test.That(t, rec.Code).Equals(http.StatusOK)
+				test.IsFalse(t, strings.HasSuffix(rec.Body.String(), "]"))
+				test.IsTrue(t, logged != nil)
+				test.Error(t, logged.Err).Is(ErrMarshalResultFailed)
+			},
+		},
+		{scenario: "write error",
+			exec: func(t *testing.T) {
+				// ARRANGE
+				rq := httptest.NewRequest(http.MethodGet, "/", nil)
+				rwerr := errors.New("write error")
+				var logged *InternalError
+				defer test.Using(&LogError, func(e InternalError) { logged = &e })()
+				rw := &failingWriter{ResponseRecorder: httptest.NewRecorder(), err: rwerr}
+
+				// ACT
+				HandlerFunc(func(context.Context, *http.Request) any {
+					return Stream(items(1))
+				})(rw, rq)
+
+				// ASSERT
+				test.IsTrue(t, logged != nil)
+				test.Error(t, logged.Err).Is(rwerr)
+			},
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.scenario, func(t *testing.T) {
+			tc.exec(t)
+		})
+	}
+}
+
+// failingWriter is a ResponseRecorder that fails to write any content.
+type failingWriter struct {
+	*httptest.ResponseRecorder
+	err error
+}
+
+func (w *failingWriter) Write([]byte) (int, error) { return 0, w.err }
@@ -0,0 +1,273 @@
+package restapi
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// Validator is implemented by types that provide their own validation.
+//
+// A Validate method may return FieldErrors to identify specific invalid fields;
+// these are combined with any errors identified by `validate` struct tags.  Any
+// other error is reported as applying to the value as a whole.
+type Validator interface {
+	Validate() error
+}
+
+// FieldError describes an invalid field, identified by a JSON pointer
+// (RFC 6901) to the field, together with the reason the field is invalid.
+type FieldError struct {
+	Pointer string `json:"pointer" xml:"pointer"`
+	Reason  string `json:"reason" xml:"reason"`
+}
+
+// FieldErrors is a collection of invalid fields.
+type FieldErrors []FieldError
+
+// Error implements the error interface for FieldErrors.
+func (e FieldErrors) Error() string {
+	s := make([]string, len(e))
+	for i, fe := range e {
+		s[i] = coalesce(fe.Pointer, "/") + ": " + fe.Reason
+	}
+	return strings.Join(s, "; ")
+}
+
+// patterns is a cache of compiled regular expressions used by `pattern` rules.
+var patterns = sync.Map{}
+
+// Validate validates a value using any `validate` struct tags on the fields of the
+// value (and any nested structs), followed by the Validate method of the value, if
+// it implements Validator.
+//
+// If the value is valid, nil is returned.  Otherwise an Error with a status of 422
+// Unprocessable Entity is returned, wrapping ErrValidationFailed, with an "errors"
+// property listing every invalid field (as FieldErrors).  If the value has no
+// invalid fields but the Validate method returns an *Error, that Error is returned.
+//
+// A `validate` tag provides a comma-separated list of rules:
+//
+//	required       // the field must not have a zero value
+//	min=n          // numbers: the value must be at least n
+//	               // strings, slices and maps: the length must be at least n
+//	max=n          // numbers: the value must be no more than n
+//	               // strings, slices and maps: the length must be no more than n
+//	len=n          // strings, slices and maps: the length must be exactly n
+//	oneof=a b c    // the value must be one of the space-separated values
+//	pattern=re     // strings: the value must match the regular expression
+//	               // (must be the last rule in the tag; the expression may
+//	               // contain commas)
+//
+// Fields are identified by JSON pointers derived from `json` tags (or field names).
+//
+// # panics
+//
+// Validate will panic with ErrInvalidArgument if a `validate` tag contains an
+// unknown or invalid rule.
+//
+// # example
+//
+//	type resource struct {
+//	    Name  string `json:"name" validate:"required,max=64"`
+//	    Email string `json:"email" validate:"pattern=^[^@]+@[^@]+$"`
+//	}
+func Validate(v any) error {
+	errs := FieldErrors{}
+	validateValue(reflect.ValueOf(v), "", &errs)
+
+	if vr, ok := v.(Validator); ok {
+		err := vr.Validate()
+
+		var (
+			apierr *Error
+			ferrs  FieldErrors
+		)
+		switch {
+		case err == nil:
+			// NO-OP
+		case errors.As(err, &ferrs):
+			errs = append(errs, ferrs...)
+		case errors.As(err, &apierr) && len(errs) == 0:
+			return apierr
+		default:
+			errs = append(errs, FieldError{Pointer: "", Reason: err.Error()})
+		}
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+	return UnprocessableEntity(ErrValidationFailed).
+		WithProperty("errors", errs)
+}
+
+// validateValue validates the fields of struct values, following pointers and
+// the elements of slices and arrays.
+func validateValue(v reflect.Value, pointer string, errs *FieldErrors) {
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if !v.IsNil() {
+			validateValue(v.Elem(), pointer, errs)
+		}
+
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			validateValue(v.Index(i), pointer+"/"+strconv.Itoa(i), errs)
+		}
+
+	case reflect.Struct:
+		validateStruct(v, pointer, errs)
+	}
+}
+
+// validateStruct applies the rules in the `validate` tags of the fields of a
+// struct value, then validates each field value.
+func validateStruct(v reflect.Value, pointer string, errs *FieldErrors) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		name, _, _ := strings.Cut(sf.Tag.Get("json"), ",")
+		switch {
+		case name == "-":
+			continue
+
+		case sf.Anonymous && name == "":
+			validateValue(v.Field(i), pointer, errs)
+			continue
+
+		case !sf.IsExported():
+			continue
+		}
+
+		fp := pointer + "/" + escapePointer(coalesce(name, sf.Name))
+		if tag, ok := sf.Tag.Lookup("validate"); ok {
+			if reason := applyRules(v.Field(i), tag); reason != "" {
+				*errs = append(*errs, FieldError{Pointer: fp, Reason: reason})
+				continue
+			}
+		}
+		validateValue(v.Field(i), fp, errs)
+	}
+}
+
+// escapePointer escapes a reference token in a JSON pointer (RFC 6901).
+func escapePointer(s string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(s, "~", "~0"), "/", "~1")
+}
+
+// applyRules applies the rules in a `validate` tag to a value, returning the reason
+// the value is invalid or an empty string if the value is valid.
+//
+// Rules (other than required) are not applied to nil pointers.
+func applyRules(v reflect.Value, tag string) string {
+	for tag != "" {
+		var rule string
+		if strings.HasPrefix(tag, "pattern=") {
+			rule, tag = tag, ""
+		} else {
+			rule, tag, _ = strings.Cut(tag, ",")
+		}
+		name, arg, _ := strings.Cut(strings.TrimSpace(rule), "=")
+
+		if name == "required" {
+			if v.IsZero() {
+				return "is required"
+			}
+			continue
+		}
+
+		rv := v
+		for rv.Kind() == reflect.Pointer {
+			if rv.IsNil() {
+				return ""
+			}
+			rv = rv.Elem()
+		}
+
+		if reason := applyRule(rv, name, arg); reason != "" {
+			return reason
+		}
+	}
+	return ""
+}
+
+// applyRule applies a single rule (other than required) to a value, returning the
+// reason the value is invalid or an empty string if the value is valid.
+func applyRule(v reflect.Value, rule string, arg string) string {
+	invalidRule := func() string {
+		panic(fmt.Errorf("%w: validate: invalid rule '%s=%s' for %s", ErrInvalidArgument, rule, arg, v.Type()))
+	}
+
+	switch rule {
+	case "min", "max", "len":
+		limit, err := strconv.ParseFloat(arg, 64)
+		if err != nil {
+			return invalidRule()
+		}
+
+		var (
+			n    float64
+			what = "length"
+		)
+		switch v.Kind() {
+		case reflect.String:
+			n = float64(utf8.RuneCountInString(v.String()))
+		case reflect.Slice, reflect.Array, reflect.Map:
+			n = float64(v.Len())
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			n, what = float64(v.Int()), "value"
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			n, what = float64(v.Uint()), "value"
+		case reflect.Float32, reflect.Float64:
+			n, what = v.Float(), "value"
+		default:
+			return invalidRule()
+		}
+
+		switch {
+		case rule == "min" && n < limit:
+			return fmt.Sprintf("%s must be at least %s", what, arg)
+		case rule == "max" && n > limit:
+			return fmt.Sprintf("%s must be no more than %s", what, arg)
+		case rule == "len" && what == "value":
+			return invalidRule()
+		case rule == "len" && n != limit:
+			return fmt.Sprintf("length must be %s", arg)
+		}
+
+	case "oneof":
+		s := fmt.Sprintf("%v", v.Interface())
+		for _, opt := range strings.Fields(arg) {
+			if s == opt {
+				return ""
+			}
+		}
+		return fmt.Sprintf("must be one of: %s", strings.Join(strings.Fields(arg), ", "))
+
+	case "pattern":
+		if v.Kind() != reflect.String {
+			return invalidRule()
+		}
+		re, ok := patterns.Load(arg)
+		if !ok {
+			compiled, err := regexp.Compile(arg)
+			if err != nil {
+				return invalidRule()
+			}
+			re, _ = patterns.LoadOrStore(arg, compiled)
+		}
+		if !re.(*regexp.Regexp).MatchString(v.String()) {
+			return fmt.Sprintf("must match the pattern: %s", arg)
+		}
+
+	default:
+		return invalidRule()
+	}
+	return ""
+}
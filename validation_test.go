@@ -0,0 +1,200 @@
+package restapi
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/blugnu/test"
+)
+
+type validated struct {
+	Name string `json:"name" validate:"required"`
+	err  error
+}
+
+func (v validated) Validate() error { return v.err }
+
+func TestValidate(t *testing.T) {
+	// ARRANGE
+	type address struct {
+		Line1    string `json:"line1" validate:"required,max=8"`
+		PostCode string `json:"post/code" validate:"pattern=^[0-9]{4,5}$"`
+	}
+	type embedded struct {
+		Ref string `json:"ref" validate:"len=3"`
+	}
+	type resource struct {
+		embedded
+		Name      string    `json:"name,omitempty" validate:"required,min=2,max=4"`
+		Age       int       `json:"age" validate:"min=18,max=65"`
+		Score     float64   `validate:"max=1.5"`
+		Status    string    `json:"status" validate:"oneof=open closed"`
+		Tags      []string  `json:"tags" validate:"max=2"`
+		Optional  *int      `json:"optional" validate:"min=1"`
+		Address   *address  `json:"address" validate:"required"`
+		Addresses []address `json:"addresses"`
+		Ignored   string    `json:"-" validate:"required"`
+	}
+
+	valid := func() *resource {
+		return &resource{
+			embedded: embedded{Ref: "abc"},
+			Name:     "abc",
+			Age:      18,
+			Status:   "open",
+			Address:  &address{Line1: "line 1", PostCode: "1234"},
+		}
+	}
+
+	testcases := []struct {
+		scenario string
+		exec     func(t *testing.T)
+	}{
+		{scenario: "valid",
+			exec: func(t *testing.T) {
+				// ACT
+				err := Validate(valid())
+
+				// ASSERT
+				test.Error(t, err).IsNil()
+			},
+		},
+		{scenario: "invalid",
+			exec: func(t *testing.T) {
+				// ARRANGE
+				v := &resource{
+					embedded:  embedded{Ref: "abcd"},
+					Name:      "a",
+					Age:       70,
+					Score:     2,
+					Status:    "pending",
+					Tags:      []string{"a", "b", "c"},
+					Optional:  test.AddressOf(0),
+					Addresses: []address{{Line1: "line 1", PostCode: "1234"}, {Line1: "too long line 1", PostCode: "12a"}},
+				}
+
+				// ACT
+				err := Validate(v)
+
+				// ASSERT
+				test.Error(t, err).Is(NewError(http.StatusUnprocessableEntity, ErrValidationFailed))
+				test.That(t, err.(*Error).properties["errors"]).Equals(FieldErrors{
+					{Pointer: "/ref", Reason: "length must be 3"},
+					{Pointer: "/name", Reason: "length must be at least 2"},
+					{Pointer: "/age", Reason: "value must be no more than 65"},
+					{Pointer: "/Score", Reason: "value must be no more than 1.5"},
+					{Pointer: "/status", Reason: "must be one of: open, closed"},
+					{Pointer: "/tags", Reason: "length must be no more than 2"},
+					{Pointer: "/optional", Reason: "value must be at least 1"},
+					{Pointer: "/address", Reason: "is required"},
+					{Pointer: "/addresses/1/line1", Reason: "length must be no more than 8"},
+					{Pointer: "/addresses/1/post~1code", Reason: "must match the pattern: ^[0-9]{4,5}$"},
+				})
+			},
+		},
+		{scenario: "invalid rule",
+			exec: func(t *testing.T) {
+				testcases := []any{
+					&struct {
+						A string `validate:"unknown"`
+					}{},
+					&struct {
+						A string `validate:"min=x"`
+					}{},
+					&struct {
+						A bool `validate:"max=1"`
+					}{},
+					&struct {
+						A int `validate:"len=1"`
+					}{},
+					&struct {
+						A int `validate:"pattern=."`
+					}{},
+					&struct {
+						A string `validate:"pattern=("`
+					}{},
+				}
+				for _, tc := range testcases {
+					t.Run("", func(t *testing.T) {
+						// ARRANGE
+						defer test.ExpectPanic(ErrInvalidArgument).Assert(t)
+
+						// ACT
+						_ = Validate(tc)
+					})
+				}
+			},
+		},
+		{scenario: "Validator/valid",
+			exec: func(t *testing.T) {
+				// ACT
+				err := Validate(validated{Name: "name"})
+
+				// ASSERT
+				test.Error(t, err).IsNil()
+			},
+		},
+		{scenario: "Validator/FieldErrors",
+			exec: func(t *testing.T) {
+				// ARRANGE
+				v := validated{err: FieldErrors{{Pointer: "/other", Reason: "is invalid"}}}
+
+				// ACT
+				err := Validate(v)
+
+				// ASSERT
+				test.Error(t, err).Is(ErrValidationFailed)
+				test.That(t, err.(*Error).properties["errors"]).Equals(FieldErrors{
+					{Pointer: "/name", Reason: "is required"},
+					{Pointer: "/other", Reason: "is invalid"},
+				})
+			},
+		},
+		{scenario: "Validator/*Error",
+			exec: func(t *testing.T) {
+				// ARRANGE
+				apierr := NewError(http.StatusConflict, "conflict")
+				v := validated{Name: "name", err: apierr}
+
+				// ACT
+				err := Validate(v)
+
+				// ASSERT
+				test.That(t, err).Equals(error(apierr))
+			},
+		},
+		{scenario: "Validator/other error",
+			exec: func(t *testing.T) {
+				// ARRANGE
+				v := validated{Name: "name", err: errors.New("invalid")}
+
+				// ACT
+				err := Validate(v)
+
+				// ASSERT
+				test.Error(t, err).Is(ErrValidationFailed)
+				test.That(t, err.(*Error).properties["errors"]).Equals(FieldErrors{
+					{Pointer: "", Reason: "invalid"},
+				})
+			},
+		},
+		{scenario: "FieldErrors/Error",
+			exec: func(t *testing.T) {
+				// ARRANGE
+				errs := FieldErrors{{Reason: "invalid"}, {Pointer: "/name", Reason: "is required"}}
+
+				// ACT
+				result := errs.Error()
+
+				// ASSERT
+				test.That(t, result).Equals("/: invalid; /name: is required")
+			},
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.scenario, func(t *testing.T) {
+			tc.exec(t)
+		})
+	}
+}